@@ -7,6 +7,28 @@ const (
 	EnableMouseTracking            = "\x1b[?1003h"
 	DisableMouseTracking           = "\x1b[?1003l"
 	DisableNormalMouseTracking     = "\x1b[?1000l"
+	EnableBracketedPaste           = "\x1b[?2004h"
+	DisableBracketedPaste          = "\x1b[?2004l"
+	BracketedPasteStart            = "\x1b[200~"
+	BracketedPasteEnd              = "\x1b[201~"
+	EnableFocusReporting           = "\x1b[?1004h"
+	DisableFocusReporting          = "\x1b[?1004l"
+	FocusIn                        = "\x1b[I"
+	FocusOut                       = "\x1b[O"
+	PushTitle                      = "\x1b[22;0t"
+	PopTitle                       = "\x1b[23;0t"
+	SetTitleStart                  = "\x1b]0;"
+	SetPointerShapeStart           = "\x1b]22;"
+	NotifyStart                    = "\x1b]9;"
+	NotifyURxvtStart               = "\x1b]777;notify;"
+	Bell                           = "\x07"
+
+	QueryPrimaryDeviceAttributes = "\x1b[c"
+	QueryTerminalVersion         = "\x1b[>0q"
+	QueryTermcapStart            = "\x1bP+q"
+	QueryWindowSizeInPixels      = "\x1b[14t"
+	QueryCellSizeInPixels        = "\x1b[16t"
+	StringTerminator             = "\x1b\\"
 
 	HideCursor = "\x1b[?25l"
 	ShowCursor = "\x1b[?25h"
@@ -0,0 +1,241 @@
+package framebuffertoansi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mmulet/term.everything/escapecodes"
+)
+
+/**
+ * How the colors in a CellGrid map to
+ * escape codes. Only the modes where a cell's
+ * look is fully described by its glyph, fg and bg
+ * can be diffed.
+ */
+type CellColorMode int
+
+const (
+	CellColorMode_Unsupported CellColorMode = iota
+	CellColorMode_Truecolor
+	CellColorMode_Indexed256
+	CellColorMode_Indexed16
+)
+
+/**
+ * The symbols drawn by chafa, one per cell.
+ * Colors are packed 0xRRGGBB in truecolor mode,
+ * palette indices otherwise, and -1 for the
+ * terminal's default color.
+ */
+type CellGrid struct {
+	Width  int
+	Height int
+	/**
+	 * 0 for the right half of a wide character
+	 */
+	Chars []rune
+	Fg    []int32
+	Bg    []int32
+}
+
+func MakeCellGrid(width, height int) *CellGrid {
+	return &CellGrid{
+		Width:  width,
+		Height: height,
+		Chars:  make([]rune, width*height),
+		Fg:     make([]int32, width*height),
+		Bg:     make([]int32, width*height),
+	}
+}
+
+func (g *CellGrid) SameSize(other *CellGrid) bool {
+	return other != nil && g.Width == other.Width && g.Height == other.Height
+}
+
+func (g *CellGrid) cellEqual(other *CellGrid, i int) bool {
+	return g.Chars[i] == other.Chars[i] &&
+		g.Fg[i] == other.Fg[i] &&
+		g.Bg[i] == other.Bg[i]
+}
+
+/**
+ * Reprinting a few unchanged cells is cheaper
+ * than moving the cursor over them.
+ */
+const maxUnchangedGapInRun = 3
+
+/**
+ * Escape codes that turn the terminal showing previous
+ * into one showing g. The grid's top left cell is at
+ * (originRow, originCol), 1 based like the CUP escape code.
+ * previous must be the same size as g.
+ */
+func (g *CellGrid) Diff(previous *CellGrid, colorMode CellColorMode, originRow, originCol int) string {
+	var sb strings.Builder
+	pen := cellPen{valid: false}
+
+	for y := range g.Height {
+		row := y * g.Width
+		x := 0
+		for x < g.Width {
+			if g.cellEqual(previous, row+x) {
+				x++
+				continue
+			}
+			start := x
+			end := x + 1
+			gap := 0
+			for next := end; next < g.Width && gap <= maxUnchangedGapInRun; next++ {
+				if g.cellEqual(previous, row+next) {
+					gap++
+					continue
+				}
+				gap = 0
+				end = next + 1
+			}
+			/**
+			 * Never start on the right half of a wide
+			 * character or cut one off at the end.
+			 */
+			for start > 0 && g.Chars[row+start] == 0 {
+				start--
+			}
+			for end < g.Width && g.Chars[row+end] == 0 {
+				end++
+			}
+
+			writeMoveCursor(&sb, originRow+y, originCol+start)
+
+			for i := row + start; i < row+end; i++ {
+				if g.Chars[i] == 0 {
+					continue
+				}
+				pen.set(&sb, colorMode, g.Fg[i], g.Bg[i])
+				sb.WriteRune(g.Chars[i])
+			}
+			x = end
+		}
+	}
+	if pen.valid {
+		sb.WriteString(escapecodes.Reset)
+	}
+	return sb.String()
+}
+
+/**
+ * The whole grid, starting at the cursor position.
+ */
+func (g *CellGrid) Print(colorMode CellColorMode) string {
+	var sb strings.Builder
+	for y := range g.Height {
+		pen := cellPen{valid: false}
+		for x := range g.Width {
+			i := y*g.Width + x
+			if g.Chars[i] == 0 {
+				continue
+			}
+			pen.set(&sb, colorMode, g.Fg[i], g.Bg[i])
+			sb.WriteRune(g.Chars[i])
+		}
+		sb.WriteString(escapecodes.Reset)
+		if y < g.Height-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+/**
+ * The whole grid with its top left cell at
+ * (originRow, originCol), for when the grid
+ * doesn't start at the left edge of the terminal.
+ */
+func (g *CellGrid) PrintAt(colorMode CellColorMode, originRow, originCol int) string {
+	var sb strings.Builder
+	for y := range g.Height {
+		writeMoveCursor(&sb, originRow+y, originCol)
+		pen := cellPen{valid: false}
+		for x := range g.Width {
+			i := y*g.Width + x
+			if g.Chars[i] == 0 {
+				continue
+			}
+			pen.set(&sb, colorMode, g.Fg[i], g.Bg[i])
+			sb.WriteRune(g.Chars[i])
+		}
+		sb.WriteString(escapecodes.Reset)
+	}
+	return sb.String()
+}
+
+/**
+ * CUP, 1 based
+ */
+func writeMoveCursor(sb *strings.Builder, row, col int) {
+	sb.WriteString("\x1b[")
+	sb.WriteString(strconv.Itoa(row))
+	sb.WriteByte(';')
+	sb.WriteString(strconv.Itoa(col))
+	sb.WriteByte('H')
+}
+
+type cellPen struct {
+	valid bool
+	fg    int32
+	bg    int32
+}
+
+func (p *cellPen) set(sb *strings.Builder, colorMode CellColorMode, fg, bg int32) {
+	if p.valid && p.fg == fg && p.bg == bg {
+		return
+	}
+	sb.WriteString("\x1b[")
+	writeSGRColor(sb, colorMode, fg, false)
+	sb.WriteByte(';')
+	writeSGRColor(sb, colorMode, bg, true)
+	sb.WriteByte('m')
+	p.valid = true
+	p.fg = fg
+	p.bg = bg
+}
+
+func writeSGRColor(sb *strings.Builder, colorMode CellColorMode, color int32, background bool) {
+	if color < 0 {
+		if background {
+			sb.WriteString("49")
+		} else {
+			sb.WriteString("39")
+		}
+		return
+	}
+	switch colorMode {
+	case CellColorMode_Truecolor:
+		if background {
+			sb.WriteString("48;2;")
+		} else {
+			sb.WriteString("38;2;")
+		}
+		sb.WriteString(strconv.Itoa(int(color>>16) & 0xff))
+		sb.WriteByte(';')
+		sb.WriteString(strconv.Itoa(int(color>>8) & 0xff))
+		sb.WriteByte(';')
+		sb.WriteString(strconv.Itoa(int(color) & 0xff))
+	case CellColorMode_Indexed256:
+		if background {
+			sb.WriteString("48;5;")
+		} else {
+			sb.WriteString("38;5;")
+		}
+		sb.WriteString(strconv.Itoa(int(color)))
+	default:
+		base := 30
+		if color >= 8 {
+			base = 90 - 8
+		}
+		if background {
+			base += 10
+		}
+		sb.WriteString(strconv.Itoa(base + int(color)))
+	}
+}
@@ -0,0 +1,155 @@
+package framebuffertoansi
+
+import (
+	"testing"
+)
+
+/**
+ * One row per string, \x00 is the right half of
+ * a wide character. Every cell gets the same fg
+ * and bg.
+ */
+func makeTestGrid(fg, bg int32, rows ...string) *CellGrid {
+	width := len([]rune(rows[0]))
+	grid := MakeCellGrid(width, len(rows))
+	for y, row := range rows {
+		for x, c := range []rune(row) {
+			i := y*width + x
+			grid.Chars[i] = c
+			grid.Fg[i] = fg
+			grid.Bg[i] = bg
+		}
+	}
+	return grid
+}
+
+func TestCellGridDiff(t *testing.T) {
+	const defaultPen = "\x1b[39;49m"
+	const reset = "\x1b[0m"
+	tests := []struct {
+		name      string
+		previous  *CellGrid
+		next      *CellGrid
+		colorMode CellColorMode
+		originRow int
+		originCol int
+		want      string
+	}{
+		{
+			name:      "nothing changed",
+			previous:  makeTestGrid(-1, -1, "abcdef", "ghijkl"),
+			next:      makeTestGrid(-1, -1, "abcdef", "ghijkl"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "",
+		},
+		{
+			name:      "one cell",
+			previous:  makeTestGrid(-1, -1, "abcdef"),
+			next:      makeTestGrid(-1, -1, "abXdef"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;3H" + defaultPen + "X" + reset,
+		},
+		{
+			name:      "origin is added",
+			previous:  makeTestGrid(-1, -1, "ab", "cd"),
+			next:      makeTestGrid(-1, -1, "ab", "Xd"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 5, originCol: 10,
+			want: "\x1b[6;10H" + defaultPen + "X" + reset,
+		},
+		{
+			name:      "short gap is reprinted",
+			previous:  makeTestGrid(-1, -1, "abcdef"),
+			next:      makeTestGrid(-1, -1, "XbcdYf"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H" + defaultPen + "XbcdY" + reset,
+		},
+		{
+			name:      "long gap moves the cursor",
+			previous:  makeTestGrid(-1, -1, "abcdefg"),
+			next:      makeTestGrid(-1, -1, "XbcdeYg"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H" + defaultPen + "X" + "\x1b[1;6HY" + reset,
+		},
+		{
+			name:      "last cell",
+			previous:  makeTestGrid(-1, -1, "abc"),
+			next:      makeTestGrid(-1, -1, "abX"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;3H" + defaultPen + "X" + reset,
+		},
+		{
+			name:      "changes on two rows",
+			previous:  makeTestGrid(-1, -1, "abc", "def"),
+			next:      makeTestGrid(-1, -1, "Xbc", "deY"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H" + defaultPen + "X" + "\x1b[2;3HY" + reset,
+		},
+		{
+			name:      "wide character printed whole",
+			previous:  makeTestGrid(-1, -1, "a日\x00b"),
+			next:      makeTestGrid(-1, -1, "a本\x00b"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;2H" + defaultPen + "本" + reset,
+		},
+		{
+			name:      "change on the right half of a wide character",
+			previous:  makeTestGrid(-1, -1, "a日Zb"),
+			next:      makeTestGrid(-1, -1, "a日\x00b"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;2H" + defaultPen + "日" + reset,
+		},
+		{
+			name:      "only the colors changed",
+			previous:  makeTestGrid(-1, -1, "a"),
+			next:      makeTestGrid(0x102030, 0xff0000, "a"),
+			colorMode: CellColorMode_Truecolor,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H\x1b[38;2;16;32;48;48;2;255;0;0ma" + reset,
+		},
+		{
+			name:      "256 colors",
+			previous:  makeTestGrid(-1, -1, "a"),
+			next:      makeTestGrid(196, 21, "b"),
+			colorMode: CellColorMode_Indexed256,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H\x1b[38;5;196;48;5;21mb" + reset,
+		},
+		{
+			name:      "16 colors, bright and dark",
+			previous:  makeTestGrid(-1, -1, "a"),
+			next:      makeTestGrid(9, 4, "b"),
+			colorMode: CellColorMode_Indexed16,
+			originRow: 1, originCol: 1,
+			want: "\x1b[1;1H\x1b[91;44mb" + reset,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.next.Diff(test.previous, test.colorMode, test.originRow, test.originCol)
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCellGridDiffChangesPenBetweenRuns(t *testing.T) {
+	previous := makeTestGrid(-1, -1, "abcdefgh")
+	next := makeTestGrid(-1, -1, "XbcdefgY")
+	next.Fg[7] = 1
+	want := "\x1b[1;1H\x1b[39;49mX" +
+		"\x1b[1;8H\x1b[31;49mY" +
+		"\x1b[0m"
+	if got := next.Diff(previous, CellColorMode_Indexed16, 1, 1); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
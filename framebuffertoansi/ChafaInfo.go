@@ -1,3 +1,5 @@
+//go:build cgo && !nochafa
+
 package framebuffertoansi
 
 // #cgo pkg-config: chafa glib-2.0
@@ -18,6 +20,18 @@ package framebuffertoansi
 // #endif
 // static int chafa_have_octant() { return HAVE_CHAFA_OCTANT; }
 //
+// // Read every cell in one cgo call.
+// static void read_cells(ChafaCanvas *canvas, gint width, gint height,
+//                        gunichar *chars, gint *fg, gint *bg) {
+//     for (gint y = 0; y < height; y++) {
+//         for (gint x = 0; x < width; x++) {
+//             gint i = y * width + x;
+//             chars[i] = chafa_canvas_get_char_at(canvas, x, y);
+//             chafa_canvas_get_colors_at(canvas, x, y, &fg[i], &bg[i]);
+//         }
+//     }
+// }
+//
 import "C"
 import (
 	"os"
@@ -26,25 +40,31 @@ import (
 )
 
 type ChafaInfo struct {
-	TermInfo  *C.ChafaTermInfo
-	Mode      C.ChafaCanvasMode
-	PixelMode C.ChafaPixelMode
-	SymbolMap *C.ChafaSymbolMap
-	Config    *C.ChafaCanvasConfig
-	Canvas    *C.ChafaCanvas
+	TermInfo       *C.ChafaTermInfo
+	Mode           C.ChafaCanvasMode
+	ChafaPixelMode C.ChafaPixelMode
+	SymbolMap      *C.ChafaSymbolMap
+	Config         *C.ChafaCanvasConfig
+	Canvas         *C.ChafaCanvas
 
 	WidthCells            int
 	HeightCells           int
 	WidthOfACellInPixels  int
 	HeightOfACellInPixels int
-	SessionTypeIsX11      bool
-	PixelTypeOverride     C.ChafaPixelType
 }
 
 func (ci *ChafaInfo) ConvertImage(texturePixels []byte, textureWidth, textureHeight, textureStride uint32) string {
 	if len(texturePixels) == 0 {
 		return ""
 	}
+	ci.DrawPixels(texturePixels, textureWidth, textureHeight, textureStride)
+	return ci.Print()
+}
+
+func (ci *ChafaInfo) DrawPixels(texturePixels []byte, textureWidth, textureHeight, textureStride uint32) {
+	if len(texturePixels) == 0 {
+		return
+	}
 
 	pixelsPtr := (*C.guint8)(unsafe.Pointer(&texturePixels[0]))
 
@@ -57,7 +77,9 @@ func (ci *ChafaInfo) ConvertImage(texturePixels []byte, textureWidth, textureHei
 		C.int(textureStride),
 	)
 	runtime.KeepAlive(texturePixels)
+}
 
+func (ci *ChafaInfo) Print() string {
 	gstr := C.chafa_canvas_print(ci.Canvas, ci.TermInfo)
 	if gstr == nil {
 		return ""
@@ -72,28 +94,99 @@ func (ci *ChafaInfo) ConvertImage(texturePixels []byte, textureWidth, textureHei
 	return C.GoStringN((*C.char)(unsafe.Pointer(ptrStr)), C.int(length))
 }
 
-func MakeChafaInfo(widthCells, heightCells, widthOfACellInPixels, heightOfACellInPixels int, sessionTypeIsX11 bool) *ChafaInfo {
+func init() {
+	RegisterRenderer("chafa", func(config RendererConfig) Renderer {
+		return MakeChafaInfo(config)
+	}, true)
+}
+
+func (ci *ChafaInfo) PixelMode() PixelMode {
+	switch ci.ChafaPixelMode {
+	case C.CHAFA_PIXEL_MODE_SIXELS:
+		return PixelMode_Sixels
+	case C.CHAFA_PIXEL_MODE_KITTY:
+		return PixelMode_Kitty
+	case C.CHAFA_PIXEL_MODE_ITERM2:
+		return PixelMode_Iterm2
+	default:
+		return PixelMode_Symbols
+	}
+}
+
+func (ci *ChafaInfo) CanvasSize() (int, int) {
+	return ci.WidthCells, ci.HeightCells
+}
+
+/**
+ * Which CellColorMode (if any) the canvas uses,
+ * only symbol mode canvases have cells.
+ */
+func (ci *ChafaInfo) CellColorMode() CellColorMode {
+	if ci.ChafaPixelMode != C.CHAFA_PIXEL_MODE_SYMBOLS {
+		return CellColorMode_Unsupported
+	}
+	switch ci.Mode {
+	case C.CHAFA_CANVAS_MODE_TRUECOLOR:
+		return CellColorMode_Truecolor
+	case C.CHAFA_CANVAS_MODE_INDEXED_256, C.CHAFA_CANVAS_MODE_INDEXED_240:
+		return CellColorMode_Indexed256
+	case C.CHAFA_CANVAS_MODE_INDEXED_16, C.CHAFA_CANVAS_MODE_INDEXED_16_8, C.CHAFA_CANVAS_MODE_INDEXED_8:
+		return CellColorMode_Indexed16
+	default:
+		/**
+		 * FGBG modes use inverted colors and
+		 * don't report them per cell.
+		 */
+		return CellColorMode_Unsupported
+	}
+}
+
+func (ci *ChafaInfo) ReadCells(grid *CellGrid) {
+	if grid.Width != ci.WidthCells || grid.Height != ci.HeightCells || len(grid.Chars) == 0 {
+		return
+	}
+	C.read_cells(ci.Canvas,
+		C.gint(grid.Width),
+		C.gint(grid.Height),
+		(*C.gunichar)(unsafe.Pointer(&grid.Chars[0])),
+		(*C.gint)(unsafe.Pointer(&grid.Fg[0])),
+		(*C.gint)(unsafe.Pointer(&grid.Bg[0])),
+	)
+	runtime.KeepAlive(grid)
+}
+
+func MakeChafaInfo(config RendererConfig) *ChafaInfo {
 	termInfo, mode, pixelMode := DetectTerminal()
+	if pixelMode == C.CHAFA_PIXEL_MODE_SYMBOLS {
+		mode = limitCanvasMode(mode, config.MaxColorMode)
+	}
+	widthCells := config.WidthCells
+	heightCells := config.HeightCells
+	widthOfACellInPixels := config.WidthOfACellInPixels
+	heightOfACellInPixels := config.HeightOfACellInPixels
 
 	ci := &ChafaInfo{
 		TermInfo:              termInfo,
 		Mode:                  mode,
-		PixelMode:             pixelMode,
+		ChafaPixelMode:        pixelMode,
 		WidthCells:            widthCells,
 		HeightCells:           heightCells,
 		WidthOfACellInPixels:  widthOfACellInPixels,
 		HeightOfACellInPixels: heightOfACellInPixels,
-		SessionTypeIsX11:      sessionTypeIsX11,
 	}
 
 	// Symbol map from env override (or default)
+	symbolTags := getChafaSymbolTags()
+	if config.SimpleSymbols {
+		symbolTags = C.CHAFA_SYMBOL_TAG_HALF
+	}
 	ci.SymbolMap = C.chafa_symbol_map_new()
-	C.chafa_symbol_map_add_by_tags(ci.SymbolMap, getChafaSymbolTags())
+	C.chafa_symbol_map_add_by_tags(ci.SymbolMap, symbolTags)
 
 	// Canvas config
 	ci.Config = C.chafa_canvas_config_new()
 	C.chafa_canvas_config_set_canvas_mode(ci.Config, ci.Mode)
-	C.chafa_canvas_config_set_pixel_mode(ci.Config, ci.PixelMode)
+	C.chafa_canvas_config_set_pixel_mode(ci.Config, ci.ChafaPixelMode)
 	C.chafa_canvas_config_set_geometry(ci.Config, C.int(widthCells), C.int(heightCells))
 	C.chafa_canvas_config_set_symbol_map(ci.Config, ci.SymbolMap)
 	// chafa_canvas_config_set_optimizations(config, TRUE);
@@ -109,44 +202,33 @@ func MakeChafaInfo(widthCells, heightCells, widthOfACellInPixels, heightOfACellI
 
 	ci.Canvas = C.chafa_canvas_new(ci.Config)
 
-	ci.PixelTypeOverride = getChafaPixelType()
-
 	return ci
 }
 
-func (ci *ChafaInfo) getPixelType() C.ChafaPixelType {
-	if ci.PixelTypeOverride != C.CHAFA_PIXEL_MAX {
-		return ci.PixelTypeOverride
-	}
-	if ci.PixelMode == C.CHAFA_PIXEL_MODE_KITTY && !ci.SessionTypeIsX11 {
-		return C.CHAFA_PIXEL_RGBA8_UNASSOCIATED
+/**
+ * Drop down to fewer colors, never up
+ */
+func limitCanvasMode(mode C.ChafaCanvasMode, limit CellColorMode) C.ChafaCanvasMode {
+	switch limit {
+	case CellColorMode_Indexed256:
+		if mode == C.CHAFA_CANVAS_MODE_TRUECOLOR {
+			return C.CHAFA_CANVAS_MODE_INDEXED_240
+		}
+	case CellColorMode_Indexed16:
+		switch mode {
+		case C.CHAFA_CANVAS_MODE_TRUECOLOR, C.CHAFA_CANVAS_MODE_INDEXED_256, C.CHAFA_CANVAS_MODE_INDEXED_240:
+			return C.CHAFA_CANVAS_MODE_INDEXED_16
+		}
 	}
-	return C.CHAFA_PIXEL_BGRA8_UNASSOCIATED
+	return mode
 }
 
-func getChafaPixelType() C.ChafaPixelType {
-	switch os.Getenv("TERM_EVERYTHING_PIXEL_TYPE") {
-	case "":
-		return C.CHAFA_PIXEL_MAX // No override
-	case "RGBA8":
-		return C.CHAFA_PIXEL_RGBA8_UNASSOCIATED
-	case "BGRA8":
-		return C.CHAFA_PIXEL_BGRA8_UNASSOCIATED
-	case "ARGB8":
-		return C.CHAFA_PIXEL_ARGB8_UNASSOCIATED
-	case "ABGR8":
-		return C.CHAFA_PIXEL_ABGR8_UNASSOCIATED
-	case "RGBA8_PREMULTIPLIED":
-		return C.CHAFA_PIXEL_RGBA8_PREMULTIPLIED
-	case "BGRA8_PREMULTIPLIED":
-		return C.CHAFA_PIXEL_BGRA8_PREMULTIPLIED
-	case "ARGB8_PREMULTIPLIED":
-		return C.CHAFA_PIXEL_ARGB8_PREMULTIPLIED
-	case "ABGR8_PREMULTIPLIED":
-		return C.CHAFA_PIXEL_ABGR8_PREMULTIPLIED
-	default:
-		return C.CHAFA_PIXEL_MAX
-	}
+/**
+ * The desktop is always premultiplied B, G, R, A
+ * (see wayland/Compositor.go)
+ */
+func (ci *ChafaInfo) getPixelType() C.ChafaPixelType {
+	return C.CHAFA_PIXEL_BGRA8_PREMULTIPLIED
 }
 
 var defaultSymbolTags = C.ChafaSymbolTags(C.CHAFA_SYMBOL_TAG_ALL)
@@ -1,3 +1,5 @@
+//go:build cgo && !nochafa
+
 package framebuffertoansi
 
 // #cgo pkg-config: chafa glib-2.0
@@ -11,9 +13,19 @@ package framebuffertoansi
 //     return term_info;
 // }
 import "C"
-import "os"
 
 func getDefaultPixelMode(termInfo *C.ChafaTermInfo) C.ChafaPixelMode {
+	if mode, ok := probedCapabilities.PixelMode(); ok &&
+		C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_BEGIN_ITERM2_IMAGE) == 0 {
+		switch mode {
+		case PixelMode_Kitty:
+			return C.CHAFA_PIXEL_MODE_KITTY
+		case PixelMode_Sixels:
+			return C.CHAFA_PIXEL_MODE_SIXELS
+		default:
+			return C.CHAFA_PIXEL_MODE_SYMBOLS
+		}
+	}
 	if C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_BEGIN_ITERM2_IMAGE) != 0 {
 		return C.CHAFA_PIXEL_MODE_ITERM2
 	} else if C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_BEGIN_KITTY_IMMEDIATE_IMAGE_V1) != 0 {
@@ -25,14 +37,6 @@ func getDefaultPixelMode(termInfo *C.ChafaTermInfo) C.ChafaPixelMode {
 	}
 }
 
-func getPixelModeOverride() string {
-	return os.Getenv("TERM_EVERYTHING_PIXEL_MODE")
-}
-
-func getCanvasModeOverride() string {
-	return os.Getenv("TERM_EVERYTHING_CANVAS_MODE")
-}
-
 func getPixelMode(termInfo *C.ChafaTermInfo) C.ChafaPixelMode {
 	override := getPixelModeOverride()
 	if override == "" {
@@ -59,6 +63,16 @@ func getDefaultCanvasMode(termInfo *C.ChafaTermInfo, pixelMode C.ChafaPixelMode)
 	case C.CHAFA_PIXEL_MODE_SYMBOLS:
 		fallthrough
 	default:
+		if mode, ok := probedCapabilities.CellColorMode(); ok {
+			switch mode {
+			case CellColorMode_Truecolor:
+				return C.CHAFA_CANVAS_MODE_TRUECOLOR
+			case CellColorMode_Indexed256:
+				return C.CHAFA_CANVAS_MODE_INDEXED_240
+			default:
+				return C.CHAFA_CANVAS_MODE_INDEXED_16
+			}
+		}
 		if C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_SET_COLOR_FGBG_DIRECT) != 0 &&
 			C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_SET_COLOR_FG_DIRECT) != 0 &&
 			C.chafa_term_info_have_seq(termInfo, C.CHAFA_TERM_SEQ_SET_COLOR_BG_DIRECT) != 0 {
@@ -110,9 +124,12 @@ func getCanvasMode(termInfo *C.ChafaTermInfo, pixelMode C.ChafaPixelMode) C.Chaf
 func DetectTerminal() (termInfo *C.ChafaTermInfo, mode C.ChafaCanvasMode, pixelMode C.ChafaPixelMode) {
 	termInfo = C.detect_term_info_from_env()
 	if getPixelModeOverride() != "" ||
-		getCanvasModeOverride() != "" {
+		getCanvasModeOverride() != "" ||
+		probedCapabilities != nil {
 		/* Make sure we have fallback sequences in case the user forces
-		 * a mode that's technically unsupported by the terminal. */
+		 * a mode that's technically unsupported by the terminal,
+		 * or the terminal told us it supports more than the
+		 * environment says. */
 		fallback_info := C.chafa_term_db_get_fallback_info(C.chafa_term_db_get_default())
 		C.chafa_term_info_supplement(termInfo, fallback_info)
 		C.chafa_term_info_unref(fallback_info)
@@ -0,0 +1,79 @@
+package framebuffertoansi
+
+import (
+	"image"
+	"strings"
+)
+
+/**
+ * A rectangle of terminal cells. Row and Col
+ * are 1 based, like the CUP escape code.
+ */
+type CellRegion struct {
+	Row         int
+	Col         int
+	WidthCells  int
+	HeightCells int
+}
+
+func (r CellRegion) Contains(row, col int) bool {
+	return row >= r.Row && row < r.Row+r.HeightCells &&
+		col >= r.Col && col < r.Col+r.WidthCells
+}
+
+/**
+ * Like DrawDesktop, but for a region of the terminal, so
+ * several DrawStates can share it (one per tiling pane).
+ * The output is added to sb instead of written to stdout.
+ * The image keeps its aspect ratio and is centered in
+ * region, the returned region is where it ended up.
+ * full redraws everything, ex: after the terminal was cleared.
+ */
+func (ds *DrawState) DrawRegion(sb *strings.Builder, texturePixels []byte, width, height uint32, region CellRegion, termSize TermSize, damage image.Rectangle, full bool) CellRegion {
+	widthCells, heightCells := CalcCanvasGeometry(
+		int(width),
+		int(height),
+		region.WidthCells,
+		region.HeightCells,
+		termSize.FontRatio,
+	)
+	placed := CellRegion{
+		Row:         region.Row + (region.HeightCells-heightCells)/2,
+		Col:         region.Col + (region.WidthCells-widthCells)/2,
+		WidthCells:  widthCells,
+		HeightCells: heightCells,
+	}
+
+	ds.ResizeRendererIfNeeded(widthCells, heightCells, termSize)
+	full = full || !ds.CanvasPrinted || placed != ds.LastRegion
+	if !full && damage.Empty() {
+		return placed
+	}
+	ds.LastRegion = placed
+	ds.CanvasPrinted = true
+
+	ds.Renderer.DrawPixels(texturePixels, width, height, width*4)
+	colorMode := ds.Renderer.CellColorMode()
+	if colorMode == CellColorMode_Unsupported {
+		/**
+		 * Images are drawn from the cursor
+		 */
+		ds.PreviousCells = nil
+		writeMoveCursor(sb, placed.Row, placed.Col)
+		sb.WriteString(ds.Renderer.Print())
+		return placed
+	}
+
+	canvasWidth, canvasHeight := ds.Renderer.CanvasSize()
+	if ds.CurrentCells == nil || ds.CurrentCells.Width != canvasWidth || ds.CurrentCells.Height != canvasHeight {
+		ds.CurrentCells = MakeCellGrid(canvasWidth, canvasHeight)
+	}
+	ds.Renderer.ReadCells(ds.CurrentCells)
+	if full || !ds.CurrentCells.SameSize(ds.PreviousCells) {
+		sb.WriteString(ds.CurrentCells.PrintAt(colorMode, placed.Row, placed.Col))
+	} else {
+		sb.WriteString(ds.CurrentCells.Diff(ds.PreviousCells, colorMode, placed.Row, placed.Col))
+	}
+	ds.PreviousCells, ds.CurrentCells = ds.CurrentCells, ds.PreviousCells
+	return placed
+}
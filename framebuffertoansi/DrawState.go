@@ -1,57 +1,125 @@
 package framebuffertoansi
 
-// #cgo pkg-config: chafa glib-2.0
-// #include "chafa.h"
-import "C"
-
 import (
 	"fmt"
+	"image"
+	"io"
 	"os"
 	"strings"
-	"unsafe"
+	"time"
 
 	"github.com/mmulet/term.everything/escapecodes"
 )
 
 type DrawState struct {
-	SessionTypeIsX11 bool
-	ChafaInfo        *ChafaInfo
+	/**
+	 * One of RendererNames(), or "auto"
+	 */
+	RendererName   string
+	Renderer       Renderer
+	RendererConfig RendererConfig
+
+	/**
+	 * In symbol modes, only the cells that changed since
+	 * the last frame are printed. PreviousCells is what
+	 * is on the terminal right now, nil means the next
+	 * frame must be printed in full.
+	 */
+	PreviousCells *CellGrid
+	CurrentCells  *CellGrid
+
+	/**
+	 * Print the full canvas at least this often, in case
+	 * something else scribbled over the terminal.
+	 * 0 or less turns off diffing.
+	 */
+	FullRefreshIntervalSeconds float64
+	TimeOfLastFullRefresh      time.Time
+	LastHadStatusLine          bool
+	LastTermSize               TermSize
+	/**
+	 * The terminal is showing the canvas
+	 * from the current Renderer.
+	 */
+	CanvasPrinted bool
+	/**
+	 * Where DrawRegion last put the canvas
+	 */
+	LastRegion CellRegion
+
+	/**
+	 * Used instead of the Renderer in kitty pixel mode,
+	 * nil (or KittyTransmission_Chafa) to use the Renderer.
+	 */
+	Kitty *KittyRenderer
+
+	Stats DrawStats
+
+	/**
+	 * Passed on to the Renderer, changed
+	 * by the adaptive quality control.
+	 */
+	MaxColorMode  CellColorMode
+	SimpleSymbols bool
+
+	/**
+	 * Size of the last write to stdout and how long
+	 * it took. Writes block when the terminal (or the
+	 * ssh connection to it) can't keep up.
+	 */
+	LastWriteBytes    int
+	LastWriteDuration time.Duration
+
+	/**
+	 * Gets a copy of everything written
+	 * to the terminal, nil for none.
+	 */
+	Tee io.Writer
 }
 
-func MakeDrawState(sessionTypeIsX11 bool) *DrawState {
+func MakeDrawState() *DrawState {
 	return &DrawState{
-		SessionTypeIsX11: sessionTypeIsX11,
+		RendererName:               "auto",
+		FullRefreshIntervalSeconds: 5,
 	}
 }
 
-func (ds *DrawState) ResizeChafaInfoIfNeeded(WidthCells int, HeightCells int, termSize TermSize) {
-
-	if ds.ChafaInfo != nil && !(ds.ChafaInfo.WidthCells == WidthCells &&
-		ds.ChafaInfo.HeightCells == HeightCells &&
-		ds.ChafaInfo.WidthOfACellInPixels == termSize.WidthOfACellInPixels &&
-		ds.ChafaInfo.HeightOfACellInPixels == termSize.HeightOfACellInPixels) {
-		ds.ChafaInfo.Destroy()
-		ds.ChafaInfo = nil
+func (ds *DrawState) ResizeRendererIfNeeded(WidthCells int, HeightCells int, termSize TermSize) {
+	config := RendererConfig{
+		WidthCells:            WidthCells,
+		HeightCells:           HeightCells,
+		WidthOfACellInPixels:  termSize.WidthOfACellInPixels,
+		HeightOfACellInPixels: termSize.HeightOfACellInPixels,
+		MaxColorMode:          ds.MaxColorMode,
+		SimpleSymbols:         ds.SimpleSymbols,
 	}
-	if ds.ChafaInfo != nil {
+	if ds.Renderer != nil && ds.RendererConfig != config {
+		ds.Renderer.Destroy()
+		ds.Renderer = nil
+	}
+	if ds.Renderer != nil {
 		return
 	}
 
-	ds.ChafaInfo = MakeChafaInfo(WidthCells,
-		HeightCells,
-		termSize.WidthOfACellInPixels,
-		termSize.HeightOfACellInPixels,
-		ds.SessionTypeIsX11)
+	ds.PreviousCells = nil
+	ds.CanvasPrinted = false
+	ds.RendererConfig = config
+	ds.Renderer = FindRenderer(ds.RendererName)(config)
 }
 
 func (ds *DrawState) Destroy() {
-	if ds.ChafaInfo != nil {
-		ds.ChafaInfo.Destroy()
-		ds.ChafaInfo = nil
+	if ds.Renderer != nil {
+		ds.Renderer.Destroy()
+		ds.Renderer = nil
 	}
 }
 
-func (ds *DrawState) DrawDesktop(texturePixels []byte, width, height uint32, statusLine *string) (int, int) {
+/**
+ * damage is the part of texturePixels that changed since the
+ * last call, if it is empty (and the terminal didn't change)
+ * the canvas is not printed again.
+ */
+func (ds *DrawState) DrawDesktop(texturePixels []byte, width, height uint32, statusLine *string, damage image.Rectangle) (int, int) {
 	haveStatusLine := statusLine != nil && len(*statusLine) > 0
 	termSize := MakeTermSize()
 
@@ -65,19 +133,15 @@ func (ds *DrawState) DrawDesktop(texturePixels []byte, width, height uint32, sta
 	heightCells := termSize.HeightCells - statusLineHeight
 
 	// Adjust geometry preserving aspect ratio.
-	C.chafa_calc_canvas_geometry(
-		C.int(width),
-		C.int(height),
-		(*C.int)(unsafe.Pointer(&widthCells)),
-		(*C.int)(unsafe.Pointer(&heightCells)),
-		C.gfloat(termSize.FontRatio),
-		C.gboolean(1), // preserve aspect
-		C.gboolean(0), // do not upscale
+	widthCells, heightCells = CalcCanvasGeometry(
+		int(width),
+		int(height),
+		widthCells,
+		heightCells,
+		termSize.FontRatio,
 	)
 
-	ds.ResizeChafaInfoIfNeeded(widthCells, heightCells, termSize)
-
-	printable := ds.ChafaInfo.ConvertImage(texturePixels, width, height, width*4)
+	ds.ResizeRendererIfNeeded(widthCells, heightCells, termSize)
 
 	var sb strings.Builder
 	if haveStatusLine {
@@ -87,10 +151,110 @@ func (ds *DrawState) DrawDesktop(texturePixels []byte, width, height uint32, sta
 		sb.WriteString("\n")
 
 	}
-	sb.WriteString(printable)
 
-	fmt.Fprint(os.Stdout, sb.String())
+	colorMode := ds.Renderer.CellColorMode()
+	if ds.CanReuseCanvas(damage, haveStatusLine, termSize) {
+		ds.Stats.AddDiffFrame(sb.Len(), 0)
+	} else if ds.Kitty.Enabled() && ds.Renderer.PixelMode() == PixelMode_Kitty {
+		/**
+		 * The image stays on the terminal, so unlike
+		 * the symbol modes there is no periodic
+		 * refresh, only damage is sent.
+		 */
+		full := !ds.CanvasPrinted || ds.TerminalChanged(haveStatusLine, termSize)
+		if full && !haveStatusLine {
+			sb.WriteString(escapecodes.MoveCursorToHome)
+		}
+		written := ds.Kitty.Draw(&sb, texturePixels, int(width), int(height), widthCells, heightCells, damage, full)
+		if full {
+			ds.Stats.AddFullFrame(sb.Len(), written)
+			ds.TimeOfLastFullRefresh = time.Now()
+		} else {
+			ds.Stats.AddDiffFrame(sb.Len(), written)
+		}
+	} else if colorMode == CellColorMode_Unsupported || ds.FullRefreshIntervalSeconds <= 0 {
+		ds.PreviousCells = nil
+		ds.Renderer.DrawPixels(texturePixels, width, height, width*4)
+		printable := ds.Renderer.Print()
+		sb.WriteString(printable)
+		ds.Stats.AddFullFrame(sb.Len(), len(printable))
+		ds.TimeOfLastFullRefresh = time.Now()
+	} else {
+		ds.Renderer.DrawPixels(texturePixels, width, height, width*4)
+		canvasWidth, canvasHeight := ds.Renderer.CanvasSize()
+		if ds.CurrentCells == nil || ds.CurrentCells.Width != canvasWidth || ds.CurrentCells.Height != canvasHeight {
+			ds.CurrentCells = MakeCellGrid(canvasWidth, canvasHeight)
+		}
+		ds.Renderer.ReadCells(ds.CurrentCells)
+
+		if ds.NeedsFullRefresh(haveStatusLine, termSize) {
+			if !haveStatusLine {
+				/**
+				 * The last diff could have left the
+				 * cursor anywhere.
+				 */
+				sb.WriteString(escapecodes.MoveCursorToHome)
+			}
+			printable := ds.Renderer.Print()
+			sb.WriteString(printable)
+			ds.Stats.AddFullFrame(sb.Len(), len(printable))
+			ds.TimeOfLastFullRefresh = time.Now()
+		} else {
+			diff := ds.CurrentCells.Diff(ds.PreviousCells, colorMode, 1+statusLineHeight, 1)
+			sb.WriteString(diff)
+			ds.Stats.AddDiffFrame(sb.Len(), len(diff))
+		}
+		ds.PreviousCells, ds.CurrentCells = ds.CurrentCells, ds.PreviousCells
+	}
+	ds.LastHadStatusLine = haveStatusLine
+	ds.LastTermSize = termSize
+	ds.CanvasPrinted = true
+
+	output := sb.String()
+	startOfWrite := time.Now()
+	fmt.Fprint(os.Stdout, output)
 	_ = os.Stdout.Sync()
+	ds.LastWriteBytes = len(output)
+	ds.LastWriteDuration = time.Since(startOfWrite)
+	if ds.Tee != nil {
+		ds.Tee.Write([]byte(output))
+	}
 
 	return widthCells, heightCells
 }
+
+func (ds *DrawState) NeedsFullRefresh(haveStatusLine bool, termSize TermSize) bool {
+	return ds.CurrentCells == nil ||
+		!ds.CurrentCells.SameSize(ds.PreviousCells) ||
+		ds.RefreshDue(haveStatusLine, termSize)
+}
+
+/**
+ * The terminal changed, or it has been too long
+ * since the whole canvas was printed.
+ */
+func (ds *DrawState) RefreshDue(haveStatusLine bool, termSize TermSize) bool {
+	return ds.TerminalChanged(haveStatusLine, termSize) ||
+		time.Since(ds.TimeOfLastFullRefresh).Seconds() >= ds.FullRefreshIntervalSeconds
+}
+
+/**
+ * The terminal was resized, or the status
+ * line came or went, since the last frame.
+ */
+func (ds *DrawState) TerminalChanged(haveStatusLine bool, termSize TermSize) bool {
+	return haveStatusLine != ds.LastHadStatusLine || termSize != ds.LastTermSize
+}
+
+/**
+ * Nothing in the desktop changed and the terminal
+ * still shows the last canvas we printed.
+ */
+func (ds *DrawState) CanReuseCanvas(damage image.Rectangle, haveStatusLine bool, termSize TermSize) bool {
+	return damage.Empty() &&
+		ds.CanvasPrinted &&
+		ds.FullRefreshIntervalSeconds > 0 &&
+		haveStatusLine == ds.LastHadStatusLine &&
+		termSize == ds.LastTermSize &&
+		time.Since(ds.TimeOfLastFullRefresh).Seconds() < ds.FullRefreshIntervalSeconds
+}
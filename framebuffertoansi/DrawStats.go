@@ -0,0 +1,59 @@
+package framebuffertoansi
+
+import "fmt"
+
+/**
+ * Running totals of what DrawDesktop wrote to the terminal.
+ */
+type DrawStats struct {
+	Frames int
+	/**
+	 * Frames where the whole canvas was printed
+	 */
+	FullFrames int
+
+	BytesWritten int64
+	/**
+	 * Estimated, because we don't print the full
+	 * canvas for diffed frames. Every diffed frame
+	 * saves the size of the last full frame minus
+	 * the size of the diff.
+	 */
+	BytesSaved int64
+
+	LastFullFrameBytes int
+}
+
+func (s *DrawStats) AddFullFrame(bytesWritten int, canvasBytes int) {
+	s.Frames++
+	s.FullFrames++
+	s.BytesWritten += int64(bytesWritten)
+	s.LastFullFrameBytes = canvasBytes
+}
+
+func (s *DrawStats) AddDiffFrame(bytesWritten int, canvasBytes int) {
+	s.Frames++
+	s.BytesWritten += int64(bytesWritten)
+	if saved := s.LastFullFrameBytes - canvasBytes; saved > 0 {
+		s.BytesSaved += int64(saved)
+	}
+}
+
+/**
+ * Percent of bytes not written thanks to diffing
+ */
+func (s *DrawStats) SavedPercent() float64 {
+	total := s.BytesWritten + s.BytesSaved
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(s.BytesSaved) / float64(total)
+}
+
+/**
+ * One line for the control socket's stats command
+ */
+func (s *DrawStats) Describe() string {
+	return fmt.Sprintf("frames=%d full_frames=%d bytes_written=%d bytes_saved=%d saved=%.1f%%",
+		s.Frames, s.FullFrames, s.BytesWritten, s.BytesSaved, s.SavedPercent())
+}
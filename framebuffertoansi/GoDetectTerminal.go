@@ -0,0 +1,91 @@
+package framebuffertoansi
+
+import (
+	"os"
+	"strings"
+)
+
+func getPixelModeOverride() string {
+	return os.Getenv("TERM_EVERYTHING_PIXEL_MODE")
+}
+
+func getCanvasModeOverride() string {
+	return os.Getenv("TERM_EVERYTHING_CANVAS_MODE")
+}
+
+/**
+ * Without chafa's terminal database, guess
+ * what the terminal supports from the environment.
+ */
+func DetectTerminalFromEnv() (PixelMode, CellColorMode) {
+	pixelMode := detectPixelModeFromEnv()
+	return pixelMode, detectColorModeFromEnv(pixelMode)
+}
+
+func detectPixelModeFromEnv() PixelMode {
+	switch getPixelModeOverride() {
+	case "SYMBOLS":
+		return PixelMode_Symbols
+	case "SIXELS":
+		return PixelMode_Sixels
+	case "KITTY":
+		return PixelMode_Kitty
+	case "ITERM2":
+		return PixelMode_Iterm2
+	}
+	if mode, ok := probedCapabilities.PixelMode(); ok {
+		return mode
+	}
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "",
+		term == "xterm-kitty",
+		term == "xterm-ghostty",
+		termProgram == "ghostty",
+		termProgram == "WezTerm":
+		return PixelMode_Kitty
+	case strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "mlterm"),
+		strings.HasPrefix(term, "contour"),
+		termProgram == "iTerm.app":
+		return PixelMode_Sixels
+	default:
+		return PixelMode_Symbols
+	}
+}
+
+func detectColorModeFromEnv(pixelMode PixelMode) CellColorMode {
+	switch getCanvasModeOverride() {
+	case "TRUECOLOR":
+		return CellColorMode_Truecolor
+	case "INDEXED_256", "INDEXED_240":
+		return CellColorMode_Indexed256
+	case "INDEXED_16", "INDEXED_16_8", "INDEXED_8", "FGBG_BGFG", "FGBG":
+		return CellColorMode_Indexed16
+	}
+	if pixelMode != PixelMode_Symbols {
+		return CellColorMode_Truecolor
+	}
+	if mode, ok := probedCapabilities.CellColorMode(); ok {
+		return mode
+	}
+	colorTerm := os.Getenv("COLORTERM")
+	if colorTerm == "truecolor" || colorTerm == "24bit" {
+		return CellColorMode_Truecolor
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case strings.Contains(term, "kitty"),
+		strings.Contains(term, "ghostty"),
+		strings.Contains(term, "alacritty"),
+		strings.Contains(term, "wezterm"),
+		strings.HasPrefix(term, "foot"),
+		strings.Contains(term, "direct"):
+		return CellColorMode_Truecolor
+	case strings.Contains(term, "256color"):
+		return CellColorMode_Indexed256
+	default:
+		return CellColorMode_Indexed16
+	}
+}
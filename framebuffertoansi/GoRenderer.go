@@ -0,0 +1,291 @@
+package framebuffertoansi
+
+import (
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterRenderer("go", func(config RendererConfig) Renderer {
+		return MakeGoRenderer(config)
+	}, false)
+}
+
+/**
+ * Renderer that doesn't need chafa (or cgo).
+ * Draws with half block or quadrant symbols,
+ * or sixels.
+ */
+type GoRenderer struct {
+	Config RendererConfig
+
+	Mode      PixelMode
+	ColorMode CellColorMode
+	/**
+	 * 2x2 pixels per cell instead of 1x2
+	 */
+	Quadrants bool
+
+	Cells *CellGrid
+
+	/**
+	 * The desktop scaled down to the canvas,
+	 * packed 0xRRGGBB
+	 */
+	Samples       []int32
+	SamplesWidth  int
+	SamplesHeight int
+
+	Sixel *SixelEncoder
+	/**
+	 * Sixel output of the last DrawPixels
+	 */
+	SixelOutput string
+}
+
+func MakeGoRenderer(config RendererConfig) *GoRenderer {
+	mode, colorMode := DetectTerminalFromEnv()
+	gr := &GoRenderer{
+		Config:    config,
+		Mode:      mode,
+		ColorMode: LimitColorMode(colorMode, config.MaxColorMode),
+		Quadrants: useQuadrants() && !config.SimpleSymbols,
+		Cells:     MakeCellGrid(max(config.WidthCells, 0), max(config.HeightCells, 0)),
+	}
+	if mode == PixelMode_Sixels {
+		gr.Sixel = MakeSixelEncoder()
+	}
+	return gr
+}
+
+func useQuadrants() bool {
+	switch os.Getenv("TERM_EVERYTHING_SYMBOLS") {
+	case "HALF", "HHALF", "VHALF":
+		return false
+	default:
+		return true
+	}
+}
+
+func (gr *GoRenderer) PixelMode() PixelMode {
+	return gr.Mode
+}
+
+/**
+ * Kitty is drawn by the KittyRenderer, and we can't
+ * draw iTerm2 images, so everything that isn't sixels
+ * falls back to symbols.
+ */
+func (gr *GoRenderer) drawsSymbols() bool {
+	return gr.Mode != PixelMode_Sixels
+}
+
+func (gr *GoRenderer) CellColorMode() CellColorMode {
+	if !gr.drawsSymbols() {
+		return CellColorMode_Unsupported
+	}
+	return gr.ColorMode
+}
+
+func (gr *GoRenderer) CanvasSize() (int, int) {
+	return gr.Config.WidthCells, gr.Config.HeightCells
+}
+
+func (gr *GoRenderer) DrawPixels(bgraPixels []byte, width, height, stride uint32) {
+	if len(bgraPixels) == 0 || gr.Cells.Width == 0 || gr.Cells.Height == 0 {
+		return
+	}
+	if !gr.drawsSymbols() {
+		sampleWidth, sampleHeight := gr.sixelSize(int(width), int(height))
+		gr.downsample(bgraPixels, int(width), int(height), int(stride), sampleWidth, sampleHeight)
+		var sb strings.Builder
+		gr.Sixel.Encode(&sb, gr.Samples, gr.SamplesWidth, gr.SamplesHeight)
+		gr.SixelOutput = sb.String()
+		return
+	}
+
+	pixelsPerCellX := 1
+	if gr.Quadrants {
+		pixelsPerCellX = 2
+	}
+	gr.downsample(bgraPixels, int(width), int(height), int(stride),
+		gr.Cells.Width*pixelsPerCellX, gr.Cells.Height*2)
+	if gr.Quadrants {
+		gr.drawQuadrants()
+	} else {
+		gr.drawHalfBlocks()
+	}
+}
+
+/**
+ * Fit the sixel image into the canvas, but never
+ * make it bigger than the desktop.
+ */
+func (gr *GoRenderer) sixelSize(width, height int) (int, int) {
+	cellWidth := gr.Config.WidthOfACellInPixels
+	cellHeight := gr.Config.HeightOfACellInPixels
+	if cellWidth <= 0 || cellHeight <= 0 {
+		cellWidth = 10
+		cellHeight = 20
+	}
+	canvasWidth := gr.Config.WidthCells * cellWidth
+	canvasHeight := gr.Config.HeightCells * cellHeight
+	if canvasWidth >= width && canvasHeight >= height {
+		return width, height
+	}
+	scale := min(float64(canvasWidth)/float64(width), float64(canvasHeight)/float64(height))
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+/**
+ * Box filter bgraPixels down to Samples
+ */
+func (gr *GoRenderer) downsample(bgraPixels []byte, width, height, stride, sampleWidth, sampleHeight int) {
+	if cap(gr.Samples) < sampleWidth*sampleHeight {
+		gr.Samples = make([]int32, sampleWidth*sampleHeight)
+	}
+	gr.Samples = gr.Samples[:sampleWidth*sampleHeight]
+	gr.SamplesWidth = sampleWidth
+	gr.SamplesHeight = sampleHeight
+
+	for sy := range sampleHeight {
+		y0 := sy * height / sampleHeight
+		y1 := max((sy+1)*height/sampleHeight, y0+1)
+		for sx := range sampleWidth {
+			x0 := sx * width / sampleWidth
+			x1 := max((sx+1)*width/sampleWidth, x0+1)
+			var r, g, b, n int32
+			for y := y0; y < y1; y++ {
+				row := bgraPixels[y*stride:]
+				for x := x0; x < x1; x++ {
+					b += int32(row[x*4+0])
+					g += int32(row[x*4+1])
+					r += int32(row[x*4+2])
+					n++
+				}
+			}
+			gr.Samples[sy*sampleWidth+sx] = packRGB(r/n, g/n, b/n)
+		}
+	}
+}
+
+func (gr *GoRenderer) drawHalfBlocks() {
+	cells := gr.Cells
+	for y := range cells.Height {
+		top := gr.Samples[(y*2)*gr.SamplesWidth:]
+		bottom := gr.Samples[(y*2+1)*gr.SamplesWidth:]
+		for x := range cells.Width {
+			i := y*cells.Width + x
+			cells.Chars[i] = '▀'
+			cells.Fg[i] = QuantizeColor(gr.ColorMode, top[x])
+			cells.Bg[i] = QuantizeColor(gr.ColorMode, bottom[x])
+		}
+	}
+}
+
+/**
+ * Indexed by a bitmask of which quarters are the
+ * foreground: 1 top left, 2 top right,
+ * 4 bottom left, 8 bottom right.
+ */
+var quadrantGlyphs = [16]rune{
+	' ', '▘', '▝', '▀',
+	'▖', '▌', '▞', '▛',
+	'▗', '▚', '▐', '▜',
+	'▄', '▙', '▟', '█',
+}
+
+func (gr *GoRenderer) drawQuadrants() {
+	cells := gr.Cells
+	var quarters [4][3]int32
+	for y := range cells.Height {
+		top := gr.Samples[(y*2)*gr.SamplesWidth:]
+		bottom := gr.Samples[(y*2+1)*gr.SamplesWidth:]
+		for x := range cells.Width {
+			quarters[0][0], quarters[0][1], quarters[0][2] = unpackRGB(top[x*2])
+			quarters[1][0], quarters[1][1], quarters[1][2] = unpackRGB(top[x*2+1])
+			quarters[2][0], quarters[2][1], quarters[2][2] = unpackRGB(bottom[x*2])
+			quarters[3][0], quarters[3][1], quarters[3][2] = unpackRGB(bottom[x*2+1])
+
+			mask, fg, bg := bestQuadrantSplit(&quarters)
+
+			i := y*cells.Width + x
+			cells.Chars[i] = quadrantGlyphs[mask]
+			cells.Fg[i] = QuantizeColor(gr.ColorMode, fg)
+			cells.Bg[i] = QuantizeColor(gr.ColorMode, bg)
+		}
+	}
+}
+
+/**
+ * Try every way to split the 4 quarters into two
+ * colors, keep the one closest to the original.
+ * The bottom right quarter is always background, the
+ * other half of the splits are the same with fg and bg
+ * swapped.
+ */
+func bestQuadrantSplit(quarters *[4][3]int32) (int, int32, int32) {
+	bestMask := 0
+	var bestFg, bestBg int32
+	bestError := int32(-1)
+	for mask := range 8 {
+		var fgSum, bgSum [3]int32
+		var fgCount, bgCount int32
+		for q := range 4 {
+			if mask&(1<<q) != 0 {
+				fgSum[0] += quarters[q][0]
+				fgSum[1] += quarters[q][1]
+				fgSum[2] += quarters[q][2]
+				fgCount++
+			} else {
+				bgSum[0] += quarters[q][0]
+				bgSum[1] += quarters[q][1]
+				bgSum[2] += quarters[q][2]
+				bgCount++
+			}
+		}
+		var fg, bg [3]int32
+		for c := range 3 {
+			if fgCount > 0 {
+				fg[c] = fgSum[c] / fgCount
+			}
+			bg[c] = bgSum[c] / bgCount
+		}
+		if fgCount == 0 {
+			fg = bg
+		}
+		var e int32
+		for q := range 4 {
+			want := bg
+			if mask&(1<<q) != 0 {
+				want = fg
+			}
+			e += colorDistance(quarters[q][0], quarters[q][1], quarters[q][2], want[0], want[1], want[2])
+		}
+		if bestError < 0 || e < bestError {
+			bestError = e
+			bestMask = mask
+			bestFg = packRGB(fg[0], fg[1], fg[2])
+			bestBg = packRGB(bg[0], bg[1], bg[2])
+		}
+	}
+	return bestMask, bestFg, bestBg
+}
+
+func (gr *GoRenderer) ReadCells(grid *CellGrid) {
+	if !grid.SameSize(gr.Cells) {
+		return
+	}
+	copy(grid.Chars, gr.Cells.Chars)
+	copy(grid.Fg, gr.Cells.Fg)
+	copy(grid.Bg, gr.Cells.Bg)
+}
+
+func (gr *GoRenderer) Print() string {
+	if !gr.drawsSymbols() {
+		return gr.SixelOutput
+	}
+	return gr.Cells.Print(gr.ColorMode)
+}
+
+func (gr *GoRenderer) Destroy() {}
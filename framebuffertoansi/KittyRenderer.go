@@ -0,0 +1,295 @@
+package framebuffertoansi
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/**
+ * How pixels get to the terminal in the
+ * kitty graphics protocol.
+ */
+type KittyTransmission int
+
+const (
+	/**
+	 * Don't use the native kitty renderer,
+	 * let chafa print the image.
+	 */
+	KittyTransmission_Chafa KittyTransmission = iota
+	/**
+	 * zlib compressed (o=z) and base64 encoded in the
+	 * escape code itself. Works over ssh.
+	 */
+	KittyTransmission_Direct
+	/**
+	 * Write to a temp file (t=t), the terminal
+	 * reads and deletes it. Local only.
+	 */
+	KittyTransmission_File
+	/**
+	 * Write to a POSIX shared memory object (t=s),
+	 * the terminal reads and unlinks it. Local only.
+	 */
+	KittyTransmission_SharedMemory
+)
+
+func ParseKittyTransmission(transmission string) KittyTransmission {
+	switch transmission {
+	case "chafa":
+		return KittyTransmission_Chafa
+	case "direct":
+		return KittyTransmission_Direct
+	case "file":
+		return KittyTransmission_File
+	case "shm":
+		return KittyTransmission_SharedMemory
+	case "auto", "":
+		return DetectKittyTransmission()
+	default:
+		return DetectKittyTransmission()
+	}
+}
+
+/**
+ * Files and shared memory only work if the
+ * terminal is on the same machine as us.
+ */
+func DetectKittyTransmission() KittyTransmission {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CLIENT") != "" {
+		return KittyTransmission_Direct
+	}
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return KittyTransmission_SharedMemory
+	}
+	return KittyTransmission_File
+}
+
+/**
+ * Kitty only deletes temp files (and we name shm objects
+ * the same way) that have this in their name.
+ */
+const kittyTempFileMarker = "tty-graphics-protocol"
+
+/**
+ * Max size of the base64 payload in one escape code
+ */
+const kittyChunkSize = 4096
+
+/**
+ * Draws the desktop with the kitty graphics protocol.
+ * The image is transmitted and placed once, after that
+ * only damaged areas are sent, as edits to the image's
+ * first (and only) animation frame.
+ */
+type KittyRenderer struct {
+	ImageID      uint32
+	Transmission KittyTransmission
+
+	/**
+	 * Size of the image on the terminal,
+	 * 0 means nothing has been sent yet.
+	 */
+	Width       int
+	Height      int
+	WidthCells  int
+	HeightCells int
+
+	rgba       []byte
+	tempFileID int
+}
+
+func MakeKittyRenderer(transmission KittyTransmission) *KittyRenderer {
+	return &KittyRenderer{
+		/**
+		 * Arbitrary, but stays the same for the
+		 * whole session so the image is reused.
+		 */
+		ImageID:      uint32(0x7e00_0000 | (os.Getpid() & 0xffffff)),
+		Transmission: transmission,
+	}
+}
+
+func (kr *KittyRenderer) Enabled() bool {
+	return kr != nil && kr.Transmission != KittyTransmission_Chafa
+}
+
+/**
+ * Writes the escape codes to show bgraPixels into sb. The cursor
+ * must be at the top left of the canvas. If full is false, and the
+ * size hasn't changed, only damage is sent.
+ * Returns the number of bytes written to sb.
+ */
+func (kr *KittyRenderer) Draw(sb *strings.Builder, bgraPixels []byte, width, height, widthCells, heightCells int, damage image.Rectangle, full bool) int {
+	start := sb.Len()
+	bounds := image.Rect(0, 0, width, height)
+
+	if full || kr.Width != width || kr.Height != height ||
+		kr.WidthCells != widthCells || kr.HeightCells != heightCells {
+		if kr.Width != 0 {
+			kr.Delete(sb)
+		}
+		kr.Width = width
+		kr.Height = height
+		kr.WidthCells = widthCells
+		kr.HeightCells = heightCells
+
+		/**
+		 * a=T: transmit and place, C=1: don't move the cursor,
+		 * c/r: scale to the canvas, q=2: no replies.
+		 */
+		kr.transmit(sb,
+			fmt.Sprintf("a=T,i=%d,p=1,f=32,s=%d,v=%d,c=%d,r=%d,C=1,q=2",
+				kr.ImageID, width, height, widthCells, heightCells),
+			kr.toRGBA(bgraPixels, width, bounds))
+		return sb.Len() - start
+	}
+
+	damage = damage.Intersect(bounds)
+	if damage.Empty() {
+		return 0
+	}
+	/**
+	 * a=f with r=1 edits the root frame in place,
+	 * x/y/s/v is the sub-rectangle, X=1 replaces
+	 * the pixels instead of alpha blending.
+	 */
+	kr.transmit(sb,
+		fmt.Sprintf("a=f,i=%d,r=1,f=32,x=%d,y=%d,s=%d,v=%d,X=1,q=2",
+			kr.ImageID, damage.Min.X, damage.Min.Y, damage.Dx(), damage.Dy()),
+		kr.toRGBA(bgraPixels, width, damage))
+	return sb.Len() - start
+}
+
+/**
+ * Delete the image (and its placements) from the terminal.
+ */
+func (kr *KittyRenderer) Delete(sb *strings.Builder) {
+	fmt.Fprintf(sb, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kr.ImageID)
+	kr.Width = 0
+	kr.Height = 0
+}
+
+/**
+ * The desktop is premultiplied BGRA, kitty
+ * wants straight (not premultiplied) RGBA.
+ * Copies the rect out of the desktop.
+ */
+func (kr *KittyRenderer) toRGBA(bgraPixels []byte, width int, rect image.Rectangle) []byte {
+	size := rect.Dx() * rect.Dy() * 4
+	if cap(kr.rgba) < size {
+		kr.rgba = make([]byte, size)
+	}
+	out := kr.rgba[:size]
+	stride := width * 4
+	i := 0
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := bgraPixels[y*stride+rect.Min.X*4 : y*stride+rect.Max.X*4]
+		for x := 0; x < len(row); x += 4 {
+			a := row[x+3]
+			switch a {
+			case 0xff, 0:
+				out[i+0] = row[x+2]
+				out[i+1] = row[x+1]
+				out[i+2] = row[x+0]
+			default:
+				out[i+0] = unpremultiply(row[x+2], a)
+				out[i+1] = unpremultiply(row[x+1], a)
+				out[i+2] = unpremultiply(row[x+0], a)
+			}
+			out[i+3] = a
+			i += 4
+		}
+	}
+	return out
+}
+
+func unpremultiply(c, a byte) byte {
+	return byte(min((uint32(c)*255+uint32(a)/2)/uint32(a), 255))
+}
+
+func (kr *KittyRenderer) transmit(sb *strings.Builder, keys string, rgba []byte) {
+	switch kr.Transmission {
+	case KittyTransmission_SharedMemory, KittyTransmission_File:
+		if kr.transmitThroughFile(sb, keys, rgba) {
+			return
+		}
+		/**
+		 * Couldn't write the file, send it
+		 * the slow way instead.
+		 */
+		kr.Transmission = KittyTransmission_Direct
+	}
+	kr.transmitDirect(sb, keys, rgba)
+}
+
+func (kr *KittyRenderer) transmitThroughFile(sb *strings.Builder, keys string, rgba []byte) bool {
+	kr.tempFileID++
+	name := fmt.Sprintf("%s-%d-%d", kittyTempFileMarker, os.Getpid(), kr.tempFileID)
+
+	var path, payload, medium string
+	if kr.Transmission == KittyTransmission_SharedMemory {
+		/**
+		 * shm_open("/name") is /dev/shm/name on linux
+		 */
+		path = "/dev/shm/" + name
+		payload = "/" + name
+		medium = "s"
+	} else {
+		path = os.TempDir() + "/" + name + ".rgba"
+		payload = path
+		medium = "t"
+	}
+	if err := os.WriteFile(path, rgba, 0600); err != nil {
+		return false
+	}
+	sb.WriteString("\x1b_G")
+	sb.WriteString(keys)
+	sb.WriteString(",t=")
+	sb.WriteString(medium)
+	sb.WriteString(",S=")
+	sb.WriteString(strconv.Itoa(len(rgba)))
+	sb.WriteByte(';')
+	sb.WriteString(base64.StdEncoding.EncodeToString([]byte(payload)))
+	sb.WriteString("\x1b\\")
+	return true
+}
+
+func (kr *KittyRenderer) transmitDirect(sb *strings.Builder, keys string, rgba []byte) {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	_, _ = w.Write(rgba)
+	_ = w.Close()
+
+	encoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	for first := true; first || len(encoded) > 0; first = false {
+		chunk := encoded[:min(len(encoded), kittyChunkSize)]
+		encoded = encoded[len(chunk):]
+		more := 0
+		if len(encoded) > 0 {
+			more = 1
+		}
+		sb.WriteString("\x1b_G")
+		if first {
+			sb.WriteString(keys)
+			sb.WriteString(",t=d,o=z,")
+		} else {
+			/**
+			 * Only m (and q) are allowed
+			 * after the first chunk.
+			 */
+			sb.WriteString("q=2,")
+		}
+		sb.WriteString("m=")
+		sb.WriteString(strconv.Itoa(more))
+		sb.WriteByte(';')
+		sb.WriteString(chunk)
+		sb.WriteString("\x1b\\")
+	}
+}
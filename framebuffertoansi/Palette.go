@@ -0,0 +1,112 @@
+package framebuffertoansi
+
+/**
+ * The 16 standard ANSI colors (xterm's defaults)
+ */
+var ansi16Palette = [16][3]int32{
+	{0, 0, 0},
+	{205, 0, 0},
+	{0, 205, 0},
+	{205, 205, 0},
+	{0, 0, 238},
+	{205, 0, 205},
+	{0, 205, 205},
+	{229, 229, 229},
+	{127, 127, 127},
+	{255, 0, 0},
+	{0, 255, 0},
+	{255, 255, 0},
+	{92, 92, 255},
+	{255, 0, 255},
+	{0, 255, 255},
+	{255, 255, 255},
+}
+
+var cube6Levels = [6]int32{0, 95, 135, 175, 215, 255}
+
+func packRGB(r, g, b int32) int32 {
+	return r<<16 | g<<8 | b
+}
+
+func unpackRGB(c int32) (int32, int32, int32) {
+	return (c >> 16) & 0xff, (c >> 8) & 0xff, c & 0xff
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int32) int32 {
+	dr := r1 - r2
+	dg := g1 - g2
+	db := b1 - b2
+	return dr*dr + dg*dg + db*db
+}
+
+func nearestCubeLevel(v int32) int32 {
+	if v < 48 {
+		return 0
+	}
+	if v < 115 {
+		return 1
+	}
+	return min((v-35)/40, 5)
+}
+
+/**
+ * Nearest color in xterm's 256 color palette, not
+ * counting the first 16 (they change with the
+ * terminal's theme). Returns 16-255.
+ */
+func Nearest256(r, g, b int32) int32 {
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cube := 16 + ri*36 + gi*6 + bi
+	cubeDistance := colorDistance(r, g, b, cube6Levels[ri], cube6Levels[gi], cube6Levels[bi])
+
+	gray := (r + g + b) / 3
+	grayIndex := min(max((gray-8+5)/10, 0), 23)
+	grayLevel := 8 + grayIndex*10
+	if colorDistance(r, g, b, grayLevel, grayLevel, grayLevel) < cubeDistance {
+		return 232 + grayIndex
+	}
+	return cube
+}
+
+/**
+ * The rgb value of a palette entry from Nearest256
+ */
+func Palette256RGB(index int32) (int32, int32, int32) {
+	if index >= 232 {
+		level := 8 + (index-232)*10
+		return level, level, level
+	}
+	if index < 16 {
+		c := ansi16Palette[max(index, 0)]
+		return c[0], c[1], c[2]
+	}
+	index -= 16
+	return cube6Levels[index/36], cube6Levels[(index/6)%6], cube6Levels[index%6]
+}
+
+func Nearest16(r, g, b int32) int32 {
+	best := int32(0)
+	bestDistance := int32(-1)
+	for i, c := range ansi16Palette {
+		d := colorDistance(r, g, b, c[0], c[1], c[2])
+		if bestDistance < 0 || d < bestDistance {
+			best = int32(i)
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+/**
+ * A packed 0xRRGGBB color as it goes in a CellGrid
+ */
+func QuantizeColor(colorMode CellColorMode, c int32) int32 {
+	switch colorMode {
+	case CellColorMode_Indexed256:
+		return Nearest256(unpackRGB(c))
+	case CellColorMode_Indexed16:
+		return Nearest16(unpackRGB(c))
+	default:
+		return c
+	}
+}
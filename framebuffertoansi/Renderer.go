@@ -0,0 +1,148 @@
+package framebuffertoansi
+
+import (
+	"slices"
+)
+
+type PixelMode int
+
+const (
+	PixelMode_Symbols PixelMode = iota
+	PixelMode_Sixels
+	PixelMode_Kitty
+	PixelMode_Iterm2
+)
+
+/**
+ * Turns the desktop pixels into something
+ * the terminal can print.
+ */
+type Renderer interface {
+	PixelMode() PixelMode
+	/**
+	 * CellColorMode_Unsupported if the
+	 * renderer doesn't draw symbols.
+	 */
+	CellColorMode() CellColorMode
+	CanvasSize() (widthCells int, heightCells int)
+	/**
+	 * bgraPixels are in the desktop's format
+	 */
+	DrawPixels(bgraPixels []byte, width, height, stride uint32)
+	/**
+	 * Copy the canvas (after DrawPixels) into grid,
+	 * only for symbol modes.
+	 */
+	ReadCells(grid *CellGrid)
+	/**
+	 * The whole canvas (after DrawPixels), printed
+	 * from the cursor position.
+	 */
+	Print() string
+	Destroy()
+}
+
+type RendererConfig struct {
+	WidthCells            int
+	HeightCells           int
+	WidthOfACellInPixels  int
+	HeightOfACellInPixels int
+
+	/**
+	 * Use at most this many colors in symbol
+	 * modes, CellColorMode_Unsupported for
+	 * whatever the terminal supports.
+	 */
+	MaxColorMode CellColorMode
+	/**
+	 * Only use half blocks, set when the
+	 * connection to the terminal is slow.
+	 */
+	SimpleSymbols bool
+}
+
+/**
+ * The fewer colors of mode and limit
+ */
+func LimitColorMode(mode CellColorMode, limit CellColorMode) CellColorMode {
+	if mode == CellColorMode_Unsupported || limit == CellColorMode_Unsupported {
+		return mode
+	}
+	return max(mode, limit)
+}
+
+type MakeRendererFunc func(config RendererConfig) Renderer
+
+type registeredRenderer struct {
+	Name string
+	Make MakeRendererFunc
+}
+
+/**
+ * In order of preference, renderers that need
+ * cgo register themselves only when built with it.
+ */
+var renderers = []registeredRenderer{}
+
+func RegisterRenderer(name string, makeRenderer MakeRendererFunc, preferred bool) {
+	r := registeredRenderer{Name: name, Make: makeRenderer}
+	if preferred {
+		renderers = slices.Insert(renderers, 0, r)
+		return
+	}
+	renderers = append(renderers, r)
+}
+
+/**
+ * Names of the renderers built into this binary
+ */
+func RendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for _, r := range renderers {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+/**
+ * "auto" (or an unknown name) picks the
+ * preferred renderer.
+ */
+func FindRenderer(name string) MakeRendererFunc {
+	for _, r := range renderers {
+		if r.Name == name {
+			return r.Make
+		}
+	}
+	return renderers[0].Make
+}
+
+/**
+ * Fit a srcWidth x srcHeight image into at most
+ * destWidth x destHeight cells, keeping the aspect
+ * ratio. Same as chafa_calc_canvas_geometry with
+ * zoom on and stretch off.
+ */
+func CalcCanvasGeometry(srcWidth, srcHeight, destWidth, destHeight int, fontRatio float64) (int, int) {
+	if srcWidth <= 0 || srcHeight <= 0 {
+		return 0, 0
+	}
+	srcAspect := float64(srcWidth) / float64(srcHeight)
+	switch {
+	case destWidth < 1 && destHeight < 1:
+		destWidth = srcWidth
+		destHeight = int(float64(destWidth)/srcAspect*fontRatio + 0.5)
+	case destWidth < 1:
+		destWidth = int(float64(destHeight)*(srcAspect/fontRatio) + 0.5)
+	case destHeight < 1:
+		destHeight = int(float64(destWidth)/srcAspect*fontRatio + 0.5)
+	default:
+		destAspect := float64(destWidth) / float64(destHeight) * fontRatio
+		if srcAspect > destAspect {
+			destHeight = int(float64(destWidth) * (fontRatio / srcAspect))
+		} else if srcAspect < destAspect {
+			destWidth = int(float64(destHeight) * (srcAspect / fontRatio))
+		}
+	}
+	return max(destWidth, 1), max(destHeight, 1)
+}
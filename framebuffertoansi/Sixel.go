@@ -0,0 +1,133 @@
+package framebuffertoansi
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * Encodes images as sixels using the 240 colors of
+ * xterm's 256 color palette that don't change with
+ * the terminal's theme.
+ */
+type SixelEncoder struct {
+	/**
+	 * Palette register for every pixel
+	 */
+	Indices []uint8
+	/**
+	 * Scratch space for one band
+	 */
+	bandMasks [240][]byte
+	bandUsed  [240]bool
+}
+
+func MakeSixelEncoder() *SixelEncoder {
+	return &SixelEncoder{}
+}
+
+/**
+ * pixels are packed 0xRRGGBB
+ */
+func (se *SixelEncoder) Encode(sb *strings.Builder, pixels []int32, width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if cap(se.Indices) < width*height {
+		se.Indices = make([]uint8, width*height)
+	}
+	se.Indices = se.Indices[:width*height]
+
+	var used [240]bool
+	for i, c := range pixels[:width*height] {
+		register := uint8(Nearest256(unpackRGB(c)) - 16)
+		se.Indices[i] = register
+		used[register] = true
+	}
+
+	sb.WriteString("\x1bP0;1;0q")
+	sb.WriteString("\"1;1;")
+	sb.WriteString(strconv.Itoa(width))
+	sb.WriteString(";")
+	sb.WriteString(strconv.Itoa(height))
+
+	for register, isUsed := range used {
+		if !isUsed {
+			continue
+		}
+		r, g, b := Palette256RGB(int32(register) + 16)
+		sb.WriteString("#")
+		sb.WriteString(strconv.Itoa(register))
+		sb.WriteString(";2;")
+		sb.WriteString(strconv.Itoa(int(r * 100 / 255)))
+		sb.WriteString(";")
+		sb.WriteString(strconv.Itoa(int(g * 100 / 255)))
+		sb.WriteString(";")
+		sb.WriteString(strconv.Itoa(int(b * 100 / 255)))
+	}
+
+	for bandTop := 0; bandTop < height; bandTop += 6 {
+		if bandTop > 0 {
+			sb.WriteString("-")
+		}
+		se.encodeBand(sb, width, height, bandTop)
+	}
+	sb.WriteString("\x1b\\")
+}
+
+/**
+ * One row of sixels, every color in the band is
+ * drawn over the same row with $ in between.
+ */
+func (se *SixelEncoder) encodeBand(sb *strings.Builder, width, height, bandTop int) {
+	bandHeight := min(6, height-bandTop)
+	se.bandUsed = [240]bool{}
+	for y := range bandHeight {
+		row := se.Indices[(bandTop+y)*width : (bandTop+y+1)*width]
+		for x, register := range row {
+			if !se.bandUsed[register] {
+				se.bandUsed[register] = true
+				if cap(se.bandMasks[register]) < width {
+					se.bandMasks[register] = make([]byte, width)
+				}
+				se.bandMasks[register] = se.bandMasks[register][:width]
+				clear(se.bandMasks[register])
+			}
+			se.bandMasks[register][x] |= 1 << y
+		}
+	}
+
+	first := true
+	for register, isUsed := range se.bandUsed {
+		if !isUsed {
+			continue
+		}
+		if !first {
+			sb.WriteString("$")
+		}
+		first = false
+		sb.WriteString("#")
+		sb.WriteString(strconv.Itoa(register))
+		writeSixelRuns(sb, se.bandMasks[register])
+	}
+}
+
+func writeSixelRuns(sb *strings.Builder, masks []byte) {
+	for x := 0; x < len(masks); {
+		run := 1
+		for x+run < len(masks) && masks[x+run] == masks[x] {
+			run++
+		}
+		c := byte('?' + masks[x])
+		if run > 3 {
+			sb.WriteString("!")
+			sb.WriteString(strconv.Itoa(run))
+			sb.WriteByte(c)
+		} else {
+			for range run {
+				sb.WriteByte(c)
+			}
+		}
+		x += run
+	}
+}
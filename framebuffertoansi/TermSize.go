@@ -65,6 +65,19 @@ func MakeTermSize() TermSize {
 		if ts.HeightOfACellInPixels > 0 {
 			ts.FontRatio = float64(ts.WidthOfACellInPixels) / float64(ts.HeightOfACellInPixels)
 		}
+	} else if caps := probedCapabilities; caps != nil &&
+		caps.WidthOfACellInPixels > 0 && caps.HeightOfACellInPixels > 0 {
+		/**
+		 * No pixels from TIOCGWINSZ (common over ssh),
+		 * use what the terminal told us at startup.
+		 */
+		ts.WidthOfACellInPixels = caps.WidthOfACellInPixels
+		ts.HeightOfACellInPixels = caps.HeightOfACellInPixels
+		ts.FontRatio = float64(ts.WidthOfACellInPixels) / float64(ts.HeightOfACellInPixels)
+		if ts.WidthCells > 0 && ts.HeightCells > 0 {
+			ts.WidthPixels = ts.WidthCells * ts.WidthOfACellInPixels
+			ts.HeightPixels = ts.HeightCells * ts.HeightOfACellInPixels
+		}
 	} else {
 		ts.WidthOfACellInPixels = -1
 		ts.HeightOfACellInPixels = -1
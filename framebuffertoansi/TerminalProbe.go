@@ -0,0 +1,342 @@
+package framebuffertoansi
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mmulet/term.everything/escapecodes"
+)
+
+/**
+ * What the terminal told us about itself
+ * when we asked it at startup.
+ */
+type TerminalCapabilities struct {
+	/**
+	 * The terminal answered DA1, so everything
+	 * it didn't answer is really unsupported.
+	 */
+	Answered bool
+
+	/**
+	 * From XTVERSION, ex: "kitty(0.35.2)"
+	 * or "foot(1.17.2)"
+	 */
+	Name string
+
+	Sixels bool
+	Kitty  bool
+
+	/**
+	 * From XTGETTCAP RGB or Tc
+	 */
+	Truecolor bool
+	/**
+	 * From XTGETTCAP colors, 0 if unknown
+	 */
+	Colors int
+
+	/**
+	 * From CSI 16t, or CSI 14t divided by
+	 * the size in cells. -1 if unknown.
+	 */
+	WidthOfACellInPixels  int
+	HeightOfACellInPixels int
+
+	/**
+	 * Bytes that came in while probing that
+	 * weren't answers (the user typing).
+	 */
+	Unparsed []byte
+}
+
+/**
+ * Set by SetTerminalCapabilities, nil when
+ * the terminal was not probed.
+ */
+var probedCapabilities *TerminalCapabilities
+
+func SetTerminalCapabilities(caps *TerminalCapabilities) {
+	probedCapabilities = caps
+}
+
+func GetTerminalCapabilities() *TerminalCapabilities {
+	return probedCapabilities
+}
+
+const kittyProbeImageID = "31"
+
+/**
+ * How long to keep reading after the timeout when
+ * the terminal hasn't answered DA1 yet. Anything it
+ * answers after we stop reading would go to the input
+ * loop and be typed into the app as keystrokes.
+ */
+const probeDrainTimeout = time.Second
+
+/**
+ * Terminals answer in order, and every terminal
+ * answers DA1, so it goes last. Once we see its
+ * answer there is nothing more to wait for.
+ */
+func terminalProbeQueries() string {
+	var sb strings.Builder
+	sb.WriteString(escapecodes.QueryTerminalVersion)
+	for _, capability := range []string{"RGB", "Tc", "colors"} {
+		sb.WriteString(escapecodes.QueryTermcapStart)
+		sb.WriteString(strings.ToUpper(hex.EncodeToString([]byte(capability))))
+		sb.WriteString(escapecodes.StringTerminator)
+	}
+	sb.WriteString("\x1b_Gi=" + kittyProbeImageID + ",s=1,v=1,a=q,t=d,f=24;AAAA")
+	sb.WriteString(escapecodes.StringTerminator)
+	sb.WriteString(escapecodes.QueryWindowSizeInPixels)
+	sb.WriteString(escapecodes.QueryCellSizeInPixels)
+	sb.WriteString(escapecodes.QueryPrimaryDeviceAttributes)
+	return sb.String()
+}
+
+/**
+ * Ask the terminal what it supports. The terminal must
+ * already be in raw mode, and nothing else may be reading
+ * stdin. Returns nil if stdin is not a terminal.
+ */
+func ProbeTerminal(timeout time.Duration) *TerminalCapabilities {
+	fd := int(os.Stdin.Fd())
+	ws, err := GetWinsize(uintptr(fd))
+	if err != nil {
+		return nil
+	}
+
+	/**
+	 * Read through a non blocking dup of stdin,
+	 * so we get read deadlines without taking
+	 * os.Stdin away from the input loop.
+	 */
+	dupFD, err := syscall.Dup(fd)
+	if err != nil {
+		return nil
+	}
+	if err := syscall.SetNonblock(dupFD, true); err != nil {
+		syscall.Close(dupFD)
+		return nil
+	}
+	in := os.NewFile(uintptr(dupFD), "stdin-probe")
+	defer func() {
+		// O_NONBLOCK is shared with stdin, put it back
+		syscall.SetNonblock(dupFD, false)
+		in.Close()
+	}()
+
+	caps := &TerminalCapabilities{
+		WidthOfACellInPixels:  -1,
+		HeightOfACellInPixels: -1,
+	}
+	if _, err := os.Stdout.WriteString(terminalProbeQueries()); err != nil {
+		return caps
+	}
+
+	deadline := time.Now().Add(timeout)
+	if err := in.SetReadDeadline(deadline); err != nil {
+		return caps
+	}
+
+	var pending []byte
+	buf := make([]byte, 1024)
+	draining := false
+	for !caps.Answered {
+		n, err := in.Read(buf)
+		pending = append(pending, buf[:n]...)
+		pending = caps.parseAnswers(pending, ws)
+		if err == nil {
+			continue
+		}
+		if draining || !errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		/**
+		 * A slow terminal (ex: over ssh), wait for the
+		 * rest of the answers, up to DA1, so they
+		 * don't leak into the input.
+		 */
+		draining = true
+		if err := in.SetReadDeadline(time.Now().Add(probeDrainTimeout)); err != nil {
+			break
+		}
+	}
+	caps.Unparsed = append(caps.Unparsed, pending...)
+	return caps
+}
+
+/**
+ * Consume every complete answer in data, returns
+ * what is left (an incomplete answer).
+ */
+func (caps *TerminalCapabilities) parseAnswers(data []byte, ws WinSize) []byte {
+	for len(data) > 0 {
+		if data[0] != 0x1b {
+			caps.Unparsed = append(caps.Unparsed, data[0])
+			data = data[1:]
+			continue
+		}
+		if len(data) < 2 {
+			return data
+		}
+		switch data[1] {
+		case 'P', '_':
+			end := strings.Index(string(data), escapecodes.StringTerminator)
+			if end < 0 {
+				return data
+			}
+			body := string(data[2:end])
+			if data[1] == 'P' {
+				caps.parseDCS(body)
+			} else {
+				caps.parseAPC(body)
+			}
+			data = data[end+len(escapecodes.StringTerminator):]
+		case '[':
+			end := 2
+			for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+				end++
+			}
+			if end == len(data) {
+				return data
+			}
+			if !caps.parseCSI(string(data[2:end]), data[end], ws) {
+				caps.Unparsed = append(caps.Unparsed, data[:end+1]...)
+			}
+			data = data[end+1:]
+		default:
+			caps.Unparsed = append(caps.Unparsed, data[0])
+			data = data[1:]
+		}
+	}
+	return data
+}
+
+func (caps *TerminalCapabilities) parseDCS(body string) {
+	if name, ok := strings.CutPrefix(body, ">|"); ok {
+		caps.Name = name
+		return
+	}
+	answer, ok := strings.CutPrefix(body, "1+r")
+	if !ok {
+		return
+	}
+	for _, entry := range strings.Split(answer, ";") {
+		hexName, hexValue, _ := strings.Cut(entry, "=")
+		name, err := hex.DecodeString(hexName)
+		if err != nil {
+			continue
+		}
+		value, _ := hex.DecodeString(hexValue)
+		switch string(name) {
+		case "RGB", "Tc":
+			caps.Truecolor = true
+		case "colors":
+			if colors, err := strconv.Atoi(string(value)); err == nil {
+				caps.Colors = colors
+			}
+		}
+	}
+}
+
+func (caps *TerminalCapabilities) parseAPC(body string) {
+	keys, message, _ := strings.Cut(body, ";")
+	if !strings.HasPrefix(keys, "G") {
+		return
+	}
+	for _, key := range strings.Split(keys[1:], ",") {
+		if key == "i="+kittyProbeImageID && message == "OK" {
+			caps.Kitty = true
+		}
+	}
+}
+
+/**
+ * Returns false if it wasn't one of our answers
+ */
+func (caps *TerminalCapabilities) parseCSI(params string, final byte, ws WinSize) bool {
+	switch final {
+	case 'c':
+		attributes, ok := strings.CutPrefix(params, "?")
+		if !ok {
+			return false
+		}
+		for _, attribute := range strings.Split(attributes, ";") {
+			if attribute == "4" {
+				caps.Sixels = true
+			}
+		}
+		caps.Answered = true
+		return true
+	case 't':
+		fields := strings.Split(params, ";")
+		if len(fields) != 3 {
+			return false
+		}
+		height, errH := strconv.Atoi(fields[1])
+		width, errW := strconv.Atoi(fields[2])
+		if errH != nil || errW != nil || width <= 0 || height <= 0 {
+			return true
+		}
+		switch fields[0] {
+		case "6":
+			caps.WidthOfACellInPixels = width
+			caps.HeightOfACellInPixels = height
+		case "4":
+			// 16t is more accurate, only use this if it hasn't answered
+			if caps.WidthOfACellInPixels <= 0 && ws.Col > 0 && ws.Row > 0 {
+				caps.WidthOfACellInPixels = width / int(ws.Col)
+				caps.HeightOfACellInPixels = height / int(ws.Row)
+			}
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+/**
+ * ok is false if there is no answer to go on.
+ * iTerm2 images can't be probed for, so they
+ * are left to the environment.
+ */
+func (caps *TerminalCapabilities) PixelMode() (mode PixelMode, ok bool) {
+	if caps == nil || !caps.Answered {
+		return PixelMode_Symbols, false
+	}
+	switch {
+	case caps.Kitty:
+		return PixelMode_Kitty, true
+	case caps.Sixels:
+		return PixelMode_Sixels, true
+	default:
+		return PixelMode_Symbols, true
+	}
+}
+
+/**
+ * ok is false if the terminal didn't say.
+ */
+func (caps *TerminalCapabilities) CellColorMode() (mode CellColorMode, ok bool) {
+	if caps == nil {
+		return CellColorMode_Unsupported, false
+	}
+	switch {
+	case caps.Truecolor, caps.Colors >= 1<<24:
+		return CellColorMode_Truecolor, true
+	case caps.Colors >= 256:
+		return CellColorMode_Indexed256, true
+	case caps.Colors > 0:
+		return CellColorMode_Indexed16, true
+	default:
+		return CellColorMode_Unsupported, false
+	}
+}
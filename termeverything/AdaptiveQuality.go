@@ -0,0 +1,214 @@
+package termeverything
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmulet/term.everything/framebuffertoansi"
+)
+
+type QualityLevel int
+
+const (
+	QualityLevel_Full QualityLevel = iota
+	QualityLevel_256Colors
+	QualityLevel_SimpleSymbols
+	QualityLevel_16Colors
+)
+
+/**
+ * Writes shorter than this didn't wait
+ * on the terminal, so they don't tell
+ * us how fast the connection is.
+ */
+const blockedWriteSeconds = 0.002
+
+/**
+ * Keeps frames from piling up when the connection
+ * to the terminal is slow (ex: over ssh). Frames
+ * are drawn no faster than they can be sent, and
+ * quality drops until a frame can be sent in
+ * TargetLatencySeconds.
+ */
+type AdaptiveQuality struct {
+	TargetLatencySeconds float64
+
+	/**
+	 * Estimated speed of the connection,
+	 * 0 until a write has blocked.
+	 */
+	BytesPerSecond float64
+	/**
+	 * Running average of bytes written per frame
+	 */
+	BytesPerFrame float64
+	/**
+	 * How long the last frame will take to get
+	 * to the terminal, the next frame waits
+	 * at least this long.
+	 */
+	FrameSendSeconds float64
+
+	Level QualityLevel
+
+	TimeOfLastLevelChange time.Time
+	/**
+	 * When the latency first went well under the
+	 * target, the zero time if it is not under.
+	 */
+	TimeUnderTarget time.Time
+}
+
+/**
+ * target is in milliseconds, returns nil
+ * (no adapting) for 0.
+ */
+func MakeAdaptiveQuality(target string) *AdaptiveQuality {
+	targetMilliseconds := 100.0
+	if target != "" {
+		if v, err := strconv.ParseFloat(target, 64); err == nil && v >= 0 {
+			targetMilliseconds = v
+		}
+	}
+	if targetMilliseconds == 0 {
+		return nil
+	}
+	return &AdaptiveQuality{
+		TargetLatencySeconds: targetMilliseconds / 1000,
+	}
+}
+
+/**
+ * Call after every write to the terminal
+ */
+func (aq *AdaptiveQuality) Update(bytesWritten int, writeDuration time.Duration) {
+	if aq == nil || bytesWritten == 0 {
+		return
+	}
+	seconds := writeDuration.Seconds()
+	if seconds >= blockedWriteSeconds {
+		sample := float64(bytesWritten) / seconds
+		if aq.BytesPerSecond == 0 {
+			aq.BytesPerSecond = sample
+		} else {
+			aq.BytesPerSecond = 0.8*aq.BytesPerSecond + 0.2*sample
+		}
+	} else if aq.BytesPerSecond > 0 {
+		/**
+		 * The write fit in the kernel's (or ssh's)
+		 * buffer, which says little about the
+		 * connection. It never lowers the estimate,
+		 * and raises it by at most 20% per write, so
+		 * blocked writes bring it back down as fast.
+		 */
+		sample := min(max(float64(bytesWritten)/blockedWriteSeconds, aq.BytesPerSecond), 2*aq.BytesPerSecond)
+		aq.BytesPerSecond = 0.8*aq.BytesPerSecond + 0.2*sample
+	}
+
+	if aq.BytesPerFrame == 0 {
+		aq.BytesPerFrame = float64(bytesWritten)
+	} else {
+		aq.BytesPerFrame = 0.9*aq.BytesPerFrame + 0.1*float64(bytesWritten)
+	}
+
+	if aq.BytesPerSecond == 0 {
+		return
+	}
+	aq.FrameSendSeconds = float64(bytesWritten) / aq.BytesPerSecond
+	aq.updateLevel(aq.BytesPerFrame / aq.BytesPerSecond)
+}
+
+func (aq *AdaptiveQuality) updateLevel(latencySeconds float64) {
+	now := time.Now()
+	if now.Sub(aq.TimeOfLastLevelChange) < time.Second {
+		return
+	}
+	if latencySeconds > aq.TargetLatencySeconds {
+		aq.TimeUnderTarget = time.Time{}
+		if aq.Level < QualityLevel_16Colors {
+			aq.Level++
+			aq.TimeOfLastLevelChange = now
+		}
+		return
+	}
+	/**
+	 * Going up a level makes frames bigger,
+	 * so only do it when well under the target
+	 * for a while.
+	 */
+	if latencySeconds > aq.TargetLatencySeconds/3 || aq.Level == QualityLevel_Full {
+		aq.TimeUnderTarget = time.Time{}
+		return
+	}
+	if aq.TimeUnderTarget.IsZero() {
+		aq.TimeUnderTarget = now
+		return
+	}
+	if now.Sub(aq.TimeUnderTarget) >= 5*time.Second {
+		aq.Level--
+		aq.TimeOfLastLevelChange = now
+		aq.TimeUnderTarget = time.Time{}
+	}
+}
+
+/**
+ * Pass the current level on to the renderer
+ */
+func (aq *AdaptiveQuality) Apply(ds *framebuffertoansi.DrawState) {
+	if aq == nil {
+		return
+	}
+	switch aq.Level {
+	case QualityLevel_Full:
+		ds.MaxColorMode = framebuffertoansi.CellColorMode_Unsupported
+		ds.SimpleSymbols = false
+	case QualityLevel_256Colors:
+		ds.MaxColorMode = framebuffertoansi.CellColorMode_Indexed256
+		ds.SimpleSymbols = false
+	case QualityLevel_SimpleSymbols:
+		ds.MaxColorMode = framebuffertoansi.CellColorMode_Indexed256
+		ds.SimpleSymbols = true
+	case QualityLevel_16Colors:
+		ds.MaxColorMode = framebuffertoansi.CellColorMode_Indexed16
+		ds.SimpleSymbols = true
+	}
+}
+
+/**
+ * Don't send the next frame before
+ * the last one got through.
+ */
+func (aq *AdaptiveQuality) MinFrameSeconds() float64 {
+	if aq == nil {
+		return 0
+	}
+	return aq.FrameSendSeconds
+}
+
+/**
+ * For the status line, empty when
+ * running at full quality and speed.
+ */
+func (aq *AdaptiveQuality) Describe() string {
+	if aq == nil {
+		return ""
+	}
+	parts := make([]string, 0, 2)
+	switch aq.Level {
+	case QualityLevel_256Colors:
+		parts = append(parts, "256 colors")
+	case QualityLevel_SimpleSymbols:
+		parts = append(parts, "256 colors, half blocks")
+	case QualityLevel_16Colors:
+		parts = append(parts, "16 colors, half blocks")
+	}
+	if aq.FrameSendSeconds > 1.0/30 {
+		parts = append(parts, fmt.Sprintf("%.0f fps", 1/aq.FrameSendSeconds))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "slow link: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,80 @@
+package termeverything
+
+import (
+	"testing"
+	"time"
+)
+
+type qualityWrite struct {
+	bytes    int
+	duration time.Duration
+}
+
+func TestAdaptiveQualityEstimate(t *testing.T) {
+	tests := []struct {
+		name             string
+		writes           []qualityWrite
+		wantMin, wantMax float64
+	}{
+		{
+			name:    "no estimate until a write blocks",
+			writes:  []qualityWrite{{100000, 0}},
+			wantMin: 0, wantMax: 0,
+		},
+		{
+			name:    "blocked write",
+			writes:  []qualityWrite{{100000, time.Second}},
+			wantMin: 100000, wantMax: 100000,
+		},
+		{
+			name:    "a big buffered write raises it by at most 20%",
+			writes:  []qualityWrite{{100000, time.Second}, {10000000, 0}},
+			wantMin: 120000, wantMax: 120000,
+		},
+		{
+			name:    "a small fast write doesn't lower it",
+			writes:  []qualityWrite{{100000, time.Second}, {10, 0}},
+			wantMin: 100000, wantMax: 100000,
+		},
+		{
+			name: "blocked writes bring it back down",
+			writes: []qualityWrite{
+				{100000, time.Second}, {10000000, 0}, {10000000, 0}, {10000000, 0},
+				{100000, time.Second}, {100000, time.Second}, {100000, time.Second},
+				{100000, time.Second}, {100000, time.Second}, {100000, time.Second},
+			},
+			wantMin: 100000, wantMax: 120000,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			aq := MakeAdaptiveQuality("")
+			for _, write := range test.writes {
+				aq.Update(write.bytes, write.duration)
+			}
+			if aq.BytesPerSecond < test.wantMin || aq.BytesPerSecond > test.wantMax {
+				t.Errorf("BytesPerSecond = %f, want %f to %f", aq.BytesPerSecond, test.wantMin, test.wantMax)
+			}
+		})
+	}
+}
+
+func TestMakeAdaptiveQuality(t *testing.T) {
+	tests := []struct {
+		target string
+		want   float64
+	}{
+		{"", 0.1},
+		{"250", 0.25},
+		{"-5", 0.1},
+		{"slow", 0.1},
+	}
+	for _, test := range tests {
+		if got := MakeAdaptiveQuality(test.target); got == nil || got.TargetLatencySeconds != test.want {
+			t.Errorf("MakeAdaptiveQuality(%q) = %+v, want target %f", test.target, got, test.want)
+		}
+	}
+	if MakeAdaptiveQuality("0") != nil {
+		t.Error("0 should turn adapting off")
+	}
+}
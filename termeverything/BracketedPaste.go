@@ -0,0 +1,282 @@
+package termeverything
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmulet/term.everything/escapecodes"
+	"github.com/mmulet/term.everything/wayland"
+)
+
+/**
+ * Everything the terminal sent between
+ * ESC[200~ and ESC[201~ when bracketed paste
+ * is enabled.
+ */
+type Paste struct {
+	Text      string
+	Modifiers int
+}
+
+func (*Paste) isXkbdCode() {}
+func (p *Paste) OrModifiers(modifiers int) {
+	p.Modifiers |= modifiers
+}
+
+func (p *Paste) GetModifiers() int {
+	return p.Modifiers
+}
+
+type PasteMode int
+
+const (
+	/**
+	 * Set the paste as the wayland selection
+	 * then send ctrl+v to the client
+	 */
+	PasteMode_Clipboard PasteMode = iota
+	/**
+	 * Type every character of the paste
+	 * as a key press
+	 */
+	PasteMode_Type
+)
+
+func ParsePasteMode(mode string) (PasteMode, error) {
+	switch mode {
+	case "clipboard":
+		return PasteMode_Clipboard, nil
+	case "type":
+		return PasteMode_Type, nil
+	}
+	return PasteMode_Clipboard, fmt.Errorf("expected clipboard or type")
+}
+
+/**
+ * A paste can be bigger than one read from
+ * stdin, so keep the paste around until we
+ * see the end marker.
+ */
+type BracketedPasteParser struct {
+	InPaste bool
+	Buffer  []byte
+	/**
+	 * The start of a start marker at the end of
+	 * the last read, ex: "\x1b[20"
+	 */
+	Pending []byte
+}
+
+/**
+ * Shorter pieces of the start marker aren't held,
+ * "\x1b[" could be alt+[, which would then
+ * wait for the next key.
+ */
+const minPendingPasteStart = 3
+
+func (p *BracketedPasteParser) Parse(data []byte) []XkbdCode {
+	start := []byte(escapecodes.BracketedPasteStart)
+	end := []byte(escapecodes.BracketedPasteEnd)
+
+	if len(p.Pending) > 0 {
+		data = append(p.Pending, data...)
+		p.Pending = nil
+	}
+
+	out := make([]XkbdCode, 0)
+	for len(data) > 0 {
+		if !p.InPaste {
+			index := bytes.Index(data, start)
+			if index == -1 {
+				for n := len(start) - 1; n >= minPendingPasteStart; n-- {
+					if bytes.HasSuffix(data, start[:n]) {
+						p.Pending = bytes.Clone(data[len(data)-n:])
+						data = data[:len(data)-n]
+						break
+					}
+				}
+				if len(data) > 0 {
+					out = append(out, ConvertKeycodeToXbdCode(data)...)
+				}
+				return out
+			}
+			if index > 0 {
+				out = append(out, ConvertKeycodeToXbdCode(data[:index])...)
+			}
+			p.InPaste = true
+			p.Buffer = p.Buffer[:0]
+			data = data[index+len(start):]
+			continue
+		}
+		/**
+		 * The end marker could be split across
+		 * two reads, so search the whole buffer.
+		 */
+		searchFrom := max(0, len(p.Buffer)-len(end)+1)
+		p.Buffer = append(p.Buffer, data...)
+		index := bytes.Index(p.Buffer[searchFrom:], end)
+		if index == -1 {
+			return out
+		}
+		index += searchFrom
+		rest := p.Buffer[index+len(end):]
+		out = append(out, &Paste{Text: string(p.Buffer[:index])})
+		p.InPaste = false
+		data = bytes.Clone(rest)
+		p.Buffer = p.Buffer[:0]
+	}
+	return out
+}
+
+/**
+ * Pastes longer than this (in characters) are put
+ * on the clipboard even in PasteMode_Type, typing
+ * them would take minutes.
+ */
+const maxTypedPasteLength = 2000
+
+/**
+ * Must hold InputAccess and the clients' locks
+ * (see ProcessCodes). A new paste cancels the
+ * one being typed.
+ */
+func (tw *TerminalWindow) HandlePaste(paste *Paste) {
+	if len(paste.Text) == 0 {
+		return
+	}
+	tw.CancelPasteTyping()
+	client := tw.FocusedClient()
+	if client == nil {
+		return
+	}
+	if tw.PasteMode == PasteMode_Type && utf8.RuneCountInString(paste.Text) <= maxTypedPasteLength {
+		/**
+		 * Text the keymap can't type (not ascii)
+		 * goes on the clipboard instead of
+		 * being typed with pieces missing.
+		 */
+		if codes, ok := pasteKeyCodes(paste.Text); ok {
+			tw.TypePaste(client, codes)
+			return
+		}
+	}
+	wayland.SetSelectionText(tw.Clients, paste.Text)
+	focused := []*wayland.Client{client}
+	SendModifiersTo(focused, ModControl)
+	wayland.SendKeyboardKey(focused, uint32(KEY_V), true)
+	wayland.SendKeyboardKey(focused, uint32(KEY_V), false)
+	SendModifiersTo(focused, 0)
+}
+
+/**
+ * The key (and modifiers) that types r, nil for
+ * characters that aren't on the keymap (not ascii).
+ */
+func pasteKeyCode(r rune) *KeyCode {
+	switch {
+	case r == '\n' || r == '\r':
+		return &KeyCode{KeyCode: KEY_ENTER}
+	case r == '\t':
+		return &KeyCode{KeyCode: KEY_TAB}
+	case r >= 32 && r < 127:
+		return KeycodeSingleCodes(int(r))
+	}
+	return nil
+}
+
+/**
+ * The keys that type text, ok is false if
+ * any character can't be typed.
+ */
+func pasteKeyCodes(text string) (codes []*KeyCode, ok bool) {
+	codes = make([]*KeyCode, 0, len(text))
+	for _, r := range text {
+		code := pasteKeyCode(r)
+		if code == nil {
+			return nil, false
+		}
+		codes = append(codes, code)
+	}
+	return codes, true
+}
+
+/**
+ * Type out the keys one at a time on another
+ * goroutine, waiting between each key so the client
+ * doesn't drop any. The client is only locked while
+ * a key is sent, so it gets each key as it is typed
+ * and the terminal keeps drawing and taking input.
+ * Typing a key (or pasting again) cancels it,
+ * see CancelPasteTyping.
+ */
+func (tw *TerminalWindow) TypePaste(client *wayland.Client, codes []*KeyCode) {
+	cancel := make(chan struct{})
+	tw.PasteCancel = cancel
+	interval := tw.PasteKeyInterval
+	go func() {
+		for _, code := range codes {
+			if !typePasteKey(client, code, cancel) {
+				return
+			}
+			select {
+			case <-cancel:
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+/**
+ * Returns false when the paste should stop,
+ * because it was canceled or the
+ * client went away.
+ */
+func typePasteKey(client *wayland.Client, code *KeyCode, cancel chan struct{}) bool {
+	client.Access.Lock()
+	defer client.Access.Unlock()
+	/**
+	 * Checked again with the lock held, the
+	 * paste could have been canceled
+	 * while we waited for it.
+	 */
+	select {
+	case <-cancel:
+		return false
+	default:
+	}
+	if client.Status != wayland.ClientStatus_Connected {
+		return false
+	}
+	clients := []*wayland.Client{client}
+	SendModifiersTo(clients, code.Modifiers)
+	wayland.SendKeyboardKey(clients, uint32(code.KeyCode), true)
+	wayland.SendKeyboardKey(clients, uint32(code.KeyCode), false)
+	SendModifiersTo(clients, 0)
+	return true
+}
+
+/**
+ * Stop typing the paste, if there is one.
+ * Must hold InputAccess.
+ */
+func (tw *TerminalWindow) CancelPasteTyping() {
+	if tw.PasteCancel != nil {
+		close(tw.PasteCancel)
+		tw.PasteCancel = nil
+	}
+}
+
+func ParsePasteTypeRate(rate string) time.Duration {
+	const defaultKeysPerSecond = 200.0
+	keysPerSecond := defaultKeysPerSecond
+	if rate != "" {
+		if v, err := strconv.ParseFloat(rate, 64); err == nil && v > 0 {
+			keysPerSecond = v
+		}
+	}
+	return time.Duration(float64(time.Second) / keysPerSecond)
+}
@@ -0,0 +1,174 @@
+package termeverything
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func describeCodes(codes []XkbdCode) []string {
+	out := make([]string, 0, len(codes))
+	for _, code := range codes {
+		switch c := code.(type) {
+		case *Paste:
+			out = append(out, "paste:"+c.Text)
+		case *KeyCode:
+			out = append(out, fmt.Sprintf("key:%d/%d", c.KeyCode, c.Modifiers))
+		default:
+			out = append(out, fmt.Sprintf("%T", code))
+		}
+	}
+	return out
+}
+
+func key(code Linux_Event_Codes) string {
+	return fmt.Sprintf("key:%d/0", code)
+}
+
+func TestBracketedPasteParser(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads []string
+		want  []string
+	}{
+		{
+			name:  "keys only",
+			reads: []string{"a"},
+			want:  []string{key(KEY_A)},
+		},
+		{
+			name:  "one read",
+			reads: []string{"\x1b[200~hello\x1b[201~"},
+			want:  []string{"paste:hello"},
+		},
+		{
+			name:  "keys around the paste",
+			reads: []string{"a", "\x1b[200~hi\x1b[201~", "b"},
+			want:  []string{key(KEY_A), "paste:hi", key(KEY_B)},
+		},
+		{
+			name:  "empty paste",
+			reads: []string{"\x1b[200~\x1b[201~"},
+			want:  []string{"paste:"},
+		},
+		{
+			name:  "text split across reads",
+			reads: []string{"\x1b[200~hel", "lo wor", "ld\x1b[201~"},
+			want:  []string{"paste:hello world"},
+		},
+		{
+			name:  "end marker split across reads",
+			reads: []string{"\x1b[200~hello\x1b[2", "01~"},
+			want:  []string{"paste:hello"},
+		},
+		{
+			name:  "end marker split after the escape",
+			reads: []string{"\x1b[200~hello\x1b", "[201~"},
+			want:  []string{"paste:hello"},
+		},
+		{
+			name:  "start marker split across reads",
+			reads: []string{"\x1b[20", "0~hello\x1b[201~"},
+			want:  []string{"paste:hello"},
+		},
+		{
+			name:  "start marker split one byte before the end",
+			reads: []string{"\x1b[200", "~hello\x1b[201~"},
+			want:  []string{"paste:hello"},
+		},
+		{
+			name:  "held start that wasn't a paste",
+			reads: []string{"\x1b[20", "0~x\x1b[201~", "a"},
+			want:  []string{"paste:x", key(KEY_A)},
+		},
+		{
+			name:  "two pastes in one read",
+			reads: []string{"\x1b[200~a\x1b[201~\x1b[200~b\x1b[201~"},
+			want:  []string{"paste:a", "paste:b"},
+		},
+		{
+			name:  "escape codes inside a paste are text",
+			reads: []string{"\x1b[200~\x1b[A\x1b[201~"},
+			want:  []string{"paste:\x1b[A"},
+		},
+		{
+			name:  "unfinished paste",
+			reads: []string{"\x1b[200~hello"},
+			want:  []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var p BracketedPasteParser
+			got := []string{}
+			for _, read := range test.reads {
+				got = append(got, describeCodes(p.Parse([]byte(read)))...)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPasteKeyCodes(t *testing.T) {
+	tests := []struct {
+		text   string
+		wantOK bool
+		want   int
+	}{
+		{"", true, 0},
+		{"hello", true, 5},
+		{"a\tb\nc\r", true, 6},
+		{"~!@#", true, 4},
+		{"héllo", false, 0},
+		{"日本", false, 0},
+		{"a\x00b", false, 0},
+		{"tab\x7f", false, 0},
+	}
+	for _, test := range tests {
+		codes, ok := pasteKeyCodes(test.text)
+		if ok != test.wantOK || len(codes) != test.want {
+			t.Errorf("pasteKeyCodes(%q) = %d codes, %v, want %d, %v", test.text, len(codes), ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestParsePasteMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    PasteMode
+		wantErr bool
+	}{
+		{"clipboard", PasteMode_Clipboard, false},
+		{"type", PasteMode_Type, false},
+		{"", PasteMode_Clipboard, true},
+		{"Type", PasteMode_Clipboard, true},
+		{"keys", PasteMode_Clipboard, true},
+	}
+	for _, test := range tests {
+		got, err := ParsePasteMode(test.mode)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParsePasteMode(%q) = %v, %v", test.mode, got, err)
+		}
+	}
+}
+
+func TestParsePasteTypeRate(t *testing.T) {
+	tests := []struct {
+		rate string
+		want string
+	}{
+		{"", "5ms"},
+		{"100", "10ms"},
+		{"0.5", "2s"},
+		{"0", "5ms"},
+		{"-3", "5ms"},
+		{"fast", "5ms"},
+	}
+	for _, test := range tests {
+		if got := ParsePasteTypeRate(test.rate).String(); got != test.want {
+			t.Errorf("ParsePasteTypeRate(%q) = %s, want %s", test.rate, got, test.want)
+		}
+	}
+}
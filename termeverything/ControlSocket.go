@@ -0,0 +1,109 @@
+package termeverything
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+/**
+ * Next to the wayland socket, takes the same
+ * commands as --headless-input, one per line,
+ * and answers each with "ok <reply>" or
+ * "error <message>".
+ */
+func ControlSocketPath(waylandDisplayName string) string {
+	return wayland.GetSocketPathFromName(waylandDisplayName) + ".control"
+}
+
+func (tw *TerminalWindow) ListenControlSocket(path string) error {
+	_ = os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	tw.ControlSocketPath = path
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go tw.HandleControlConnection(conn)
+		}
+	}()
+	return nil
+}
+
+func (tw *TerminalWindow) HandleControlConnection(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		reply, err := tw.RunCommand(line)
+		if err != nil {
+			fmt.Fprintf(conn, "error %s\n", err)
+			continue
+		}
+		fmt.Fprintf(conn, "ok %s\n", reply)
+	}
+}
+
+/**
+ * For --screenshot and friends, run a command in
+ * the term.everything that is already running.
+ */
+func SendControlCommand(waylandDisplayName string, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", ControlSocketPath(waylandDisplayName), 5*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("is term.everything running on %s? %w", waylandDisplayName, err)
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+		return "", err
+	}
+	answer, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	answer = strings.TrimSuffix(answer, "\n")
+	if message, ok := strings.CutPrefix(answer, "error "); ok {
+		return "", fmt.Errorf("%s", message)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(answer, "ok")), nil
+}
+
+/**
+ * --screenshot and --screenshot-window, returns the exit code
+ */
+func RunScreenshotCommand(args *CommandLineArgs) int {
+	displayName := args.WaylandDisplayNameArg
+	for _, name := range []string{"WAYLAND_DISPLAY_NAME", "WAYLAND_DISPLAY"} {
+		if displayName == "" {
+			displayName = os.Getenv(name)
+		}
+	}
+	if displayName == "" {
+		fmt.Fprintf(os.Stderr, "Which term.everything? Pass its --wayland-display-name\n")
+		return 1
+	}
+	command := "screenshot"
+	if args.ScreenshotWindow {
+		command = "screenshot window"
+	}
+	path, err := SendControlCommand(displayName, command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Screenshot failed: %v\n", err)
+		return 1
+	}
+	fmt.Println(path)
+	return 0
+}
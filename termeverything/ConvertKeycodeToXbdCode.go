@@ -96,6 +96,11 @@ func ConvertKeycodeToXbdCode(data []byte) []XkbdCode {
 	if len(data) == 2 {
 		return parse_length_2(data)
 	}
+	if len(data) > 3 {
+		if out, ok := splitFocusEvents(data); ok {
+			return out
+		}
+	}
 	if len(data) == 3 {
 		return parse_length_3(data)
 	}
@@ -244,6 +249,10 @@ func parse_length_3(data []byte) []XkbdCode {
 			return []XkbdCode{&KeyCode{KeyCode: KEY_HOME, Modifiers: 0}}
 		case 90: // 'Z' => Shift+Tab
 			return []XkbdCode{&KeyCode{KeyCode: KEY_TAB, Modifiers: ModShift}}
+		case 73: // 'I' => terminal focus in
+			return []XkbdCode{&FocusChange{Focused: true}}
+		case 79: // 'O' => terminal focus out
+			return []XkbdCode{&FocusChange{Focused: false}}
 		// These work for alt+F1, shift+F2, etc in some terminals
 		case 80: // 'P'
 			return []XkbdCode{&KeyCode{KeyCode: KEY_F1, Modifiers: 0}}
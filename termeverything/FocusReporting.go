@@ -0,0 +1,55 @@
+package termeverything
+
+import (
+	"bytes"
+
+	"github.com/mmulet/term.everything/escapecodes"
+)
+
+/**
+ * Sent by the terminal (when focus reporting is on)
+ * when the terminal window/tab gains or loses focus.
+ */
+type FocusChange struct {
+	Focused   bool
+	Modifiers int
+}
+
+func (*FocusChange) isXkbdCode() {}
+func (f *FocusChange) OrModifiers(modifiers int) {
+	f.Modifiers |= modifiers
+}
+
+func (f *FocusChange) GetModifiers() int {
+	return f.Modifiers
+}
+
+/**
+ * Focus events can arrive in the same read as
+ * other input, so pull them out and parse what is
+ * before and after them separately.
+ */
+func splitFocusEvents(data []byte) ([]XkbdCode, bool) {
+	focusIn := bytes.Index(data, []byte(escapecodes.FocusIn))
+	focusOut := bytes.Index(data, []byte(escapecodes.FocusOut))
+
+	index := focusIn
+	focused := true
+	if focusIn == -1 || (focusOut != -1 && focusOut < focusIn) {
+		index = focusOut
+		focused = false
+	}
+	if index == -1 {
+		return nil, false
+	}
+
+	out := make([]XkbdCode, 0)
+	if index > 0 {
+		out = append(out, ConvertKeycodeToXbdCode(data[:index])...)
+	}
+	out = append(out, &FocusChange{Focused: focused})
+	if rest := data[index+len(escapecodes.FocusIn):]; len(rest) > 0 {
+		out = append(out, ConvertKeycodeToXbdCode(rest)...)
+	}
+	return out, true
+}
@@ -0,0 +1,425 @@
+package termeverything
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type HeadlessVideoFormat int
+
+const (
+	HeadlessVideoFormat_Y4M HeadlessVideoFormat = iota
+	HeadlessVideoFormat_Rawvideo
+)
+
+func ParseHeadlessVideoFormat(format string) HeadlessVideoFormat {
+	switch format {
+	case "rawvideo", "raw":
+		return HeadlessVideoFormat_Rawvideo
+	default:
+		return HeadlessVideoFormat_Y4M
+	}
+}
+
+/**
+ * Where the desktop goes when there is no terminal:
+ * PNG snapshots and/or a stream of raw video frames.
+ */
+type HeadlessOutput struct {
+	/**
+	 * Directory for PNG snapshots,
+	 * empty for no snapshots.
+	 */
+	PNGDir string
+	/**
+	 * 0 to only write snapshots
+	 * when asked to.
+	 */
+	PNGIntervalSeconds float64
+	TimeOfLastPNG      time.Time
+	PNGCount           int
+
+	Video              io.WriteCloser
+	VideoFormat        HeadlessVideoFormat
+	VideoFrameSeconds  float64
+	TimeOfLastVideo    time.Time
+	VideoHeaderWritten bool
+	/**
+	 * Reused for every video frame
+	 */
+	VideoFrame []byte
+
+	/**
+	 * Paths (or "" for the next file in PNGDir) to
+	 * snapshot, from the input script.
+	 */
+	SnapshotRequests chan string
+}
+
+func MakeHeadlessOutput(args *CommandLineArgs) (*HeadlessOutput, error) {
+	ho := &HeadlessOutput{
+		PNGDir:            args.HeadlessPNGDir,
+		VideoFormat:       ParseHeadlessVideoFormat(args.HeadlessVideoFormat),
+		VideoFrameSeconds: 1.0 / 30,
+		SnapshotRequests:  make(chan string, 32),
+	}
+	if args.HeadlessPNGInterval != "" {
+		if seconds, err := strconv.ParseFloat(args.HeadlessPNGInterval, 64); err == nil && seconds >= 0 {
+			ho.PNGIntervalSeconds = seconds
+		}
+	} else if ho.PNGDir != "" {
+		ho.PNGIntervalSeconds = 1
+	}
+	if args.HeadlessVideoFrameRate != "" {
+		if fps, err := strconv.ParseFloat(args.HeadlessVideoFrameRate, 64); err == nil && fps > 0 {
+			ho.VideoFrameSeconds = 1 / fps
+		}
+	}
+	if ho.PNGDir != "" {
+		if err := os.MkdirAll(ho.PNGDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if args.HeadlessVideo != "" {
+		video, err := OpenHeadlessStream(args.HeadlessVideo, true)
+		if err != nil {
+			return nil, err
+		}
+		ho.Video = video
+	}
+	return ho, nil
+}
+
+/**
+ * "-" is stdin/stdout, "fd:N" is an already
+ * open file descriptor, anything else is a path.
+ */
+func OpenHeadlessStream(spec string, write bool) (*os.File, error) {
+	if spec == "-" {
+		if write {
+			/**
+			 * The wayland package prints its diagnostics
+			 * to os.Stdout, send them to stderr so they
+			 * don't end up in the middle of the stream.
+			 */
+			stream := os.Stdout
+			os.Stdout = os.Stderr
+			return stream, nil
+		}
+		return os.Stdin, nil
+	}
+	if fdString, ok := strings.CutPrefix(spec, "fd:"); ok {
+		fd, err := strconv.Atoi(fdString)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("bad file descriptor %q", spec)
+		}
+		return os.NewFile(uintptr(fd), spec), nil
+	}
+	if write {
+		return os.Create(spec)
+	}
+	return os.Open(spec)
+}
+
+/**
+ * Called every frame from the draw loop,
+ * after the desktop is drawn.
+ */
+func (ho *HeadlessOutput) Frame(desktop *wayland.Desktop) {
+	now := time.Now()
+	for len(ho.SnapshotRequests) > 0 {
+		ho.WritePNG(desktop, <-ho.SnapshotRequests)
+	}
+	if ho.PNGDir != "" && ho.PNGIntervalSeconds > 0 &&
+		now.Sub(ho.TimeOfLastPNG).Seconds() >= ho.PNGIntervalSeconds {
+		ho.TimeOfLastPNG = now
+		ho.WritePNG(desktop, "")
+	}
+	if ho.Video != nil && now.Sub(ho.TimeOfLastVideo).Seconds() >= ho.VideoFrameSeconds {
+		ho.TimeOfLastVideo = now
+		if err := ho.WriteVideoFrame(desktop); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write video frame, stopping video: %v\n", err)
+			ho.Video.Close()
+			ho.Video = nil
+		}
+	}
+}
+
+/**
+ * path "" is the next numbered file in PNGDir
+ */
+func (ho *HeadlessOutput) WritePNG(desktop *wayland.Desktop, path string) {
+	if path == "" {
+		ho.PNGCount++
+		dir := ho.PNGDir
+		if dir == "" {
+			dir = "."
+		}
+		path = filepath.Join(dir, fmt.Sprintf("frame-%06d.png", ho.PNGCount))
+	}
+	if err := WritePNG(BGRAToRGBA(desktop.RGBA), path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+}
+
+func (ho *HeadlessOutput) WriteVideoFrame(desktop *wayland.Desktop) error {
+	if ho.VideoFormat == HeadlessVideoFormat_Rawvideo {
+		_, err := ho.Video.Write(desktop.Buffer)
+		return err
+	}
+	if !ho.VideoHeaderWritten {
+		ho.VideoHeaderWritten = true
+		fps := int(1/ho.VideoFrameSeconds + 0.5)
+		header := fmt.Sprintf("YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C420jpeg XCOLORRANGE=FULL\n",
+			desktop.Width, desktop.Height, fps)
+		if _, err := io.WriteString(ho.Video, header); err != nil {
+			return err
+		}
+	}
+	ho.VideoFrame = BGRAToYUV420(ho.VideoFrame[:0], desktop.Buffer, desktop.Width, desktop.Height)
+	if _, err := io.WriteString(ho.Video, "FRAME\n"); err != nil {
+		return err
+	}
+	_, err := ho.Video.Write(ho.VideoFrame)
+	return err
+}
+
+/**
+ * Full range (jpeg) BT.601, chroma is
+ * averaged over 2x2 blocks.
+ */
+func BGRAToYUV420(out []byte, bgra []byte, width, height int) []byte {
+	chromaWidth := (width + 1) / 2
+	chromaHeight := (height + 1) / 2
+	for y := range height {
+		row := bgra[y*width*4:]
+		for x := range width {
+			b, g, r := float64(row[x*4]), float64(row[x*4+1]), float64(row[x*4+2])
+			out = append(out, clampByte(0.299*r+0.587*g+0.114*b))
+		}
+	}
+	cb := make([]byte, 0, chromaWidth*chromaHeight)
+	cr := make([]byte, 0, chromaWidth*chromaHeight)
+	for cy := range chromaHeight {
+		for cx := range chromaWidth {
+			var r, g, b, n float64
+			for y := cy * 2; y < min(cy*2+2, height); y++ {
+				for x := cx * 2; x < min(cx*2+2, width); x++ {
+					i := (y*width + x) * 4
+					b += float64(bgra[i])
+					g += float64(bgra[i+1])
+					r += float64(bgra[i+2])
+					n++
+				}
+			}
+			r, g, b = r/n, g/n, b/n
+			cb = append(cb, clampByte(128-0.168736*r-0.331264*g+0.5*b))
+			cr = append(cr, clampByte(128+0.5*r-0.418688*g-0.081312*b))
+		}
+	}
+	out = append(out, cb...)
+	return append(out, cr...)
+}
+
+func clampByte(v float64) byte {
+	return byte(min(max(v+0.5, 0), 255))
+}
+
+/**
+ * Reads commands, one per line, from --headless-input:
+ *
+ *	type "text\n"       type text (quotes optional)
+ *	key <code> [shift] [ctrl] [alt]   press and release a linux key code
+ *	move <x> <y>        pointer to desktop pixel x, y
+ *	press|release|click [left|right|middle]
+ *	scroll <amount>     vertical scroll in pixels, negative is up
+ *	focus in|out
+ *	sleep <seconds>
+ *	snapshot [path]
+ *	screenshot [window]
+ *	quit [exit code]
+ *
+ * Empty lines and lines starting with # are skipped.
+ */
+func (tw *TerminalWindow) HeadlessInputLoop() {
+	if tw.Args.HeadlessInput == "" {
+		return
+	}
+	input, err := OpenHeadlessStream(tw.Args.HeadlessInput, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open headless input: %v\n", err)
+		return
+	}
+	defer input.Close()
+
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := tw.RunCommand(line); err != nil {
+			fmt.Fprintf(os.Stderr, "headless input %q: %v\n", line, err)
+		}
+	}
+}
+
+/**
+ * Run one command from --headless-input or the
+ * control socket, returns what to reply.
+ */
+func (tw *TerminalWindow) RunCommand(line string) (string, error) {
+	tw.InputAccess.Lock()
+	defer tw.InputAccess.Unlock()
+	tw.ReceiveNewClients()
+
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	fields := strings.Fields(rest)
+
+	switch command {
+	case "type":
+		text := rest
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			text = unquoted
+		}
+		codes := make([]XkbdCode, 0, len(text))
+		for _, b := range []byte(text) {
+			if code := KeycodeSingleCodes(int(b)); code != nil {
+				codes = append(codes, code)
+			}
+		}
+		tw.ProcessCodes(codes)
+	case "key":
+		if len(fields) == 0 {
+			return "", fmt.Errorf("missing key code")
+		}
+		keyCode, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return "", err
+		}
+		modifiers := 0
+		for _, modifier := range fields[1:] {
+			switch modifier {
+			case "shift":
+				modifiers |= ModShift
+			case "ctrl", "control":
+				modifiers |= ModControl
+			case "alt":
+				modifiers |= ModAlt
+			default:
+				return "", fmt.Errorf("unknown modifier %q", modifier)
+			}
+		}
+		tw.ProcessCodes([]XkbdCode{&KeyCode{KeyCode: Linux_Event_Codes(keyCode), Modifiers: modifiers}})
+	case "move":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("move needs x and y")
+		}
+		x, errX := strconv.ParseFloat(fields[0], 32)
+		y, errY := strconv.ParseFloat(fields[1], 32)
+		if errX != nil || errY != nil {
+			return "", fmt.Errorf("bad position")
+		}
+		unlock := tw.LockConnectedClients()
+		logicalX, logicalY := wayland.LogicalPosition(float32(x), float32(y))
+		wayland.SendPointerMotion(tw.ShownClients(), logicalX, logicalY)
+		unlock()
+	case "press", "release", "click":
+		button := BTN_LEFT
+		if len(fields) > 0 {
+			switch fields[0] {
+			case "left":
+			case "right":
+				button = BTN_RIGHT
+			case "middle":
+				button = BTN_MIDDLE
+			default:
+				return "", fmt.Errorf("unknown button %q", fields[0])
+			}
+		}
+		unlock := tw.LockConnectedClients()
+		clients := tw.ShownClients()
+		if command != "release" {
+			wayland.SendPointerButton(clients, uint32(button), true)
+		}
+		if command != "press" {
+			wayland.SendPointerButton(clients, uint32(button), false)
+		}
+		unlock()
+	case "scroll":
+		if len(fields) != 1 {
+			return "", fmt.Errorf("scroll needs an amount")
+		}
+		amount, err := strconv.ParseFloat(fields[0], 32)
+		if err != nil {
+			return "", err
+		}
+		unlock := tw.LockConnectedClients()
+		wayland.SendPointerAxis(tw.ShownClients(), protocols.WlPointerAxis_enum_vertical_scroll, float32(amount))
+		unlock()
+	case "focus":
+		tw.ProcessCodes([]XkbdCode{&FocusChange{Focused: rest != "out"}})
+	case "sleep":
+		seconds, err := strconv.ParseFloat(rest, 64)
+		if err != nil {
+			return "", err
+		}
+		tw.InputAccess.Unlock()
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		tw.InputAccess.Lock()
+	case "snapshot":
+		if tw.HeadlessOutput == nil {
+			return "", fmt.Errorf("no headless output")
+		}
+		tw.HeadlessOutput.SnapshotRequests <- rest
+	case "screenshot":
+		if tw.ScreenshotRequests == nil {
+			return "", fmt.Errorf("screenshots are not available")
+		}
+		reply := make(chan ScreenshotResult, 1)
+		tw.ScreenshotRequests <- ScreenshotRequest{Window: rest == "window", Reply: reply}
+		/**
+		 * Don't hold up input while
+		 * the draw loop gets to it.
+		 */
+		tw.InputAccess.Unlock()
+		result := <-reply
+		tw.InputAccess.Lock()
+		return result.Path, result.Err
+	case "stats":
+		if tw.StatsRequests == nil {
+			return "", fmt.Errorf("stats are not available")
+		}
+		reply := make(chan string, 1)
+		tw.StatsRequests <- reply
+		tw.InputAccess.Unlock()
+		stats := <-reply
+		tw.InputAccess.Lock()
+		return stats, nil
+	case "quit":
+		exitCode := 0
+		if rest != "" {
+			code, err := strconv.Atoi(rest)
+			if err != nil {
+				return "", err
+			}
+			exitCode = code
+		}
+		GlobalExitChan <- exitCode
+	default:
+		return "", fmt.Errorf("unknown command")
+	}
+	return "", nil
+}
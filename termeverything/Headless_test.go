@@ -0,0 +1,77 @@
+package termeverything
+
+import (
+	"slices"
+	"testing"
+)
+
+/**
+ * Pixels are given as r, g, b
+ */
+func bgraPixels(rgb ...[3]byte) []byte {
+	out := make([]byte, 0, len(rgb)*4)
+	for _, p := range rgb {
+		out = append(out, p[2], p[1], p[0], 255)
+	}
+	return out
+}
+
+func TestBGRAToYUV420(t *testing.T) {
+	black := [3]byte{0, 0, 0}
+	white := [3]byte{255, 255, 255}
+	red := [3]byte{255, 0, 0}
+	green := [3]byte{0, 255, 0}
+	blue := [3]byte{0, 0, 255}
+	tests := []struct {
+		name   string
+		pixels [][3]byte
+		width  int
+		height int
+		want   []byte
+	}{
+		{"empty", nil, 0, 0, []byte{}},
+		{"black", [][3]byte{black, black, black, black}, 2, 2, []byte{0, 0, 0, 0, 128, 128}},
+		{"white", [][3]byte{white, white, white, white}, 2, 2, []byte{255, 255, 255, 255, 128, 128}},
+		{"red", [][3]byte{red}, 1, 1, []byte{76, 85, 255}},
+		{"green", [][3]byte{green}, 1, 1, []byte{150, 44, 21}},
+		{"blue", [][3]byte{blue}, 1, 1, []byte{29, 255, 107}},
+		{
+			name:   "chroma is averaged",
+			pixels: [][3]byte{black, white, black, white},
+			width:  2,
+			height: 2,
+			want:   []byte{0, 255, 0, 255, 128, 128},
+		},
+		{
+			name:   "odd width",
+			pixels: [][3]byte{black, white, red},
+			width:  3,
+			height: 1,
+			want:   []byte{0, 255, 76, 128, 85, 128, 255},
+		},
+		{
+			name:   "odd height",
+			pixels: [][3]byte{blue, blue, blue},
+			width:  1,
+			height: 3,
+			want:   []byte{29, 29, 29, 255, 255, 107, 107},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := BGRAToYUV420([]byte{}, bgraPixels(test.pixels...), test.width, test.height)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBGRAToYUV420ReusesOut(t *testing.T) {
+	bgra := bgraPixels([3]byte{0, 0, 0}, [3]byte{255, 255, 255})
+	out := BGRAToYUV420(nil, bgra, 2, 1)
+	again := BGRAToYUV420(out[:0], bgra, 2, 1)
+	if &again[0] != &out[0] || !slices.Equal(again, []byte{0, 255, 128, 128}) {
+		t.Errorf("got %v, want the same buffer with [0 255 128 128]", again)
+	}
+}
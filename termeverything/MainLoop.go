@@ -11,17 +11,20 @@ import (
 
 func MainLoop() {
 	args := ParseArgs()
+	if args.Screenshot || args.ScreenshotWindow {
+		os.Exit(RunScreenshotCommand(&args))
+	}
 	SetVirtualMonitorSize(args.VirtualMonitorSize)
+	SetOutputScale(args.Scale)
+	SetOutputRotation(args.Rotate)
+	SetVirtualOutputs(args.ExtraOutputs)
 	listener, err := wayland.MakeSocketListener(&args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create socket listener: %v\n", err)
 		os.Exit(1)
 	}
 
-	displaySize := wayland.Size{
-		Width:  uint32(wayland.VirtualMonitorSize.Width),
-		Height: uint32(wayland.VirtualMonitorSize.Height),
-	}
+	displaySize := wayland.VirtualOutputs[0].DesktopSize()
 
 	terminalWindow := MakeTerminalWindow(listener,
 		displaySize,
@@ -36,6 +39,36 @@ func MainLoop() {
 		terminalWindow.FrameEvents,
 		&args,
 	)
+	terminalWindow.ScreenshotRequests = terminanDrawLoop.ScreenshotRequests
+	terminalWindow.StatsRequests = terminanDrawLoop.StatsRequests
+	terminanDrawLoop.Viewport = terminalWindow.Viewport
+	terminanDrawLoop.Tiling = terminalWindow.Tiling
+	terminanDrawLoop.Overview = terminalWindow.Overview
+	if err := terminalWindow.ListenControlSocket(ControlSocketPath(listener.WaylandDisplayName)); err != nil && args.Headless {
+		fmt.Fprintf(os.Stderr, "Failed to create control socket: %v\n", err)
+	}
+
+	if args.Headless {
+		headless, err := MakeHeadlessOutput(&args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start headless output: %v\n", err)
+			os.Exit(1)
+		}
+		terminalWindow.HeadlessOutput = headless
+		terminanDrawLoop.Headless = headless
+		terminanDrawLoop.StatusLine.ShowStatusLine = false
+	}
+
+	if args.Record != "" && !args.Headless {
+		recorder, err := MakeRecorder(args.Record, args.RecordInput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start recording: %v\n", err)
+			os.Exit(1)
+		}
+		terminalWindow.Recorder = recorder
+		terminanDrawLoop.Recorder = recorder
+		terminanDrawLoop.DrawState.Tee = recorder
+	}
 
 	go listener.MainLoopThenClose()
 	go terminalWindow.InputLoop()
@@ -67,9 +100,17 @@ func MainLoop() {
 			if !args.SupportOldApps && strings.HasPrefix(e, "XDG_SESSION_TYPE=") {
 				continue
 			}
+			/**
+			 * Tokens from the outer desktop mean
+			 * nothing to us, we hand out our own below.
+			 */
+			if strings.HasPrefix(e, "XDG_ACTIVATION_TOKEN=") || strings.HasPrefix(e, "DESKTOP_STARTUP_ID=") {
+				continue
+			}
 			filtered = append(filtered, e)
 		}
 		filtered = append(filtered, fmt.Sprintf("WAYLAND_DISPLAY=%s", listener.WaylandDisplayName))
+		filtered = append(filtered, fmt.Sprintf("XDG_ACTIVATION_TOKEN=%s", wayland.NewActivationToken("")))
 		if !args.SupportOldApps {
 			filtered = append(filtered, "XDG_SESSION_TYPE=wayland")
 		}
@@ -0,0 +1,107 @@
+package termeverything
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmulet/term.everything/escapecodes"
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type NotificationMode int
+
+const (
+	/**
+	 * OSC 9, supported by iTerm2, kitty,
+	 * WezTerm, ghostty, Windows Terminal...
+	 */
+	NotificationMode_Osc9 NotificationMode = iota
+	/**
+	 * OSC 777 notify, supported by urxvt,
+	 * foot, ghostty, WezTerm, VTE...
+	 */
+	NotificationMode_Osc777
+	/**
+	 * Only ring the bell
+	 */
+	NotificationMode_Bell
+	NotificationMode_None
+)
+
+func ParseNotificationMode(mode string) (NotificationMode, error) {
+	switch mode {
+	case "osc9":
+		return NotificationMode_Osc9, nil
+	case "osc777":
+		return NotificationMode_Osc777, nil
+	case "bell":
+		return NotificationMode_Bell, nil
+	case "none":
+		return NotificationMode_None, nil
+	}
+	return NotificationMode_Osc9, fmt.Errorf("expected osc9, osc777, bell or none")
+}
+
+/**
+ * Apps ask for attention with xdg_activation_v1.activate.
+ * We never move focus around, but if the app isn't the
+ * one the user is looking at, tell the terminal so it
+ * can show a desktop notification (and ring the bell).
+ */
+func (tw *TerminalDrawLoop) HandleActivationRequests() {
+	for _, s := range tw.Clients {
+		for {
+			select {
+			case toplevelID := <-s.ActivationRequests:
+				tw.HandleActivationRequest(s, toplevelID)
+			default:
+				goto DoneActivationRequests
+			}
+		}
+	DoneActivationRequests:
+	}
+}
+
+func (tw *TerminalDrawLoop) HandleActivationRequest(s *wayland.Client, toplevelID protocols.ObjectID[protocols.XdgToplevel]) {
+	toplevel := wayland.GetXdgToplevelObject(s, toplevelID)
+	if toplevel == nil {
+		return
+	}
+	if tw.TerminalFocused && toplevel == tw.GetFocusedToplevel() {
+		return
+	}
+	if protocols.DebugRequests {
+		return
+	}
+	title := toplevel.AppID
+	if toplevel.Title != nil && *toplevel.Title != "" {
+		title = *toplevel.Title
+	}
+	if title == "" {
+		title = "term.everything"
+	}
+	os.Stdout.WriteString(NotificationEscapeCode(tw.NotificationMode, sanitizeTitle(title), "needs your attention"))
+}
+
+/**
+ * The first BEL ends the OSC sequence,
+ * the second one rings the bell.
+ */
+func NotificationEscapeCode(mode NotificationMode, title string, body string) string {
+	switch mode {
+	case NotificationMode_Osc9:
+		return escapecodes.NotifyStart + title + ": " + body + escapecodes.Bell + escapecodes.Bell
+	case NotificationMode_Osc777:
+		/**
+		 * ; separates the title from the body
+		 */
+		title = strings.ReplaceAll(title, ";", ",")
+		return escapecodes.NotifyURxvtStart + title + ";" + body + escapecodes.Bell + escapecodes.Bell
+	case NotificationMode_Bell:
+		return escapecodes.Bell
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,25 @@
+package termeverything
+
+import "testing"
+
+func TestParseNotificationMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    NotificationMode
+		wantErr bool
+	}{
+		{"osc9", NotificationMode_Osc9, false},
+		{"osc777", NotificationMode_Osc777, false},
+		{"bell", NotificationMode_Bell, false},
+		{"none", NotificationMode_None, false},
+		{"", NotificationMode_Osc9, true},
+		{"OSC9", NotificationMode_Osc9, true},
+		{"popup", NotificationMode_Osc9, true},
+	}
+	for _, test := range tests {
+		got, err := ParseNotificationMode(test.mode)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParseNotificationMode(%q) = %v, %v", test.mode, got, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package termeverything
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+/**
+ * With --extra-outputs:
+ * Alt+Shift+O shows the next output,
+ * Alt+Shift+M moves the focused window to the next
+ * output (and shows it there).
+ * Returns true if the code should not be
+ * sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleOutputCode(code XkbdCode) bool {
+	c, ok := code.(*KeyCode)
+	if !ok || len(wayland.VirtualOutputs) < 2 ||
+		c.Modifiers&(ModAlt|ModShift) != ModAlt|ModShift || c.Modifiers&ModControl != 0 {
+		return false
+	}
+	current := wayland.CurrentVirtualOutput()
+	next := (current + 1) % len(wayland.VirtualOutputs)
+	switch c.KeyCode {
+	case KEY_O:
+		wayland.SetCurrentVirtualOutput(next)
+	case KEY_M:
+		if s, toplevelID, ok := tw.FocusedToplevel(); ok {
+			wayland.MoveToplevelToOutput(s, toplevelID, next)
+		}
+		wayland.SetCurrentVirtualOutput(next)
+	default:
+		return false
+	}
+	return true
+}
+
+/**
+ * Switch the desktop to another output,
+ * it can be a different size.
+ */
+func (tw *TerminalDrawLoop) ShowOutput(index int) {
+	output := wayland.GetVirtualOutput(index)
+	size := output.DesktopSize()
+	tw.Desktop = wayland.MakeDesktop(size, false, iconPNG)
+	tw.Desktop.Output = output.Index
+	tw.VirtualMonitorSize = size
+	tw.Viewport.SetDesktopSize(size)
+	tw.PendingDamage = image.Rect(0, 0, int(size.Width), int(size.Height))
+	tw.DrawState.PreviousCells = nil
+	tw.DrawState.CanvasPrinted = false
+}
+
+/**
+ * For the status line, empty with only one output
+ */
+func (tw *TerminalDrawLoop) OutputStatus() string {
+	if len(wayland.VirtualOutputs) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("output %d/%d", tw.Desktop.Output+1, len(wayland.VirtualOutputs))
+}
@@ -0,0 +1,509 @@
+package termeverything
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmulet/term.everything/escapecodes"
+	"github.com/mmulet/term.everything/framebuffertoansi"
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Where a thumbnail is on the terminal, so the
+ * input loop knows what was clicked.
+ */
+type OverviewThumbnailLayout struct {
+	Client     *wayland.Client
+	ToplevelID protocols.ObjectID[protocols.XdgToplevel]
+	/**
+	 * The whole grid cell, with the title
+	 */
+	Region framebuffertoansi.CellRegion
+}
+
+/**
+ * Shared between TerminalWindow (hotkeys and the mouse)
+ * and TerminalDrawLoop (layout and drawing).
+ */
+type Overview struct {
+	Access sync.Mutex
+
+	Active bool
+	/**
+	 * Opened, closed or the selection moved since
+	 * the draw loop last called TakeChanged.
+	 */
+	Changed bool
+
+	/**
+	 * Written by the draw loop every frame
+	 */
+	Thumbnails []OverviewThumbnailLayout
+	Columns    int
+	/**
+	 * Index into Thumbnails, PointerThumbnail
+	 * is -1 when the pointer is not on one.
+	 */
+	Selected         int
+	PointerThumbnail int
+}
+
+func MakeOverview() *Overview {
+	return &Overview{
+		PointerThumbnail: -1,
+	}
+}
+
+func (o *Overview) IsActive() bool {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	return o.Active
+}
+
+func (o *Overview) SetActive(active bool) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	o.Active = active
+	o.Selected = 0
+	o.PointerThumbnail = -1
+	o.Changed = true
+}
+
+func (o *Overview) TakeChanged() (active bool, changed bool) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	changed = o.Changed
+	o.Changed = false
+	return o.Active, changed
+}
+
+func (o *Overview) SetThumbnails(thumbnails []OverviewThumbnailLayout, columns int) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	o.Thumbnails = thumbnails
+	o.Columns = columns
+	if o.Selected >= len(thumbnails) {
+		o.Selected = max(len(thumbnails)-1, 0)
+	}
+}
+
+func (o *Overview) GetSelected() int {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	return o.Selected
+}
+
+func (o *Overview) GetColumns() int {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	return max(o.Columns, 1)
+}
+
+/**
+ * Move the selection by steps, clamped
+ * to the first and last thumbnail.
+ */
+func (o *Overview) MoveSelection(steps int) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	selected := min(max(o.Selected+steps, 0), max(len(o.Thumbnails)-1, 0))
+	if selected != o.Selected {
+		o.Selected = selected
+		o.Changed = true
+	}
+}
+
+/**
+ * Select the thumbnail at row and col (1 based),
+ * if there is one.
+ */
+func (o *Overview) PointerMove(row, col int) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	o.PointerThumbnail = -1
+	for i, thumbnail := range o.Thumbnails {
+		if !thumbnail.Region.Contains(row, col) {
+			continue
+		}
+		o.PointerThumbnail = i
+		if i != o.Selected {
+			o.Selected = i
+			o.Changed = true
+		}
+		return
+	}
+}
+
+func (o *Overview) GetPointerThumbnail() int {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	return o.PointerThumbnail
+}
+
+/**
+ * The thumbnail at index, ok is false
+ * if there is none.
+ */
+func (o *Overview) Thumbnail(index int) (thumbnail OverviewThumbnailLayout, ok bool) {
+	o.Access.Lock()
+	defer o.Access.Unlock()
+	if index < 0 || index >= len(o.Thumbnails) {
+		return OverviewThumbnailLayout{}, false
+	}
+	return o.Thumbnails[index], true
+}
+
+/**
+ * For the status line
+ */
+func (o *Overview) Describe() string {
+	if !o.IsActive() {
+		return ""
+	}
+	return "overview"
+}
+
+/**
+ * Alt+Shift+E opens (or closes) the overview. While it is
+ * open, the arrow keys and the mouse select a window,
+ * Enter or a click focuses it, Escape goes back.
+ * Nothing is sent on to the clients while it is open.
+ * Returns true if the code should not be
+ * sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleOverviewCode(code XkbdCode) bool {
+	o := tw.Overview
+	if c, ok := code.(*KeyCode); ok && c.KeyCode == KEY_E &&
+		c.Modifiers&(ModAlt|ModShift) == ModAlt|ModShift && c.Modifiers&ModControl == 0 {
+		o.SetActive(!o.IsActive())
+		return true
+	}
+	if !o.IsActive() {
+		return false
+	}
+	switch c := code.(type) {
+	case *KeyCode:
+		columns := o.GetColumns()
+		switch c.KeyCode {
+		case KEY_LEFT:
+			o.MoveSelection(-1)
+		case KEY_RIGHT, KEY_TAB:
+			o.MoveSelection(1)
+		case KEY_UP:
+			o.MoveSelection(-columns)
+		case KEY_DOWN:
+			o.MoveSelection(columns)
+		case KEY_ENTER:
+			tw.ChooseOverviewThumbnail(o.GetSelected())
+		case KEY_ESC:
+			o.SetActive(false)
+		}
+		return true
+	case *PointerMove:
+		// Regions are 1 based
+		o.PointerMove(c.Row+1, c.Col+1)
+		return true
+	case *PointerButtonPress:
+		if i := o.GetPointerThumbnail(); i >= 0 {
+			tw.ChooseOverviewThumbnail(i)
+		}
+		return true
+	case *PointerButtonRelease, *PointerWheel:
+		return true
+	}
+	return false
+}
+
+/**
+ * Show the window's output and workspace, raise
+ * it above the others and close the overview.
+ * Must hold the clients' locks (see LockConnectedClients).
+ */
+func (tw *TerminalWindow) ChooseOverviewThumbnail(index int) {
+	thumbnail, ok := tw.Overview.Thumbnail(index)
+	if !ok {
+		return
+	}
+	if toplevel := wayland.GetXdgToplevelObject(thumbnail.Client, thumbnail.ToplevelID); toplevel != nil {
+		wayland.SetCurrentVirtualOutput(toplevel.Output)
+		wayland.SetCurrentWorkspace(toplevel.Workspace)
+		wayland.RaiseToplevel(thumbnail.Client, thumbnail.ToplevelID)
+	}
+	tw.Overview.SetActive(false)
+}
+
+/**
+ * Draw loop side of a thumbnail, one per toplevel
+ */
+type OverviewThumbnail struct {
+	Client     *wayland.Client
+	ToplevelID protocols.ObjectID[protocols.XdgToplevel]
+	Toplevel   *wayland.XdgToplevel
+	Surface    *wayland.WlSurface
+	/**
+	 * Only this window is drawn into it,
+	 * at the size of its output.
+	 */
+	Desktop   *wayland.Desktop
+	DrawState *framebuffertoansi.DrawState
+}
+
+/**
+ * Every toplevel on every workspace and output, sorted
+ * so they don't change places from frame to frame.
+ */
+func (tw *TerminalDrawLoop) OverviewThumbnailList() []*OverviewThumbnail {
+	thumbnails := make([]*OverviewThumbnail, 0)
+	for _, s := range tw.Clients {
+		for _, topLevelID := range slices.Sorted(maps.Keys(s.TopLevelSurfaces())) {
+			toplevel := wayland.GetXdgToplevelObject(s, topLevelID)
+			if toplevel == nil {
+				continue
+			}
+			surfaceID := s.GetSurfaceIDFromRole(protocols.AnyObjectID(topLevelID))
+			if surfaceID == nil {
+				continue
+			}
+			surface := wayland.GetWlSurfaceObject(s, *surfaceID)
+			if surface == nil || surface.Texture == nil {
+				continue
+			}
+			thumbnail := tw.OverviewThumbnails[surface]
+			if thumbnail == nil {
+				thumbnail = &OverviewThumbnail{
+					Client:     s,
+					ToplevelID: topLevelID,
+					Surface:    surface,
+					DrawState:  framebuffertoansi.MakeDrawState(),
+				}
+				thumbnail.DrawState.RendererName = tw.DrawState.RendererName
+				thumbnail.DrawState.FullRefreshIntervalSeconds = tw.DrawState.FullRefreshIntervalSeconds
+			}
+			thumbnail.Toplevel = toplevel
+			thumbnails = append(thumbnails, thumbnail)
+		}
+	}
+	return thumbnails
+}
+
+/**
+ * The grid with the biggest thumbnails, each
+ * cell has one row under the image for the title.
+ * nil when no grid fits the terminal.
+ */
+func OverviewRegions(count int, imageWidth, imageHeight int, termSize framebuffertoansi.TermSize, statusLineHeight int) ([]framebuffertoansi.CellRegion, int) {
+	areaWidth := termSize.WidthCells
+	areaHeight := termSize.HeightCells - statusLineHeight
+	bestColumns, bestArea := 1, -1
+	for columns := 1; columns <= count; columns++ {
+		rows := (count + columns - 1) / columns
+		cellWidth := (areaWidth - (columns - 1)) / columns
+		cellHeight := (areaHeight-(rows-1))/rows - 1
+		if cellWidth <= 0 || cellHeight <= 0 {
+			continue
+		}
+		w, h := framebuffertoansi.CalcCanvasGeometry(imageWidth, imageHeight, cellWidth, cellHeight, termSize.FontRatio)
+		if w*h > bestArea {
+			bestColumns, bestArea = columns, w*h
+		}
+	}
+	if bestArea < 0 {
+		return nil, 0
+	}
+	rows := (count + bestColumns - 1) / bestColumns
+	cellWidth := (areaWidth - (bestColumns - 1)) / bestColumns
+	cellHeight := (areaHeight - (rows - 1)) / rows
+	regions := make([]framebuffertoansi.CellRegion, count)
+	for i := range count {
+		regions[i] = framebuffertoansi.CellRegion{
+			Row:         1 + statusLineHeight + (i/bestColumns)*(cellHeight+1),
+			Col:         1 + (i%bestColumns)*(cellWidth+1),
+			WidthCells:  cellWidth,
+			HeightCells: cellHeight,
+		}
+	}
+	return regions, bestColumns
+}
+
+/**
+ * Centered under the image, the selected
+ * one in reverse video.
+ */
+func writeOverviewTitle(sb *strings.Builder, title string, row int, region framebuffertoansi.CellRegion, selected bool) {
+	runes := []rune(sanitizeTitle(title))
+	if len(runes) > region.WidthCells {
+		runes = runes[:region.WidthCells]
+	}
+	padding := (region.WidthCells - len(runes)) / 2
+	sb.WriteString(moveCursorTo(row, region.Col))
+	sb.WriteString(strings.Repeat(" ", region.WidthCells))
+	sb.WriteString(moveCursorTo(row, region.Col+padding))
+	if selected {
+		sb.WriteString(escapecodes.Inverse)
+	}
+	sb.WriteString(string(runes))
+	if selected {
+		sb.WriteString(escapecodes.Reset)
+	}
+}
+
+func overviewTitle(toplevel *wayland.XdgToplevel, desktop *wayland.Desktop) string {
+	title := toplevel.AppID
+	if toplevel.Title != nil && *toplevel.Title != "" {
+		title = *toplevel.Title
+	}
+	if title == "" {
+		title = "untitled"
+	}
+	if len(wayland.VirtualOutputs) > 1 && toplevel.Output != desktop.Output {
+		title = fmt.Sprintf("%s (output %d)", title, toplevel.Output+1)
+	}
+	if toplevel.Workspace != desktop.Workspace {
+		title = fmt.Sprintf("%s (workspace %d)", title, toplevel.Workspace+1)
+	}
+	return title
+}
+
+func (tw *TerminalDrawLoop) HasToplevels() bool {
+	for _, s := range tw.Clients {
+		for topLevelID := range s.TopLevelSurfaces() {
+			if wayland.GetXdgToplevelObject(s, topLevelID) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/**
+ * Leaving the overview: the thumbnails took the windows'
+ * damage, so redraw the desktop (and any tiling
+ * panes) from scratch.
+ */
+func (tw *TerminalDrawLoop) StopOverview() {
+	for _, thumbnail := range tw.OverviewThumbnails {
+		thumbnail.DrawState.Destroy()
+	}
+	tw.OverviewThumbnails = nil
+	tw.Overview.SetThumbnails(nil, 0)
+	tw.Desktop.LastSurfaceRects = nil
+	for _, pane := range tw.TilingPanes {
+		pane.Desktop = nil
+		pane.DrawState.PreviousCells = nil
+		pane.DrawState.CanvasPrinted = false
+	}
+	tw.DrawState.PreviousCells = nil
+	tw.DrawState.CanvasPrinted = false
+	tw.TilingFullDraw = true
+	os.Stdout.WriteString(escapecodes.ClearScreen)
+}
+
+/**
+ * Every window as a thumbnail, each drawn by its own
+ * DrawState, with its title underneath. Returns false
+ * when there are no windows, or no room for them.
+ */
+func (tw *TerminalDrawLoop) DrawOverviewToTerminal(status_line string) bool {
+	thumbnails := tw.OverviewThumbnailList()
+	if len(thumbnails) == 0 {
+		return false
+	}
+
+	haveStatusLine := !tw.HideStatusBar && status_line != ""
+	statusLineHeight := 0
+	if haveStatusLine {
+		statusLineHeight = 1
+	}
+	termSize := framebuffertoansi.MakeTermSize()
+	if termSize.WidthCells <= 0 || termSize.HeightCells <= statusLineHeight+1 {
+		return false
+	}
+	if winsize, err := framebuffertoansi.GetWinsize(os.Stdout.Fd()); err == nil {
+		tw.Recorder.UpdateSize(int(winsize.Col), int(winsize.Row))
+	}
+	full := termSize != tw.LastOverviewTermSize || haveStatusLine != tw.LastOverviewHadStatusLine ||
+		len(thumbnails) != len(tw.OverviewThumbnails)
+	monitor := tw.VirtualMonitorSize
+	regions, columns := OverviewRegions(len(thumbnails), int(monitor.Width), int(monitor.Height), termSize, statusLineHeight)
+	if regions == nil {
+		return false
+	}
+
+	var sb strings.Builder
+	if full {
+		sb.WriteString(escapecodes.ClearScreen)
+	}
+	if haveStatusLine {
+		sb.WriteString(escapecodes.MoveCursorToHome)
+		sb.WriteString(status_line)
+		sb.WriteString(escapecodes.ClearLineAfterCursor)
+	}
+
+	selected := tw.Overview.GetSelected()
+	nextThumbnails := make(map[*wayland.WlSurface]*OverviewThumbnail, len(thumbnails))
+	layout := make([]OverviewThumbnailLayout, len(thumbnails))
+	for i, thumbnail := range thumbnails {
+		region := regions[i]
+		imageRegion := region
+		imageRegion.HeightCells--
+
+		output := wayland.GetVirtualOutput(thumbnail.Toplevel.Output)
+		size := output.DesktopSize()
+		if configured := thumbnail.Toplevel.ConfiguredSize; configured != nil {
+			size = wayland.DesktopSizeOf(*configured)
+		}
+		if thumbnail.Desktop == nil || thumbnail.Desktop.Width != int(size.Width) || thumbnail.Desktop.Height != int(size.Height) {
+			thumbnail.Desktop = wayland.MakeDesktop(size, false, nil)
+			thumbnail.Desktop.OnlyToplevel = thumbnail.Surface
+			thumbnail.Desktop.OnlyToplevelClient = thumbnail.Client
+			thumbnail.Desktop.HideCursorSurfaces = true
+		}
+		thumbnail.Desktop.Output = thumbnail.Toplevel.Output
+		thumbnail.Desktop.Workspace = thumbnail.Toplevel.Workspace
+		thumbnail.Desktop.DrawClients([]*wayland.Client{thumbnail.Client})
+
+		tw.Quality.Apply(thumbnail.DrawState)
+		placed := thumbnail.DrawState.DrawRegion(&sb,
+			thumbnail.Desktop.Buffer,
+			size.Width,
+			size.Height,
+			imageRegion,
+			termSize,
+			thumbnail.Desktop.DamageBounds(),
+			full,
+		)
+		writeOverviewTitle(&sb, overviewTitle(thumbnail.Toplevel, tw.Desktop), placed.Row+placed.HeightCells, region, i == selected)
+		layout[i] = OverviewThumbnailLayout{
+			Client:     thumbnail.Client,
+			ToplevelID: thumbnail.ToplevelID,
+			Region:     region,
+		}
+		nextThumbnails[thumbnail.Surface] = thumbnail
+	}
+	for surface, thumbnail := range tw.OverviewThumbnails {
+		if _, ok := nextThumbnails[surface]; !ok {
+			thumbnail.DrawState.Destroy()
+		}
+	}
+	tw.OverviewThumbnails = nextThumbnails
+	tw.Overview.SetThumbnails(layout, columns)
+	tw.LastOverviewTermSize = termSize
+	tw.LastOverviewHadStatusLine = haveStatusLine
+
+	output := sb.String()
+	startOfWrite := time.Now()
+	os.Stdout.WriteString(output)
+	_ = os.Stdout.Sync()
+	tw.Quality.Update(len(output), time.Since(startOfWrite))
+	if tw.DrawState.Tee != nil {
+		tw.DrawState.Tee.Write([]byte(output))
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package termeverything
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mmulet/term.everything/framebuffertoansi"
+)
+
+func TestOverviewRegions(t *testing.T) {
+	tests := []struct {
+		name             string
+		count            int
+		imageWidth       int
+		imageHeight      int
+		width, height    int
+		statusLineHeight int
+		want             []framebuffertoansi.CellRegion
+		wantColumns      int
+	}{
+		{
+			name:  "one window under the status line",
+			count: 1, imageWidth: 1920, imageHeight: 1080,
+			width: 80, height: 24, statusLineHeight: 1,
+			want:        []framebuffertoansi.CellRegion{region(2, 1, 80, 23)},
+			wantColumns: 1,
+		},
+		{
+			name:  "wide terminal puts them side by side",
+			count: 2, imageWidth: 1920, imageHeight: 1080,
+			width: 80, height: 24,
+			want:        []framebuffertoansi.CellRegion{region(1, 1, 39, 24), region(1, 41, 39, 24)},
+			wantColumns: 2,
+		},
+		{
+			name:  "tall terminal stacks them",
+			count: 2, imageWidth: 1920, imageHeight: 1080,
+			width: 40, height: 60,
+			want:        []framebuffertoansi.CellRegion{region(1, 1, 40, 29), region(31, 1, 40, 29)},
+			wantColumns: 1,
+		},
+		{
+			name:  "no room for the titles",
+			count: 2, imageWidth: 1920, imageHeight: 1080,
+			width: 1, height: 4,
+			want:        nil,
+			wantColumns: 0,
+		},
+		{
+			name:  "too many windows",
+			count: 5, imageWidth: 1920, imageHeight: 1080,
+			width: 5, height: 3,
+			want:        nil,
+			wantColumns: 0,
+		},
+		{
+			name:  "no windows",
+			count: 0, imageWidth: 1920, imageHeight: 1080,
+			width: 80, height: 24,
+			want:        nil,
+			wantColumns: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			termSize := framebuffertoansi.TermSize{WidthCells: test.width, HeightCells: test.height, FontRatio: 0.5}
+			got, columns := OverviewRegions(test.count, test.imageWidth, test.imageHeight, termSize, test.statusLineHeight)
+			if !slices.Equal(got, test.want) || columns != test.wantColumns {
+				t.Errorf("got %v, %d columns, want %v, %d columns", got, columns, test.want, test.wantColumns)
+			}
+		})
+	}
+}
+
+func TestOverviewRegionsFitTheTerminal(t *testing.T) {
+	for width := 1; width <= 30; width++ {
+		for height := 2; height <= 20; height++ {
+			for count := 1; count <= 10; count++ {
+				termSize := framebuffertoansi.TermSize{WidthCells: width, HeightCells: height, FontRatio: 0.5}
+				regions, columns := OverviewRegions(count, 1920, 1080, termSize, 1)
+				if regions == nil {
+					continue
+				}
+				area := region(2, 1, width, height-1)
+				for i, r := range regions {
+					/**
+					 * At least one row for the image
+					 * and one for the title
+					 */
+					if r.WidthCells < 1 || r.HeightCells < 2 ||
+						!area.Contains(r.Row, r.Col) ||
+						!area.Contains(r.Row+r.HeightCells-1, r.Col+r.WidthCells-1) {
+						t.Fatalf("%dx%d, %d windows: %v is outside %v", width, height, count, r, area)
+					}
+					if i > 0 && i%columns != 0 && r.Col <= regions[i-1].Col+regions[i-1].WidthCells {
+						t.Fatalf("%dx%d, %d windows: %v overlaps %v", width, height, count, r, regions[i-1])
+					}
+				}
+			}
+		}
+	}
+}
@@ -17,17 +17,47 @@ var licensesFile string
 const version = "0.7.6"
 
 type CommandLineArgs struct {
-	WaylandDisplayNameArg string
-	SupportOldApps        bool
-	Xwayland              string
-	XwaylandWM            string
-	Shell                 string
-	HideStatusBar         bool
-	VirtualMonitorSize    string
-	DebugLog              bool
-	ReverseScroll         bool
-	MaxFrameRate          string
-	Positionals           []string
+	WaylandDisplayNameArg  string
+	SupportOldApps         bool
+	Xwayland               string
+	XwaylandWM             string
+	Shell                  string
+	HideStatusBar          bool
+	VirtualMonitorSize     string
+	DebugLog               bool
+	ReverseScroll          bool
+	MaxFrameRate           string
+	UnfocusedFrameRate     string
+	TitleTemplate          string
+	PasteMode              string
+	PasteTypeRate          string
+	TerminalPointer        bool
+	FitWindow              bool
+	ZoomKeys               string
+	WorkspaceKeys          string
+	Tiling                 string
+	ExtraOutputs           string
+	Scale                  string
+	Rotate                 string
+	Notifications          string
+	FullRefreshInterval    string
+	KittyTransmission      string
+	Renderer               string
+	ProbeTimeout           string
+	LatencyTarget          string
+	Headless               bool
+	HeadlessPNGDir         string
+	HeadlessPNGInterval    string
+	HeadlessVideo          string
+	HeadlessVideoFormat    string
+	HeadlessVideoFrameRate string
+	HeadlessInput          string
+	Record                 string
+	RecordInput            bool
+	ScreenshotDir          string
+	Screenshot             bool
+	ScreenshotWindow       bool
+	Positionals            []string
 }
 
 func (args *CommandLineArgs) WaylandDisplayName() string {
@@ -51,6 +81,36 @@ func ParseArgs() CommandLineArgs {
 	licensesFlag := flag.Bool("licenses", false, "")
 	flag.BoolVar(&args.ReverseScroll, "reverse-scroll", false, "")
 	flag.StringVar(&args.MaxFrameRate, "max-frame-rate", "", "")
+	flag.StringVar(&args.UnfocusedFrameRate, "unfocused-frame-rate", "1", "")
+	flag.StringVar(&args.TitleTemplate, "title-template", defaultTitleTemplate, "")
+	flag.StringVar(&args.PasteMode, "paste-mode", "clipboard", "")
+	flag.StringVar(&args.PasteTypeRate, "paste-type-rate", "", "")
+	flag.BoolVar(&args.TerminalPointer, "terminal-pointer", false, "")
+	flag.BoolVar(&args.FitWindow, "fit-window", false, "")
+	flag.StringVar(&args.ZoomKeys, "zoom-keys", "alt", "")
+	flag.StringVar(&args.WorkspaceKeys, "workspace-keys", "alt", "")
+	flag.StringVar(&args.Tiling, "tiling", "off", "")
+	flag.StringVar(&args.ExtraOutputs, "extra-outputs", "", "")
+	flag.StringVar(&args.Scale, "scale", "", "")
+	flag.StringVar(&args.Rotate, "rotate", "0", "")
+	flag.StringVar(&args.Notifications, "notifications", "osc9", "")
+	flag.StringVar(&args.FullRefreshInterval, "full-refresh-interval", "", "")
+	flag.StringVar(&args.KittyTransmission, "kitty-transmission", "auto", "")
+	flag.StringVar(&args.Renderer, "renderer", "auto", "")
+	flag.StringVar(&args.ProbeTimeout, "probe-timeout", "", "")
+	flag.StringVar(&args.LatencyTarget, "latency-target", "", "")
+	flag.BoolVar(&args.Headless, "headless", false, "")
+	flag.StringVar(&args.HeadlessPNGDir, "headless-png-dir", "", "")
+	flag.StringVar(&args.HeadlessPNGInterval, "headless-png-interval", "", "")
+	flag.StringVar(&args.HeadlessVideo, "headless-video", "", "")
+	flag.StringVar(&args.HeadlessVideoFormat, "headless-video-format", "y4m", "")
+	flag.StringVar(&args.HeadlessVideoFrameRate, "headless-video-frame-rate", "", "")
+	flag.StringVar(&args.HeadlessInput, "headless-input", "", "")
+	flag.StringVar(&args.Record, "record", "", "")
+	flag.BoolVar(&args.RecordInput, "record-input", false, "")
+	flag.StringVar(&args.ScreenshotDir, "screenshot-dir", "", "")
+	flag.BoolVar(&args.Screenshot, "screenshot", false, "")
+	flag.BoolVar(&args.ScreenshotWindow, "screenshot-window", false, "")
 
 	flag.Parse()
 
@@ -67,6 +127,23 @@ func ParseArgs() CommandLineArgs {
 		os.Exit(0)
 	}
 
+	if _, err := ParsePasteMode(args.PasteMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --paste-mode %s, %v\n", args.PasteMode, err)
+		os.Exit(1)
+	}
+	if _, err := ParseHotkeys(args.ZoomKeys); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --zoom-keys %s, %v\n", args.ZoomKeys, err)
+		os.Exit(1)
+	}
+	if _, err := ParseHotkeys(args.WorkspaceKeys); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --workspace-keys %s, %v\n", args.WorkspaceKeys, err)
+		os.Exit(1)
+	}
+	if _, err := ParseNotificationMode(args.Notifications); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --notifications %s, %v\n", args.Notifications, err)
+		os.Exit(1)
+	}
+
 	args.Positionals = flag.Args()
 	return args
 }
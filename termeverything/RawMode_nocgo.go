@@ -0,0 +1,71 @@
+//go:build !cgo
+
+package termeverything
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+func getTermios(fd int, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TCGETS), uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func setTermiosNow(fd int, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TCSETS), uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+/**
+ * Same as make_raw in RawMode.go: cfmakeraw,
+ * but keep output post-processing (NL -> CRNL)
+ * so stdout isn't garbled.
+ */
+func makeRaw(t *syscall.Termios) {
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.Cflag &^= syscall.CSIZE | syscall.PARENB
+	t.Cflag |= syscall.CS8
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+
+	t.Oflag |= syscall.OPOST | syscall.ONLCR
+}
+
+func EnableRawModeFD(fd int) (func() error, error) {
+	var orig syscall.Termios
+	if err := getTermios(fd, &orig); err != nil {
+		// Not a tty
+		return func() error { return nil }, nil
+	}
+
+	raw := orig
+	makeRaw(&raw)
+
+	if err := setTermiosNow(fd, &raw); err != nil {
+		return nil, fmt.Errorf("tcsetattr (raw) failed")
+	}
+
+	restored := false
+	restore := func() error {
+		if restored {
+			return nil
+		}
+		restored = true
+		if err := setTermiosNow(fd, &orig); err != nil {
+			return fmt.Errorf("tcsetattr (restore) failed")
+		}
+		return nil
+	}
+
+	return restore, nil
+}
@@ -0,0 +1,162 @@
+package termeverything
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mmulet/term.everything/escapecodes"
+	"github.com/mmulet/term.everything/framebuffertoansi"
+)
+
+/**
+ * Writes what we draw to the terminal (and optionally
+ * what the user types) as an asciicast v2 file, so
+ * it can be replayed with asciinema.
+ * Used from both the draw loop and the input loop.
+ */
+type Recorder struct {
+	Access sync.Mutex
+
+	File      *os.File
+	StartTime time.Time
+
+	/**
+	 * Also record input as "i" events
+	 */
+	RecordInput bool
+
+	Cols int
+	Rows int
+}
+
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env"`
+}
+
+func MakeRecorder(path string, recordInput bool) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		File:        file,
+		StartTime:   time.Now(),
+		RecordInput: recordInput,
+		Cols:        80,
+		Rows:        24,
+	}
+	if ws, err := framebuffertoansi.GetWinsize(os.Stdout.Fd()); err == nil && ws.Col > 0 && ws.Row > 0 {
+		r.Cols = int(ws.Col)
+		r.Rows = int(ws.Row)
+	}
+
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     r.Cols,
+		Height:    r.Rows,
+		Timestamp: r.StartTime.Unix(),
+		Env: map[string]string{
+			"TERM":  os.Getenv("TERM"),
+			"SHELL": os.Getenv("SHELL"),
+		},
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(header, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	/**
+	 * Frames don't clear the screen or hide
+	 * the cursor, the alternative screen did
+	 * that in the real terminal.
+	 */
+	r.event("o", escapecodes.HideCursor+escapecodes.ClearScreen)
+	return r, nil
+}
+
+/**
+ * One line: [seconds, type, data]
+ */
+func (r *Recorder) event(eventType string, data string) {
+	var line bytes.Buffer
+	fmt.Fprintf(&line, "[%.6f, %q, ", time.Since(r.StartTime).Seconds(), eventType)
+	encoder := json.NewEncoder(&line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return
+	}
+	// Encode ends with a newline
+	line.Truncate(line.Len() - 1)
+	line.WriteString("]\n")
+	r.File.Write(line.Bytes())
+}
+
+/**
+ * Output, so it can be used as DrawState.Tee
+ */
+func (r *Recorder) Write(data []byte) (int, error) {
+	if r == nil {
+		return len(data), nil
+	}
+	r.Access.Lock()
+	defer r.Access.Unlock()
+	if r.File == nil {
+		return len(data), nil
+	}
+	r.event("o", string(data))
+	return len(data), nil
+}
+
+func (r *Recorder) Input(data []byte) {
+	if r == nil || !r.RecordInput {
+		return
+	}
+	r.Access.Lock()
+	defer r.Access.Unlock()
+	if r.File == nil {
+		return
+	}
+	r.event("i", string(data))
+}
+
+/**
+ * Record a resize event if the terminal
+ * changed size since the last call.
+ */
+func (r *Recorder) UpdateSize(cols, rows int) {
+	if r == nil || cols <= 0 || rows <= 0 {
+		return
+	}
+	r.Access.Lock()
+	defer r.Access.Unlock()
+	if r.File == nil || (cols == r.Cols && rows == r.Rows) {
+		return
+	}
+	r.Cols = cols
+	r.Rows = rows
+	r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *Recorder) Close() {
+	if r == nil {
+		return
+	}
+	r.Access.Lock()
+	defer r.Access.Unlock()
+	if r.File == nil {
+		return
+	}
+	r.File.Close()
+	r.File = nil
+}
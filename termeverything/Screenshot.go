@@ -0,0 +1,243 @@
+package termeverything
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Screenshots have to be taken on the draw loop,
+ * requests from other goroutines go through
+ * TerminalDrawLoop.ScreenshotRequests.
+ */
+type ScreenshotRequest struct {
+	/**
+	 * Only the focused window instead
+	 * of the whole desktop.
+	 */
+	Window bool
+	Reply  chan ScreenshotResult
+}
+
+type ScreenshotResult struct {
+	Path string
+	Err  error
+}
+
+/**
+ * How long the path stays in the status line
+ */
+const screenshotMessageSeconds = 5.0
+
+func DefaultScreenshotDir() string {
+	if dir := os.Getenv("XDG_PICTURES_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	for _, pictures := range []string{userDirsPicturesDir(home), filepath.Join(home, "Pictures")} {
+		if pictures == "" {
+			continue
+		}
+		if info, err := os.Stat(pictures); err == nil && info.IsDir() {
+			return pictures
+		}
+	}
+	return "."
+}
+
+/**
+ * XDG_PICTURES_DIR is usually not in the environment,
+ * xdg-user-dirs writes it to user-dirs.dirs as
+ * XDG_PICTURES_DIR="$HOME/Pictures"
+ * Returns "" if it isn't there.
+ */
+func userDirsPicturesDir(home string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	data, err := os.ReadFile(filepath.Join(configHome, "user-dirs.dirs"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "XDG_PICTURES_DIR=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if rest, ok := strings.CutPrefix(value, "$HOME"); ok {
+			value = home + rest
+		}
+		/**
+		 * Set to $HOME/ when the user
+		 * turned the directory off.
+		 */
+		if !filepath.IsAbs(value) || filepath.Clean(value) == filepath.Clean(home) {
+			return ""
+		}
+		return value
+	}
+	return ""
+}
+
+/**
+ * Textures and the desktop are BGRA, PNG wants RGBA.
+ * Drawn over black, so the result is opaque.
+ */
+func BGRAToRGBA(src *image.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := range bounds.Dy() {
+		srcRow := src.Pix[(y+bounds.Min.Y-src.Rect.Min.Y)*src.Stride+(bounds.Min.X-src.Rect.Min.X)*4:]
+		dstRow := img.Pix[y*img.Stride:]
+		for x := range bounds.Dx() {
+			dstRow[x*4+0] = srcRow[x*4+2]
+			dstRow[x*4+1] = srcRow[x*4+1]
+			dstRow[x*4+2] = srcRow[x*4+0]
+			dstRow[x*4+3] = 0xff
+		}
+	}
+	return img
+}
+
+func WritePNG(img image.Image, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/**
+ * The surface of the toplevel GetFocusedToplevel returns
+ */
+func (tw *TerminalDrawLoop) GetFocusedToplevelSurface() *wayland.WlSurface {
+	_, surface := tw.GetFocusedToplevelClientAndSurface()
+	return surface
+}
+
+func (tw *TerminalDrawLoop) GetFocusedToplevelClientAndSurface() (*wayland.Client, *wayland.WlSurface) {
+	s, topLevelID, top_level := wayland.TopmostToplevel(tw.Clients, tw.Desktop.ShowsToplevel)
+	if top_level == nil {
+		return nil, nil
+	}
+	surfaceID := s.GetSurfaceIDFromRole(protocols.AnyObjectID(topLevelID))
+	if surfaceID == nil {
+		return nil, nil
+	}
+	if surface := wayland.GetWlSurfaceObject(s, *surfaceID); surface != nil {
+		return s, surface
+	}
+	return nil, nil
+}
+
+/**
+ * Save the desktop (or just the focused window)
+ * at full resolution, returns the path.
+ */
+func (tw *TerminalDrawLoop) TakeScreenshot(window bool) (string, error) {
+	src := tw.Desktop.RGBA
+	name := "desktop"
+	if window {
+		surface := tw.GetFocusedToplevelSurface()
+		if surface == nil {
+			return "", fmt.Errorf("no window to take a screenshot of")
+		}
+		src = surface.Texture.AsRGBA()
+		if src == nil {
+			return "", fmt.Errorf("the window has not drawn anything yet")
+		}
+		name = "window"
+	}
+
+	if err := os.MkdirAll(tw.ScreenshotDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(tw.ScreenshotDir,
+		fmt.Sprintf("term.everything-%s-%s.png", name, time.Now().Format("2006-01-02-15-04-05.000")))
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	if err := WritePNG(BGRAToRGBA(src), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+/**
+ * Take the screenshot and say where
+ * it went in the status line.
+ */
+func (tw *TerminalDrawLoop) TakeScreenshotAndReport(window bool) ScreenshotResult {
+	path, err := tw.TakeScreenshot(window)
+	if err != nil {
+		tw.ScreenshotMessage = "screenshot failed: " + err.Error()
+	} else {
+		tw.ScreenshotMessage = "saved " + path
+	}
+	tw.TimeOfScreenshotMessage = time.Now()
+	return ScreenshotResult{Path: path, Err: err}
+}
+
+/**
+ * F12 takes a screenshot, whether or not the status
+ * line is showing. Returns true if the code should
+ * not be sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleScreenshotCode(code XkbdCode) bool {
+	c, ok := code.(*KeyCode)
+	if !ok || c.KeyCode != KEY_F12 || c.Modifiers != 0 || tw.ScreenshotRequests == nil {
+		return false
+	}
+	/**
+	 * Don't wait on the draw loop, it
+	 * may be waiting on the clients
+	 * we have locked.
+	 */
+	select {
+	case tw.ScreenshotRequests <- ScreenshotRequest{}:
+	default:
+	}
+	return true
+}
+
+func (tw *TerminalDrawLoop) HandleScreenshotRequests() {
+	for {
+		select {
+		case request := <-tw.ScreenshotRequests:
+			result := tw.TakeScreenshotAndReport(request.Window)
+			if request.Reply != nil {
+				request.Reply <- result
+			}
+		default:
+			return
+		}
+	}
+}
+
+/**
+ * For the status line
+ */
+func (tw *TerminalDrawLoop) ScreenshotStatus() string {
+	if tw.ScreenshotMessage == "" ||
+		time.Since(tw.TimeOfScreenshotMessage).Seconds() > screenshotMessageSeconds {
+		return ""
+	}
+	return tw.ScreenshotMessage
+}
@@ -7,27 +7,102 @@ import (
 	"strings"
 
 	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
 )
 
+/**
+ * Bigger than any real monitor, and the desktop
+ * buffer (4 bytes a pixel) still fits in memory.
+ */
+const maxPixelSize = 16384
+
+func ParsePixelSize(size string) (wayland.PixelSize, error) {
+	parts := strings.Split(size, "x")
+	if len(parts) != 2 {
+		return wayland.PixelSize{}, fmt.Errorf("expected <width>x<height>")
+	}
+	width, err1 := strconv.Atoi(parts[0])
+	height, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return wayland.PixelSize{}, fmt.Errorf("expected <width>x<height>")
+	}
+	if width > maxPixelSize || height > maxPixelSize {
+		return wayland.PixelSize{}, fmt.Errorf("expected a width and height up to %d", maxPixelSize)
+	}
+	return wayland.PixelSize{
+		Width:  wayland.Pixels(width),
+		Height: wayland.Pixels(height),
+	}, nil
+}
+
 func SetVirtualMonitorSize(newVirtualMonitorSize string) {
 	if newVirtualMonitorSize == "" {
 		return
 	}
-	parts := strings.Split(newVirtualMonitorSize, "x")
-	if len(parts) != 2 {
-		fmt.Fprintf(os.Stderr, "Invalid virtual monitor size %s, expected <width>x<height>\n", newVirtualMonitorSize)
+	size, err := ParsePixelSize(newVirtualMonitorSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid virtual monitor size %s, %v\n", newVirtualMonitorSize, err)
 		os.Exit(1)
 	}
-	width, err1 := strconv.Atoi(parts[0])
-	height, err2 := strconv.Atoi(parts[1])
-	if err1 != nil || err2 != nil {
-		fmt.Fprintf(os.Stderr, "Invalid virtual monitor size %s, expected <width>x<height>\n", newVirtualMonitorSize)
+	wayland.VirtualMonitorSize.Width = size.Width
+	wayland.VirtualMonitorSize.Height = size.Height
+}
+
+/**
+ * extraOutputs is a comma separated list of sizes,
+ * or a number of outputs the same size as the first
+ * (--virtual-monitor-size).
+ */
+func SetVirtualOutputs(extraOutputs string) {
+	sizes := make([]wayland.PixelSize, 0)
+	if count, err := strconv.Atoi(extraOutputs); err == nil {
+		for range max(count, 0) {
+			sizes = append(sizes, wayland.VirtualMonitorSize)
+		}
+	} else if extraOutputs != "" {
+		for _, part := range strings.Split(extraOutputs, ",") {
+			size, err := ParsePixelSize(strings.TrimSpace(part))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid output size %s, %v\n", part, err)
+				os.Exit(1)
+			}
+			sizes = append(sizes, size)
+		}
+	}
+	wayland.SetVirtualOutputs(sizes)
+}
+
+/**
+ * scale is desktop pixels per window pixel,
+ * ex: 2 or 1.5, empty for 1.
+ */
+func SetOutputScale(scale string) {
+	if scale == "" {
+		return
+	}
+	value, err := strconv.ParseFloat(scale, 64)
+	if err != nil || value < 0.25 || value > 8 {
+		fmt.Fprintf(os.Stderr, "Invalid scale %s, expected a number from 0.25 to 8\n", scale)
 		os.Exit(1)
 	}
-	if width <= 0 || height <= 0 {
-		fmt.Fprintf(os.Stderr, "Invalid virtual monitor size %s, expected <width>x<height>\n", newVirtualMonitorSize)
+	wayland.SetOutputScale(value)
+}
+
+/**
+ * rotation is 0 or 90, see wayland.OutputTransform.
+ * 180 and 270 would only differ by drawing the
+ * desktop upside down, which the terminal can't
+ * be turned to fix, so they aren't offered.
+ */
+func SetOutputRotation(rotation string) {
+	transforms := map[string]protocols.WlOutputTransform_enum{
+		"0":  protocols.WlOutputTransform_enum_normal,
+		"90": protocols.WlOutputTransform_enum__90,
+	}
+	transform, ok := transforms[rotation]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid rotation %s, expected 0 or 90\n", rotation)
 		os.Exit(1)
 	}
-	wayland.VirtualMonitorSize.Width = wayland.Pixels(width)
-	wayland.VirtualMonitorSize.Height = wayland.Pixels(height)
+	wayland.SetOutputTransform(transform)
 }
@@ -0,0 +1,37 @@
+package termeverything
+
+import (
+	"testing"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+func TestParsePixelSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    wayland.PixelSize
+		wantErr bool
+	}{
+		{"640x480", wayland.PixelSize{Width: 640, Height: 480}, false},
+		{"1x1", wayland.PixelSize{Width: 1, Height: 1}, false},
+		{"16384x16384", wayland.PixelSize{Width: 16384, Height: 16384}, false},
+		{"16385x480", wayland.PixelSize{}, true},
+		{"640x99999999999", wayland.PixelSize{}, true},
+		{"", wayland.PixelSize{}, true},
+		{"640", wayland.PixelSize{}, true},
+		{"640x", wayland.PixelSize{}, true},
+		{"x480", wayland.PixelSize{}, true},
+		{"640x480x2", wayland.PixelSize{}, true},
+		{"640X480", wayland.PixelSize{}, true},
+		{"640 x 480", wayland.PixelSize{}, true},
+		{"0x480", wayland.PixelSize{}, true},
+		{"640x-480", wayland.PixelSize{}, true},
+		{"64.5x480", wayland.PixelSize{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParsePixelSize(test.size)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParsePixelSize(%q) = %v, %v", test.size, got, err)
+		}
+	}
+}
@@ -50,7 +50,11 @@ type Status_Line struct {
 
 	b       map[string]*StatusLineButton
 	Sponsor *StatusLineButton
-	Bugs    *StatusLineButton
+	/**
+	 * The callback is set by the draw loop
+	 */
+	Screenshot *StatusLineButton
+	Bugs       *StatusLineButton
 }
 
 func (s *Status_Line) UpdateMousePosition(code *PointerMove) {
@@ -112,6 +116,18 @@ func MakeStatusLine() *Status_Line {
 		},
 	}
 
+	/**
+	 * No Keycode, F12 is handled by
+	 * TerminalWindow.HandleScreenshotCode
+	 * even when the status line is hidden.
+	 */
+	sl.Screenshot = &StatusLineButton{
+		Button: LineButton{
+			String:   "[F12] screenshot",
+			Callback: func() {},
+		},
+	}
+
 	sl.Sponsor = &StatusLineButton{
 		Button: LineButton{
 			String: "[Sponsor this project]",
@@ -136,16 +152,28 @@ func MakeStatusLine() *Status_Line {
 	return sl
 }
 
-func (s *Status_Line) Draw(delta_time float64, app_title *string, keys_pressed_this_frame map[Linux_Event_Codes]bool) string {
+/**
+ * indicators are shown after the app title,
+ * empty ones are skipped.
+ */
+func (s *Status_Line) Draw(delta_time float64, app_title *string, keys_pressed_this_frame map[Linux_Event_Codes]bool, indicators ...string) string {
 	if !s.ShowStatusLine {
 		return ""
 	}
 
-	text := s.Line(keys_pressed_this_frame,
+	parts := []StatusLineTextOrButton{
 		s.b["escape"], &StatusLineText{" "},
+		s.Screenshot, &StatusLineText{" "},
 		s.Sponsor, &StatusLineText{" | "},
 		s.ChooseAppTitle(app_title), &StatusLineText{" | "},
-	)
+	}
+	for _, indicator := range indicators {
+		if indicator == "" {
+			continue
+		}
+		parts = append(parts, &StatusLineText{indicator}, &StatusLineText{" | "})
+	}
+	text := s.Line(keys_pressed_this_frame, parts...)
 
 	s.TextLoopTime += delta_time
 
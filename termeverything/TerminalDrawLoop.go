@@ -2,11 +2,13 @@ package termeverything
 
 import (
 	_ "embed"
+	"image"
 	"os"
 	"slices"
 	"strconv"
 	"time"
 
+	"github.com/mmulet/term.everything/escapecodes"
 	"github.com/mmulet/term.everything/framebuffertoansi"
 	"github.com/mmulet/term.everything/wayland"
 	"github.com/mmulet/term.everything/wayland/protocols"
@@ -16,14 +18,16 @@ import (
 var iconPNG []byte
 
 type FrameInputState struct {
-	KeysPressedThisFrame map[Linux_Event_Codes]bool
-	MouseMoveThisFrame   bool
+	KeysPressedThisFrame  map[Linux_Event_Codes]bool
+	MouseMoveThisFrame    bool
+	FocusChangedThisFrame bool
 }
 
 func MakeFrameInputState() FrameInputState {
 	return FrameInputState{
-		KeysPressedThisFrame: make(map[Linux_Event_Codes]bool),
-		MouseMoveThisFrame:   false,
+		KeysPressedThisFrame:  make(map[Linux_Event_Codes]bool),
+		MouseMoveThisFrame:    false,
+		FocusChangedThisFrame: false,
 	}
 }
 
@@ -45,6 +49,16 @@ type TerminalDrawLoop struct {
 	 */
 	MinTerminalTimeSeconds *float64
 
+	/**
+	 * Same as MinTerminalTimeSeconds, but used while
+	 * the terminal does not have focus.
+	 *
+	 * This is set from the --unfocused-frame-rate argument.
+	 */
+	UnfocusedMinTerminalTimeSeconds *float64
+
+	TerminalFocused bool
+
 	DrawState *framebuffertoansi.DrawState
 
 	Desktop *wayland.Desktop
@@ -59,6 +73,105 @@ type TerminalDrawLoop struct {
 
 	StatusLine *Status_Line
 
+	TerminalTitle *TerminalTitle
+
+	/**
+	 * Set from the --terminal-pointer argument.
+	 * The app's cursor is not drawn, instead the
+	 * terminal's pointer shape is set with OSC 22.
+	 */
+	TerminalPointer bool
+	/**
+	 * Last shape sent with OSC 22
+	 */
+	LastPointerShape string
+
+	/**
+	 * Set from the --notifications argument
+	 */
+	NotificationMode NotificationMode
+
+	/**
+	 * Desktop damage from frames that haven't
+	 * been drawn to the terminal yet.
+	 */
+	PendingDamage image.Rectangle
+
+	/**
+	 * Set from the --latency-target argument,
+	 * nil to always draw at full quality.
+	 */
+	Quality *AdaptiveQuality
+
+	/**
+	 * Set in --headless mode, frames go
+	 * here instead of the terminal.
+	 */
+	Headless *HeadlessOutput
+
+	/**
+	 * Set from the --record argument
+	 */
+	Recorder *Recorder
+
+	/**
+	 * Set from the --screenshot-dir argument
+	 */
+	ScreenshotDir      string
+	ScreenshotRequests chan ScreenshotRequest
+	/**
+	 * The control socket's stats command,
+	 * answered with DrawStats.Describe
+	 */
+	StatsRequests chan chan string
+	/**
+	 * Where the last screenshot went, shown
+	 * in the status line for a few seconds.
+	 */
+	ScreenshotMessage       string
+	TimeOfScreenshotMessage time.Time
+
+	/**
+	 * Zoom and pan, shared with TerminalWindow
+	 */
+	Viewport *Viewport
+	/**
+	 * The zoomed in part of the desktop,
+	 * reused between frames.
+	 */
+	ViewportBuffer []byte
+
+	/**
+	 * Split-pane mode, shared with TerminalWindow
+	 */
+	Tiling      *Tiling
+	TilingPanes map[*wayland.WlSurface]*TilingPane
+	/**
+	 * Tiling was turned on or off, clear
+	 * the terminal and draw everything.
+	 */
+	TilingFullDraw          bool
+	LastTilingTermSize      framebuffertoansi.TermSize
+	LastTilingHadStatusLine bool
+
+	/**
+	 * Thumbnails of every window, shared with TerminalWindow
+	 */
+	Overview           *Overview
+	OverviewThumbnails map[*wayland.WlSurface]*OverviewThumbnail
+	/**
+	 * Opened, closed or the selection moved
+	 */
+	OverviewChanged           bool
+	LastOverviewTermSize      framebuffertoansi.TermSize
+	LastOverviewHadStatusLine bool
+
+	/**
+	 * Frame callbacks of windows that
+	 * aren't shown, see SendFrameCallbacks
+	 */
+	HeldFrameDrawRequests map[*wayland.Client][]wayland.FrameDrawRequest
+
 	GetClients      chan *wayland.Client
 	FirstDrawDone   bool
 	LastDrawSize    framebuffertoansi.WinSize
@@ -79,10 +192,8 @@ func MakeTerminalDrawLoop(desktop_size wayland.Size,
 		MinTerminalTimeSeconds:   nil,
 		SharedRenderedScreenSize: sharedRenderedScreenSize,
 		HideStatusBar:            hide_status_bar,
-		DrawState: framebuffertoansi.MakeDrawState(
-			DisplayServerType() == DisplayServerTypeX11,
-		),
-		VirtualMonitorSize: desktop_size,
+		DrawState:                framebuffertoansi.MakeDrawState(),
+		VirtualMonitorSize:       desktop_size,
 
 		Desktop: wayland.MakeDesktop(wayland.Size{
 			Width:  desktop_size.Width,
@@ -95,6 +206,36 @@ func MakeTerminalDrawLoop(desktop_size wayland.Size,
 		FrameEvents:             frameEvents,
 		GetClients:              make(chan *wayland.Client, 32),
 		FrameInputState:         MakeFrameInputState(),
+		TerminalFocused:         true,
+		TerminalTitle:           MakeTerminalTitle(""),
+		ScreenshotDir:           DefaultScreenshotDir(),
+		ScreenshotRequests:      make(chan ScreenshotRequest, 8),
+		StatsRequests:           make(chan chan string, 8),
+		Viewport:                MakeViewport(desktop_size),
+		Tiling:                  MakeTiling(TilingSplit_Off),
+		Overview:                MakeOverview(),
+		HeldFrameDrawRequests:   make(map[*wayland.Client][]wayland.FrameDrawRequest),
+	}
+	tw.StatusLine.Screenshot.Button.Callback = func() {
+		tw.TakeScreenshotAndReport(false)
+	}
+	if args != nil && !protocols.DebugRequests {
+		tw.TerminalTitle = MakeTerminalTitle(args.TitleTemplate)
+	}
+	if args != nil {
+		// Checked in ParseArgs
+		tw.NotificationMode, _ = ParseNotificationMode(args.Notifications)
+		tw.DrawState.Kitty = framebuffertoansi.MakeKittyRenderer(
+			framebuffertoansi.ParseKittyTransmission(args.KittyTransmission),
+		)
+		tw.DrawState.RendererName = args.Renderer
+		tw.Quality = MakeAdaptiveQuality(args.LatencyTarget)
+		if args.ScreenshotDir != "" {
+			tw.ScreenshotDir = args.ScreenshotDir
+		}
+	}
+	if args != nil && args.TerminalPointer {
+		tw.TerminalPointer = true
 	}
 	if args != nil && args.MaxFrameRate != "" {
 		if fps, err := strconv.ParseFloat(args.MaxFrameRate, 64); err == nil && fps > 0 {
@@ -102,21 +243,91 @@ func MakeTerminalDrawLoop(desktop_size wayland.Size,
 			tw.MinTerminalTimeSeconds = &v
 		}
 	}
+	if args != nil && args.FullRefreshInterval != "" {
+		if seconds, err := strconv.ParseFloat(args.FullRefreshInterval, 64); err == nil {
+			tw.DrawState.FullRefreshIntervalSeconds = seconds
+		}
+	}
+	if args != nil && args.UnfocusedFrameRate != "" {
+		if fps, err := strconv.ParseFloat(args.UnfocusedFrameRate, 64); err == nil && fps > 0 {
+			v := 1.0 / fps
+			tw.UnfocusedMinTerminalTimeSeconds = &v
+		}
+	}
 
 	return tw
 }
 
+/**
+ * The toplevel that has the keyboard focus
+ * while the terminal is focused, see
+ * UpdateKeyboardFocus.
+ */
+func (tw *TerminalDrawLoop) GetFocusedToplevel() *wayland.XdgToplevel {
+	_, _, top_level := tw.TopmostFocusableToplevel()
+	return top_level
+}
+
 func (tw *TerminalDrawLoop) GetAppTitle() *string {
-	for _, s := range tw.Clients {
-		for topLevelID := range s.TopLevelSurfaces() {
-			top_level := wayland.GetXdgToplevelObject(s, topLevelID)
-			if top_level == nil {
-				continue
-			}
-			return top_level.Title
+	top_level := tw.GetFocusedToplevel()
+	if top_level == nil {
+		return nil
+	}
+	return top_level.Title
+}
+
+func (tw *TerminalDrawLoop) UpdateTerminalTitle() {
+	top_level := tw.GetFocusedToplevel()
+	if top_level == nil {
+		tw.TerminalTitle.Update("", "")
+		return
+	}
+	title := ""
+	if top_level.Title != nil {
+		title = *top_level.Title
+	}
+	tw.TerminalTitle.Update(title, top_level.AppID)
+}
+
+/**
+ * Answer the control socket's stats
+ * commands with the DrawState's stats
+ */
+func (tw *TerminalDrawLoop) HandleStatsRequests() {
+	for {
+		select {
+		case reply := <-tw.StatsRequests:
+			reply <- tw.DrawState.Stats.Describe()
+		default:
+			return
 		}
 	}
-	return nil
+}
+
+/**
+ * With --terminal-pointer, the terminal shows the
+ * pointer while the app's cursor is a shape. Apps
+ * that use a cursor surface get it drawn
+ * into the image instead.
+ */
+func (tw *TerminalDrawLoop) TerminalDrawsPointer() bool {
+	return tw.TerminalPointer && wayland.CursorIsShape()
+}
+
+/**
+ * Send the app's cursor shape to the terminal (OSC 22)
+ * when it changes.
+ */
+func (tw *TerminalDrawLoop) UpdateTerminalPointer() {
+	if !tw.TerminalPointer || protocols.DebugRequests {
+		return
+	}
+	shape := wayland.CursorShapeName()
+	if shape == tw.LastPointerShape {
+		return
+	}
+	tw.LastPointerShape = shape
+	os.Stdout.WriteString(escapecodes.SetPointerShapeStart + shape + escapecodes.Bell)
 }
 
 func (tw *TerminalDrawLoop) DrawToTerminal(status_line string) {
@@ -132,12 +343,34 @@ func (tw *TerminalDrawLoop) DrawToTerminal(status_line string) {
 		statusLine = &status_line
 	}
 
+	if winsize, err := framebuffertoansi.GetWinsize(os.Stdout.Fd()); err == nil {
+		tw.Recorder.UpdateSize(int(winsize.Col), int(winsize.Row))
+	}
+
+	buffer := tw.Desktop.Buffer
+	width, height := tw.VirtualMonitorSize.Width, tw.VirtualMonitorSize.Height
+	damage := tw.PendingDamage
+	rect, changed := tw.Viewport.TakeRect()
+	if rect != image.Rect(0, 0, int(width), int(height)) {
+		tw.ViewportBuffer = CropBGRA(tw.ViewportBuffer, tw.Desktop, rect)
+		buffer = tw.ViewportBuffer
+		width, height = uint32(rect.Dx()), uint32(rect.Dy())
+		damage = damage.Intersect(rect).Sub(rect.Min)
+	}
+	if changed {
+		damage = image.Rect(0, 0, int(width), int(height))
+	}
+
 	widthCells, heightCells := tw.DrawState.DrawDesktop(
-		tw.Desktop.Buffer,
-		tw.VirtualMonitorSize.Width,
-		tw.VirtualMonitorSize.Height,
+		buffer,
+		width,
+		height,
 		statusLine,
+		damage,
 	)
+	tw.PendingDamage = image.Rectangle{}
+	tw.Quality.Update(tw.DrawState.LastWriteBytes, tw.DrawState.LastWriteDuration)
+	tw.Quality.Apply(tw.DrawState)
 	tw.SharedRenderedScreenSize.WidthCells = &widthCells
 	tw.SharedRenderedScreenSize.HeightCells = &heightCells
 
@@ -155,13 +388,24 @@ func (tw *TerminalDrawLoop) MainLoop() {
 				case *KeyCode:
 					tw.FrameInputState.KeysPressedThisFrame[c.KeyCode] = true
 				case *PointerMove:
+					wasOnStatusLine := tw.StatusLine.TerminalMousePosition.y == 0
 					tw.StatusLine.UpdateMousePosition(c)
-					tw.FrameInputState.MouseMoveThisFrame = true
+					/**
+					 * When the terminal draws the pointer, moving
+					 * the mouse only needs a redraw for hovering
+					 * over the status line.
+					 */
+					if !tw.TerminalDrawsPointer() || wasOnStatusLine || c.Row == 0 {
+						tw.FrameInputState.MouseMoveThisFrame = true
+					}
 				case *PointerButtonPress:
 					tw.StatusLine.HandleTerminalMousePress(true)
 				case *PointerButtonRelease:
 					tw.StatusLine.HandleTerminalMousePress(false)
 				case *PointerWheel:
+				case *FocusChange:
+					tw.TerminalFocused = c.Focused
+					tw.FrameInputState.FocusChangedThisFrame = true
 				}
 			case client := <-tw.GetClients:
 				//TODO removing clients
@@ -188,19 +432,6 @@ func (tw *TerminalDrawLoop) DrawClients() {
 	} else {
 		delta_time = tw.DesiredFrameTimeSeconds
 	}
-	num_draw_requests := 0
-	for _, s := range tw.Clients {
-		for {
-			select {
-			case callback_id := <-s.FrameDrawRequests:
-				protocols.WlCallback_done(s, callback_id, uint32(time.Now().UnixMilli()))
-				num_draw_requests++
-			default:
-				goto DoneCallbacks
-			}
-		}
-	DoneCallbacks:
-	}
 	clients_to_delete := make([]int, 0)
 	for i, s := range tw.Clients {
 		s.Access.Lock()
@@ -232,12 +463,90 @@ func (tw *TerminalDrawLoop) DrawClients() {
 
 	}
 
-	tw.Desktop.DrawClients(tw.Clients)
+	if output := wayland.CurrentVirtualOutput(); output != tw.Desktop.Output {
+		tw.ShowOutput(output)
+	}
+	if workspace := wayland.CurrentWorkspace(); workspace != tw.Desktop.Workspace {
+		tw.ShowWorkspace(workspace)
+	}
+	num_draw_requests := tw.SendFrameCallbacks()
+	tw.SuspendHiddenToplevels()
+	tw.UpdateKeyboardFocus()
 
-	status_line := tw.StatusLine.Draw(delta_time, tw.GetAppTitle(), tw.FrameInputState.KeysPressedThisFrame)
+	overview, overviewChanged := tw.Overview.TakeChanged()
+	if overviewChanged {
+		tw.OverviewChanged = true
+	}
+	/**
+	 * The thumbnails draw the windows themselves
+	 */
+	if overview && !tw.HasToplevels() {
+		tw.Overview.SetActive(false)
+		overview = false
+	}
+	showOverview := tw.Headless == nil && overview
+	if !showOverview && tw.OverviewThumbnails != nil {
+		tw.StopOverview()
+	}
+
+	split, tilingChanged := tw.Tiling.TakeSplit()
+	if tilingChanged {
+		tw.TilingFullDraw = true
+	}
+	/**
+	 * The panes draw the windows themselves
+	 */
+	tiled := tw.Headless == nil && split != TilingSplit_Off && tw.GetFocusedToplevel() != nil
+	if !tiled && tw.TilingPanes != nil {
+		tw.StopTiling()
+	}
+
+	if !tiled && !showOverview {
+		tw.Desktop.HideCursorSurfaces = tw.TerminalDrawsPointer()
+		tw.Desktop.DrawClients(tw.Clients)
+		tw.PendingDamage = tw.PendingDamage.Union(tw.Desktop.DamageBounds())
+		if tw.Viewport.FitsWindow() {
+			tw.Viewport.SetWindowRect(tw.FocusedWindowRect())
+		}
+	}
+	tw.HandleScreenshotRequests()
+	tw.HandleStatsRequests()
+
+	if tw.Headless != nil {
+		tw.Headless.Frame(tw.Desktop)
+		tw.TimeOfStartOfLastFrame = &start_of_frame
+		return
+	}
+
+	tw.UpdateTerminalTitle()
+	tw.UpdateTerminalPointer()
+	tw.HandleActivationRequests()
+
+	status_line := tw.StatusLine.Draw(delta_time, tw.GetAppTitle(), tw.FrameInputState.KeysPressedThisFrame,
+		tw.Overview.Describe(),
+		tw.OutputStatus(),
+		tw.WorkspaceStatus(),
+		tw.Tiling.Describe(),
+		tw.Viewport.Describe(),
+		tw.Quality.Describe(),
+		tw.ScreenshotStatus(),
+	)
 
 	if tw.ShouldDrawFrame(start_of_frame, num_draw_requests) {
-		tw.DrawToTerminal(status_line)
+		drewOverview := showOverview && tw.DrawOverviewToTerminal(status_line)
+		if !drewOverview && (!tiled || !tw.DrawTilesToTerminal(status_line, split, tw.TilingFullDraw)) {
+			if tiled || showOverview {
+				/**
+				 * Too small for the panes or thumbnails,
+				 * so the desktop wasn't drawn above
+				 */
+				tw.Desktop.HideCursorSurfaces = tw.TerminalDrawsPointer()
+				tw.Desktop.DrawClients(tw.Clients)
+			}
+			tw.DrawToTerminal(status_line)
+		}
+		tw.TilingFullDraw = false
+		tw.OverviewChanged = false
 	}
 
 	// const draw_time = Date.now();
@@ -255,6 +564,7 @@ func (tw *TerminalDrawLoop) DrawClients() {
 
 func (tw *TerminalDrawLoop) ResetFrameState() {
 	tw.FrameInputState.MouseMoveThisFrame = false
+	tw.FrameInputState.FocusChangedThisFrame = false
 	clear(tw.FrameInputState.KeysPressedThisFrame)
 }
 
@@ -264,16 +574,16 @@ func (tw *TerminalDrawLoop) ShouldDrawFrame(start_of_frame float64, num_draw_req
 			tw.FirstDrawDone = true
 		}
 	}()
-	if tw.MinTerminalTimeSeconds != nil {
+	if minTerminalTimeSeconds := tw.CurrentMinTerminalTimeSeconds(); minTerminalTimeSeconds != nil && !tw.FrameInputState.FocusChangedThisFrame {
 		last := 0.0
 		if tw.TimeOfLastTerminalDraw != nil {
 			last = *tw.TimeOfLastTerminalDraw
 		}
-		if start_of_frame-last < *tw.MinTerminalTimeSeconds {
+		if start_of_frame-last < *minTerminalTimeSeconds {
 			return false
 		}
-		tw.TimeOfLastTerminalDraw = &start_of_frame
 	}
+	tw.TimeOfLastTerminalDraw = &start_of_frame
 	if protocols.DebugRequests {
 		return false
 	}
@@ -287,7 +597,32 @@ func (tw *TerminalDrawLoop) ShouldDrawFrame(start_of_frame float64, num_draw_req
 		}
 	}
 	if num_draw_requests == 0 {
-		return tw.FrameInputState.MouseMoveThisFrame || !tw.FirstDrawDone
+		return tw.FrameInputState.MouseMoveThisFrame ||
+			tw.Viewport.HasChanged() ||
+			tw.TilingFullDraw ||
+			tw.OverviewChanged ||
+			tw.FrameInputState.FocusChangedThisFrame ||
+			!tw.FirstDrawDone
 	}
 	return true
 }
+
+/**
+ * While the terminal is not focused, draw
+ * at the (slower) unfocused frame rate.
+ */
+func (tw *TerminalDrawLoop) CurrentMinTerminalTimeSeconds() *float64 {
+	minTime := tw.MinTerminalTimeSeconds
+	if !tw.TerminalFocused && tw.UnfocusedMinTerminalTimeSeconds != nil &&
+		(minTime == nil || *minTime < *tw.UnfocusedMinTerminalTimeSeconds) {
+		minTime = tw.UnfocusedMinTerminalTimeSeconds
+	}
+	/**
+	 * Don't draw faster than the
+	 * terminal can take it.
+	 */
+	if paced := tw.Quality.MinFrameSeconds(); paced > 0 && (minTime == nil || *minTime < paced) {
+		minTime = &paced
+	}
+	return minTime
+}
@@ -0,0 +1,42 @@
+package termeverything
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mmulet/term.everything/framebuffertoansi"
+)
+
+const defaultProbeTimeout = 200 * time.Millisecond
+
+/**
+ * Milliseconds, 0 turns off probing.
+ */
+func ParseProbeTimeout(timeout string) time.Duration {
+	if timeout == "" {
+		return defaultProbeTimeout
+	}
+	v, err := strconv.ParseFloat(timeout, 64)
+	if err != nil || v < 0 {
+		return defaultProbeTimeout
+	}
+	return time.Duration(v * float64(time.Millisecond))
+}
+
+/**
+ * Ask the terminal what it supports, so pixel mode,
+ * colors and cell size don't have to be guessed from
+ * the environment (which is wrong in tmux, over ssh, ...).
+ * Returns any input that came in while waiting.
+ */
+func ProbeTerminal(timeout time.Duration) []byte {
+	if timeout <= 0 {
+		return nil
+	}
+	caps := framebuffertoansi.ProbeTerminal(timeout)
+	if caps == nil {
+		return nil
+	}
+	framebuffertoansi.SetTerminalCapabilities(caps)
+	return caps.Unparsed
+}
@@ -0,0 +1,146 @@
+package termeverything
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mmulet/term.everything/escapecodes"
+)
+
+const defaultTitleTemplate = "{title} — {app_id}"
+
+/**
+ * Mirrors the title and app_id of the focused
+ * toplevel to the terminal's title (and tab).
+ *
+ * The terminal's original title is pushed on
+ * the terminal's title stack at startup and
+ * popped on exit.
+ */
+type TerminalTitle struct {
+	/**
+	 * {title} and {app_id} are replaced
+	 * with the toplevel's values.
+	 * Empty means don't touch the terminal title.
+	 */
+	Template string
+
+	LastTitle *string
+}
+
+func MakeTerminalTitle(template string) *TerminalTitle {
+	return &TerminalTitle{
+		Template: template,
+	}
+}
+
+func (t *TerminalTitle) Enabled() bool {
+	return t.Template != ""
+}
+
+/**
+ * Fill in the template. The text between two fields
+ * is a separator, it is only kept when there is
+ * something on both sides of it, so a missing
+ * title or app_id doesn't leave a dangling " — ".
+ * The fields themselves are never trimmed.
+ * Empty when both fields are.
+ */
+func (t *TerminalTitle) Format(title, appID string) string {
+	if title == "" && appID == "" {
+		return ""
+	}
+	fields := map[string]string{
+		"{title}":  title,
+		"{app_id}": appID,
+	}
+	var out strings.Builder
+	rest := t.Template
+	/**
+	 * The separator waiting for the
+	 * next non empty field
+	 */
+	separator := ""
+	wroteField := false
+	for first := true; ; first = false {
+		index, name := nextTitleField(rest)
+		if index < 0 {
+			/**
+			 * Text after the last field
+			 */
+			out.WriteString(rest)
+			break
+		}
+		switch {
+		case first:
+			/**
+			 * Text before the first field
+			 */
+			out.WriteString(rest[:index])
+		case wroteField && separator == "":
+			separator = rest[:index]
+		}
+		if value := fields[name]; value != "" {
+			out.WriteString(separator)
+			out.WriteString(value)
+			separator = ""
+			wroteField = true
+		}
+		rest = rest[index+len(name):]
+	}
+	return sanitizeTitle(out.String())
+}
+
+/**
+ * The first {title} or {app_id} in template,
+ * -1 if there are none.
+ */
+func nextTitleField(template string) (int, string) {
+	index, name := -1, ""
+	for _, field := range []string{"{title}", "{app_id}"} {
+		if i := strings.Index(template, field); i >= 0 && (index < 0 || i < index) {
+			index, name = i, field
+		}
+	}
+	return index, name
+}
+
+/**
+ * Update the terminal title if it changed
+ * since the last call.
+ */
+func (t *TerminalTitle) Update(title, appID string) {
+	if !t.Enabled() {
+		return
+	}
+	newTitle := t.Format(title, appID)
+	if t.LastTitle != nil && *t.LastTitle == newTitle {
+		return
+	}
+	if t.LastTitle == nil && newTitle == "" {
+		return
+	}
+	t.LastTitle = &newTitle
+	if newTitle == "" {
+		/**
+		 * No app, so go back to the original
+		 * title (and save it again for later)
+		 */
+		os.Stdout.WriteString(escapecodes.PopTitle + escapecodes.PushTitle)
+		return
+	}
+	os.Stdout.WriteString(escapecodes.SetTitleStart + newTitle + escapecodes.Bell)
+}
+
+/**
+ * The title comes from the app, so strip anything
+ * that could end the OSC sequence early.
+ */
+func sanitizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, title)
+}
@@ -0,0 +1,37 @@
+package termeverything
+
+import "testing"
+
+func TestTerminalTitleFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		title    string
+		appID    string
+		want     string
+	}{
+		{"both", defaultTitleTemplate, "Inbox", "thunderbird", "Inbox — thunderbird"},
+		{"no title", defaultTitleTemplate, "", "thunderbird", "thunderbird"},
+		{"no app_id", defaultTitleTemplate, "Inbox", "", "Inbox"},
+		{"neither", defaultTitleTemplate, "", "", ""},
+		{"text around the fields", "[{app_id}: {title}]", "Inbox", "mail", "[mail: Inbox]"},
+		{"text around a missing field", "[{app_id}: {title}]", "Inbox", "", "[Inbox]"},
+		{"no fields", "term.everything", "Inbox", "mail", "term.everything"},
+		{"no fields and no app", "term.everything", "", "", ""},
+		{"repeated field", "{title} {title}", "a", "b", "a a"},
+		{"missing field in the middle", "{title} | {app_id} | {title}", "a", "", "a | a"},
+		{"fields are not trimmed", "{title}-{app_id}", " a ", "b", " a -b"},
+		{"unicode is kept", defaultTitleTemplate, "日本語", "app", "日本語 — app"},
+		{"escape codes are stripped", "{title}", "a\x1b]0;evil\x07b", "", "a]0;evilb"},
+		{"C1 controls are stripped", "{title}", "a\u009cb\u007f", "", "ab"},
+		{"unknown fields are text", "{name} {title}", "a", "", "{name} a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MakeTerminalTitle(test.template).Format(test.title, test.appID)
+			if got != test.want {
+				t.Errorf("Format(%q, %q) with %q = %q, want %q", test.title, test.appID, test.template, got, test.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/signal"
 	"slices"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/mmulet/term.everything/escapecodes"
 	"github.com/mmulet/term.everything/framebuffertoansi"
@@ -46,6 +48,68 @@ type TerminalWindow struct {
 	SharedRenderedScreenSize *RenderedScreenSize
 
 	RestoreTerminalMode func() error
+
+	PasteParser      BracketedPasteParser
+	PasteMode        PasteMode
+	PasteKeyInterval time.Duration
+	/**
+	 * Closed to stop the paste being typed,
+	 * nil when there isn't one.
+	 * Guarded by InputAccess.
+	 */
+	PasteCancel chan struct{}
+
+	/**
+	 * Typed while probing the terminal,
+	 * processed first by InputLoop.
+	 */
+	PendingInput []byte
+
+	/**
+	 * Set in --headless mode, the input
+	 * script asks it for snapshots.
+	 */
+	HeadlessOutput *HeadlessOutput
+
+	/**
+	 * Set from the --record argument
+	 */
+	Recorder *Recorder
+
+	/**
+	 * Input comes from the terminal, --headless-input
+	 * and the control socket, one at a time.
+	 */
+	InputAccess sync.Mutex
+
+	/**
+	 * TerminalDrawLoop.ScreenshotRequests
+	 */
+	ScreenshotRequests chan ScreenshotRequest
+	/**
+	 * TerminalDrawLoop.StatsRequests
+	 */
+	StatsRequests chan chan string
+
+	ControlSocketPath string
+
+	/**
+	 * From --zoom-keys, Alt+=, Alt+- and
+	 * Alt+0 zoom (see HandleViewportCode)
+	 */
+	ZoomKeys bool
+	/**
+	 * From --workspace-keys, see
+	 * HandleWorkspaceCode
+	 */
+	WorkspaceKeys bool
+
+	/**
+	 * Shared with TerminalDrawLoop
+	 */
+	Viewport *Viewport
+	Tiling   *Tiling
+	Overview *Overview
 }
 
 func MakeTerminalWindow(
@@ -55,9 +119,27 @@ func MakeTerminalWindow(
 
 ) *TerminalWindow {
 
-	restoreTerminalMode, err := EnableRawModeFD(int(os.Stdin.Fd()))
-	if err != nil {
-		panic(err)
+	// Checked in ParseArgs
+	pasteMode, _ := ParsePasteMode(args.PasteMode)
+	zoomKeys, _ := ParseHotkeys(args.ZoomKeys)
+	workspaceKeys, _ := ParseHotkeys(args.WorkspaceKeys)
+
+	restoreTerminalMode := func() error { return nil }
+	var pendingInput []byte
+	if !args.Headless {
+		var err error
+		restoreTerminalMode, err = EnableRawModeFD(int(os.Stdin.Fd()))
+		if err != nil {
+			panic(err)
+		}
+
+		/**
+		 * The debug build prints to stdout, the
+		 * queries would get mixed in with it.
+		 */
+		if !protocols.DebugRequests {
+			pendingInput = ProbeTerminal(ParseProbeTimeout(args.ProbeTimeout))
+		}
 	}
 
 	tw := &TerminalWindow{
@@ -72,12 +154,26 @@ func MakeTerminalWindow(
 		// RestoreTerminalMode:      func() error { return nil },
 		RestoreTerminalMode: restoreTerminalMode,
 		GetClients:          make(chan *wayland.Client, 32),
+		PasteMode:           pasteMode,
+		ZoomKeys:            zoomKeys,
+		WorkspaceKeys:       workspaceKeys,
+		PasteKeyInterval:    ParsePasteTypeRate(args.PasteTypeRate),
+		PendingInput:        pendingInput,
+		Viewport:            MakeViewport(desktop_size),
+		Tiling:              MakeTiling(ParseTilingSplit(args.Tiling)),
+		Overview:            MakeOverview(),
 	}
+	tw.Viewport.FitWindow = args.FitWindow
 
-	if !protocols.DebugRequests {
+	if !protocols.DebugRequests && !args.Headless {
 		os.Stdout.WriteString(escapecodes.EnableAlternativeScreenBuffer)
 		os.Stdout.WriteString(escapecodes.EnableMouseTracking)
 		os.Stdout.WriteString(escapecodes.EnableSGR)
+		os.Stdout.WriteString(escapecodes.EnableBracketedPaste)
+		os.Stdout.WriteString(escapecodes.EnableFocusReporting)
+		if args.TitleTemplate != "" {
+			os.Stdout.WriteString(escapecodes.PushTitle)
+		}
 
 		os.Stdout.WriteString(escapecodes.HideCursor)
 	}
@@ -110,6 +206,13 @@ func (tw *TerminalWindow) OnExit() {
 		}
 	}
 	tw.RestoreTerminalMode()
+	tw.Recorder.Close()
+	if tw.ControlSocketPath != "" {
+		os.Remove(tw.ControlSocketPath)
+	}
+	if tw.Args.Headless {
+		return
+	}
 
 	os.Stdout.WriteString(escapecodes.DisableAlternativeScreenBuffer)
 	os.Stdout.WriteString(escapecodes.ShowCursor)
@@ -117,10 +220,29 @@ func (tw *TerminalWindow) OnExit() {
 	// TODO re-enable if enabled above
 	// os.Stdout.WriteString(escapecodes.DisableNormalMouseTracking)
 	os.Stdout.WriteString(escapecodes.DisableMouseTracking)
+	os.Stdout.WriteString(escapecodes.DisableBracketedPaste)
+	os.Stdout.WriteString(escapecodes.DisableFocusReporting)
+	if tw.Args.TitleTemplate != "" {
+		os.Stdout.WriteString(escapecodes.PopTitle)
+	}
+	if tw.Args.TerminalPointer {
+		os.Stdout.WriteString(escapecodes.SetPointerShapeStart + "default" + escapecodes.Bell)
+	}
 
 }
 
 func (tw *TerminalWindow) InputLoop() {
+	if tw.Args.Headless {
+		tw.HeadlessInputLoop()
+		return
+	}
+	if len(tw.PendingInput) > 0 {
+		tw.Recorder.Input(tw.PendingInput)
+		tw.InputAccess.Lock()
+		tw.ProcessCodes(tw.PasteParser.Parse(tw.PendingInput))
+		tw.InputAccess.Unlock()
+		tw.PendingInput = nil
+	}
 	buf := make([]byte, 4096)
 	for {
 
@@ -131,23 +253,34 @@ func (tw *TerminalWindow) InputLoop() {
 			return
 		}
 		chunk := buf[:n]
-		for {
-			select {
-			case client := <-tw.GetClients:
-				//TODO removing client
-				tw.Clients = append(tw.Clients, client)
-			default:
-				goto GotData
-			}
-		}
-	GotData:
-		codes := ConvertKeycodeToXbdCode(chunk)
+		tw.Recorder.Input(chunk)
+		tw.InputAccess.Lock()
+		tw.ReceiveNewClients()
+		codes := tw.PasteParser.Parse(chunk)
 		tw.ProcessCodes(codes)
+		tw.InputAccess.Unlock()
 	}
 }
 
-func (tw *TerminalWindow) ProcessCodes(codes []XkbdCode) {
+func (tw *TerminalWindow) ReceiveNewClients() {
+	for {
+		select {
+		case client := <-tw.GetClients:
+			//TODO removing client
+			tw.Clients = append(tw.Clients, client)
+		default:
+			return
+		}
+	}
+}
+
+/**
+ * Drop disconnected clients and lock the rest,
+ * call the returned func to unlock them.
+ */
+func (tw *TerminalWindow) LockConnectedClients() (unlock func()) {
 	clients_to_delete := make([]int, 0)
+	locked := make([]*wayland.Client, 0, len(tw.Clients))
 	for i, s := range tw.Clients {
 		s.Access.Lock()
 		if s.Status != wayland.ClientStatus_Connected {
@@ -155,55 +288,63 @@ func (tw *TerminalWindow) ProcessCodes(codes []XkbdCode) {
 			clients_to_delete = append(clients_to_delete, i)
 			continue
 		} else {
-			defer s.Access.Unlock()
+			locked = append(locked, s)
 		}
 	}
 	for i := len(clients_to_delete) - 1; i >= 0; i-- {
 		index := clients_to_delete[i]
 		tw.Clients = slices.Delete(tw.Clients, index, index+1)
 	}
+	return func() {
+		for _, s := range locked {
+			s.Access.Unlock()
+		}
+	}
+}
+
+func (tw *TerminalWindow) ProcessCodes(codes []XkbdCode) {
+	unlock := tw.LockConnectedClients()
+	defer unlock()
 
 	for _, code := range codes {
 		tw.FrameEvents <- code
 
-		for _, s := range tw.Clients {
-			if keyboard_map := protocols.GetGlobalWlKeyboardBinds(s); keyboard_map != nil {
-				modifiers := code.GetModifiers()
-				ser := wayland.GetNextEventSerial()
-				for keyboardID := range keyboard_map {
-					protocols.WlKeyboard_modifiers(
-						s,
-						keyboardID,
-						ser,
-						uint32(modifiers),
-						0, 0, 0,
-					)
-				}
-			}
+		/**
+		 * Typing while a paste is being
+		 * typed stops the paste.
+		 */
+		if _, isKey := code.(*KeyCode); isKey {
+			tw.CancelPasteTyping()
 		}
+
+		if tw.HandleScreenshotCode(code) || tw.HandleOutputCode(code) || tw.HandleWorkspaceCode(code) || tw.HandleOverviewCode(code) || tw.HandleTilingCode(code) || tw.HandleViewportCode(code) {
+			continue
+		}
+
+		/**
+		 * After the handlers, which can
+		 * switch the workspace.
+		 */
+		clients := tw.ShownClients()
+		SendModifiersTo(clients, code.GetModifiers())
 		switch c := code.(type) {
 		case *KeyCode:
-			wayland.SendKeyboardKey(tw.Clients, uint32(c.KeyCode), true)
+			wayland.SendKeyboardKey(clients, uint32(c.KeyCode), true)
 			// Send key released immediately
-			wayland.SendKeyboardKey(tw.Clients, uint32(c.KeyCode), false)
+			wayland.SendKeyboardKey(clients, uint32(c.KeyCode), false)
 
 		case *PointerMove:
 			cols, rows := tw.CurrentTerminalSize()
-			x := float32(c.Col) *
-				(float32(tw.VirtualMonitorSize.Width) /
-					float32(cols))
-			y := float32(c.Row) *
-				(float32(tw.VirtualMonitorSize.Height) /
-					float32(rows))
+			x, y := wayland.LogicalPosition(tw.Viewport.ToDesktop(c.Col, c.Row, cols, rows))
 
-			wayland.SendPointerMotion(tw.Clients, x, y)
+			wayland.SendPointerMotion(clients, x, y)
 
 		case *PointerButtonPress:
 
 			release := tw.GetButtonToReleaseAndUpdatePressedMouseButton(c.Button)
-			wayland.SendPointerButton(tw.Clients, uint32(c.Button), true)
+			wayland.SendPointerButton(clients, uint32(c.Button), true)
 			if c.NeedToReleaseOtherButtons && release != nil {
-				wayland.SendPointerButton(tw.Clients, uint32(*release), false)
+				wayland.SendPointerButton(clients, uint32(*release), false)
 			}
 
 		case *PointerButtonRelease:
@@ -216,7 +357,7 @@ func (tw *TerminalWindow) ProcessCodes(codes []XkbdCode) {
 				tw.PressedMouseButton = nil
 			}
 
-			wayland.SendPointerButton(tw.Clients, uint32(buttonToRelease), false)
+			wayland.SendPointerButton(clients, uint32(buttonToRelease), false)
 
 		case *PointerWheel:
 			_, rows := tw.CurrentTerminalSize()
@@ -225,14 +366,38 @@ func (tw *TerminalWindow) ProcessCodes(codes []XkbdCode) {
 			if (c.Modifiers & ModAlt) != 0 {
 				scale = 1
 			}
-			amount := scale * float32(tw.ScrollDirection(c.Up)) * float32(tw.VirtualMonitorSize.Height) / float32(rows)
-			wayland.SendPointerAxis(tw.Clients, protocols.WlPointerAxis_enum_vertical_scroll, amount)
+			amount := scale * float32(tw.ScrollDirection(c.Up)) * float32(tw.Viewport.Rect().Dy()) / float32(rows)
+			wayland.SendPointerAxis(clients, protocols.WlPointerAxis_enum_vertical_scroll, amount)
+		case *Paste:
+			tw.HandlePaste(c)
+		case *FocusChange:
+			/**
+			 * The draw loop moves the keyboard
+			 * focus, see UpdateKeyboardFocus.
+			 */
 		default:
 			// literal never_default(code) equivalent: do nothing
 		}
 	}
 }
 
+func SendModifiersTo(clients []*wayland.Client, modifiers int) {
+	for _, s := range clients {
+		if keyboard_map := protocols.GetGlobalWlKeyboardBinds(s); keyboard_map != nil {
+			ser := wayland.GetNextEventSerial()
+			for keyboardID := range keyboard_map {
+				protocols.WlKeyboard_modifiers(
+					s,
+					keyboardID,
+					ser,
+					uint32(modifiers),
+					0, 0, 0,
+				)
+			}
+		}
+	}
+}
+
 func (tw *TerminalWindow) ScrollDirection(code_up bool) float32 {
 	var code float32 = 1.0
 	if code_up {
@@ -0,0 +1,529 @@
+package termeverything
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmulet/term.everything/escapecodes"
+	"github.com/mmulet/term.everything/framebuffertoansi"
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type TilingSplit int
+
+const (
+	TilingSplit_Off TilingSplit = iota
+	/**
+	 * Panes side by side
+	 */
+	TilingSplit_Horizontal
+	/**
+	 * Panes on top of each other
+	 */
+	TilingSplit_Vertical
+)
+
+func ParseTilingSplit(split string) TilingSplit {
+	switch strings.ToLower(split) {
+	case "horizontal", "h":
+		return TilingSplit_Horizontal
+	case "vertical", "v":
+		return TilingSplit_Vertical
+	default:
+		return TilingSplit_Off
+	}
+}
+
+/**
+ * Where a pane is on the terminal, so the
+ * input loop can send the mouse to the
+ * window under it.
+ */
+type TilingPaneLayout struct {
+	Client *wayland.Client
+	/**
+	 * Where the window's image is, not the
+	 * whole pane (it keeps its aspect ratio).
+	 */
+	Placed framebuffertoansi.CellRegion
+	Width  int
+	Height int
+}
+
+/**
+ * Shared between TerminalWindow (hotkeys and the mouse)
+ * and TerminalDrawLoop (layout and drawing).
+ */
+type Tiling struct {
+	Access sync.Mutex
+
+	Split TilingSplit
+	/**
+	 * The split changed since the draw loop
+	 * last called TakeSplit.
+	 */
+	Changed bool
+
+	/**
+	 * Written by the draw loop every frame
+	 */
+	Panes []TilingPaneLayout
+	/**
+	 * Index into Panes of the pane under the pointer,
+	 * and the one last clicked (it gets the keyboard).
+	 */
+	PointerPane int
+	FocusedPane int
+}
+
+func MakeTiling(split TilingSplit) *Tiling {
+	return &Tiling{
+		Split:   split,
+		Changed: split != TilingSplit_Off,
+	}
+}
+
+func (t *Tiling) Enabled() bool {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	return t.Split != TilingSplit_Off
+}
+
+/**
+ * Switch to split, or back to the
+ * single desktop if already in it.
+ */
+func (t *Tiling) Toggle(split TilingSplit) {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	if t.Split == split {
+		t.Split = TilingSplit_Off
+	} else {
+		t.Split = split
+	}
+	t.Changed = true
+}
+
+func (t *Tiling) TakeSplit() (TilingSplit, bool) {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	changed := t.Changed
+	t.Changed = false
+	return t.Split, changed
+}
+
+func (t *Tiling) SetPanes(panes []TilingPaneLayout) {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	t.Panes = panes
+	if t.PointerPane >= len(panes) {
+		t.PointerPane = 0
+	}
+	if t.FocusedPane >= len(panes) {
+		t.FocusedPane = 0
+	}
+}
+
+func (t *Tiling) GetPointerPane() int {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	return t.PointerPane
+}
+
+/**
+ * For the status line
+ */
+func (t *Tiling) Describe() string {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	switch t.Split {
+	case TilingSplit_Horizontal:
+		return "tiled side by side"
+	case TilingSplit_Vertical:
+		return "tiled on top of each other"
+	default:
+		return ""
+	}
+}
+
+/**
+ * Alt+Shift+H tiles side by side, Alt+Shift+V on top
+ * of each other, the same key again goes back to
+ * the desktop. While tiled, the mouse goes to the
+ * window under it, and the keyboard to the window
+ * that was clicked last.
+ * Returns true if the code should not be
+ * sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleTilingCode(code XkbdCode) bool {
+	t := tw.Tiling
+	switch c := code.(type) {
+	case *KeyCode:
+		if c.Modifiers&(ModAlt|ModShift) == ModAlt|ModShift && c.Modifiers&ModControl == 0 {
+			switch c.KeyCode {
+			case KEY_H:
+				t.Toggle(TilingSplit_Horizontal)
+				return true
+			case KEY_V:
+				t.Toggle(TilingSplit_Vertical)
+				return true
+			}
+		}
+		if client := tw.FocusedPaneClient(); client != nil {
+			SendModifiersTo([]*wayland.Client{client}, c.Modifiers)
+			wayland.SendKeyboardKey([]*wayland.Client{client}, uint32(c.KeyCode), true)
+			wayland.SendKeyboardKey([]*wayland.Client{client}, uint32(c.KeyCode), false)
+			return true
+		}
+	case *PointerMove:
+		t.Access.Lock()
+		defer t.Access.Unlock()
+		if t.Split == TilingSplit_Off || len(t.Panes) == 0 {
+			return false
+		}
+		// Regions are 1 based
+		row, col := c.Row+1, c.Col+1
+		for i, pane := range t.Panes {
+			if !pane.Placed.Contains(row, col) {
+				continue
+			}
+			t.PointerPane = i
+			x := float32(col-pane.Placed.Col) * float32(pane.Width) / float32(pane.Placed.WidthCells)
+			y := float32(row-pane.Placed.Row) * float32(pane.Height) / float32(pane.Placed.HeightCells)
+			x, y = wayland.LogicalPosition(x, y)
+			wayland.SendPointerMotion([]*wayland.Client{pane.Client}, x, y)
+			break
+		}
+		return true
+	case *PointerButtonPress:
+		client := tw.PointerPaneClient(true)
+		if client == nil {
+			return false
+		}
+		SendModifiersTo([]*wayland.Client{client}, c.Modifiers)
+		release := tw.GetButtonToReleaseAndUpdatePressedMouseButton(c.Button)
+		wayland.SendPointerButton([]*wayland.Client{client}, uint32(c.Button), true)
+		if c.NeedToReleaseOtherButtons && release != nil {
+			wayland.SendPointerButton([]*wayland.Client{client}, uint32(*release), false)
+		}
+		return true
+	case *PointerButtonRelease:
+		client := tw.PointerPaneClient(false)
+		if client == nil {
+			return false
+		}
+		buttonToRelease := c.Button
+		if c.NeedsButtonGuessing {
+			if tw.PressedMouseButton == nil {
+				return true
+			}
+			buttonToRelease = *tw.PressedMouseButton
+			tw.PressedMouseButton = nil
+		}
+		wayland.SendPointerButton([]*wayland.Client{client}, uint32(buttonToRelease), false)
+		return true
+	case *PointerWheel:
+		t.Access.Lock()
+		if t.Split == TilingSplit_Off || t.PointerPane >= len(t.Panes) {
+			t.Access.Unlock()
+			return false
+		}
+		pane := t.Panes[t.PointerPane]
+		t.Access.Unlock()
+
+		var scale float32 = 0.5
+		if (c.Modifiers & ModAlt) != 0 {
+			scale = 1
+		}
+		amount := scale * tw.ScrollDirection(c.Up) * float32(pane.Height) / float32(max(pane.Placed.HeightCells, 1))
+		wayland.SendPointerAxis([]*wayland.Client{pane.Client}, protocols.WlPointerAxis_enum_vertical_scroll, amount)
+		return true
+	}
+	return false
+}
+
+/**
+ * The client of the pane under the pointer,
+ * nil when not tiled. focus also gives that
+ * pane the keyboard.
+ */
+func (tw *TerminalWindow) PointerPaneClient(focus bool) *wayland.Client {
+	t := tw.Tiling
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	if t.Split == TilingSplit_Off || t.PointerPane >= len(t.Panes) {
+		return nil
+	}
+	if focus {
+		t.FocusedPane = t.PointerPane
+	}
+	return t.Panes[t.PointerPane].Client
+}
+
+func (tw *TerminalWindow) FocusedPaneClient() *wayland.Client {
+	return tw.Tiling.FocusedPaneClient()
+}
+
+/**
+ * The client of the pane that was clicked
+ * last, nil when not tiled.
+ */
+func (t *Tiling) FocusedPaneClient() *wayland.Client {
+	t.Access.Lock()
+	defer t.Access.Unlock()
+	if t.Split == TilingSplit_Off || t.FocusedPane >= len(t.Panes) {
+		return nil
+	}
+	return t.Panes[t.FocusedPane].Client
+}
+
+/**
+ * Draw loop side of a pane, one per toplevel
+ */
+type TilingPane struct {
+	Client     *wayland.Client
+	ToplevelID protocols.ObjectID[protocols.XdgToplevel]
+	Surface    *wayland.WlSurface
+	/**
+	 * Only this window is drawn into it, at the
+	 * size the window was configured to.
+	 */
+	Desktop   *wayland.Desktop
+	DrawState *framebuffertoansi.DrawState
+}
+
+/**
+ * Every toplevel shown, sorted so the panes
+ * don't change places from frame to frame
+ */
+func (tw *TerminalDrawLoop) ToplevelPanes() []*TilingPane {
+	panes := make([]*TilingPane, 0)
+	for _, s := range tw.Clients {
+		for _, topLevelID := range slices.Sorted(maps.Keys(s.TopLevelSurfaces())) {
+			if top_level := wayland.GetXdgToplevelObject(s, topLevelID); top_level == nil || !tw.Desktop.ShowsToplevel(top_level) {
+				continue
+			}
+			surfaceID := s.GetSurfaceIDFromRole(protocols.AnyObjectID(topLevelID))
+			if surfaceID == nil {
+				continue
+			}
+			surface := wayland.GetWlSurfaceObject(s, *surfaceID)
+			if surface == nil {
+				continue
+			}
+			pane := tw.TilingPanes[surface]
+			if pane == nil {
+				pane = &TilingPane{
+					Client:     s,
+					ToplevelID: topLevelID,
+					Surface:    surface,
+					DrawState:  framebuffertoansi.MakeDrawState(),
+				}
+				pane.DrawState.RendererName = tw.DrawState.RendererName
+				pane.DrawState.FullRefreshIntervalSeconds = tw.DrawState.FullRefreshIntervalSeconds
+			}
+			panes = append(panes, pane)
+		}
+	}
+	return panes
+}
+
+/**
+ * Cut the terminal (below the status line) into one
+ * region per pane, with a one cell border between them.
+ * nil when there isn't a cell for every pane and border.
+ */
+func TilingRegions(split TilingSplit, count int, termSize framebuffertoansi.TermSize, statusLineHeight int) []framebuffertoansi.CellRegion {
+	area := framebuffertoansi.CellRegion{
+		Row:         1 + statusLineHeight,
+		Col:         1,
+		WidthCells:  termSize.WidthCells,
+		HeightCells: termSize.HeightCells - statusLineHeight,
+	}
+	length := area.WidthCells
+	if split == TilingSplit_Vertical {
+		length = area.HeightCells
+	}
+	if count == 0 || length < 2*count-1 {
+		return nil
+	}
+	available := length - (count - 1)
+	regions := make([]framebuffertoansi.CellRegion, count)
+	start := 0
+	for i := range count {
+		size := available / count
+		if i < available%count {
+			size++
+		}
+		region := area
+		if split == TilingSplit_Vertical {
+			region.Row += start
+			region.HeightCells = size
+		} else {
+			region.Col += start
+			region.WidthCells = size
+		}
+		regions[i] = region
+		start += size + 1
+	}
+	return regions
+}
+
+func moveCursorTo(row, col int) string {
+	return fmt.Sprintf("\x1b[%d;%dH", row, col)
+}
+
+/**
+ * The lines between panes
+ */
+func writeTilingBorders(sb *strings.Builder, split TilingSplit, regions []framebuffertoansi.CellRegion) {
+	for _, region := range regions[:len(regions)-1] {
+		if split == TilingSplit_Vertical {
+			sb.WriteString(moveCursorTo(region.Row+region.HeightCells, region.Col))
+			sb.WriteString(strings.Repeat("─", region.WidthCells))
+			continue
+		}
+		for row := region.Row; row < region.Row+region.HeightCells; row++ {
+			sb.WriteString(moveCursorTo(row, region.Col+region.WidthCells))
+			sb.WriteString("│")
+		}
+	}
+}
+
+/**
+ * Turning tiling off: give the windows the whole
+ * monitor back and redraw the desktop from scratch.
+ */
+func (tw *TerminalDrawLoop) StopTiling() {
+	for _, pane := range tw.TilingPanes {
+		if toplevel := wayland.GetXdgToplevelObject(pane.Client, pane.ToplevelID); toplevel != nil {
+			toplevel.SetConfiguredSize(pane.Client, pane.ToplevelID, nil)
+		}
+		pane.DrawState.Destroy()
+	}
+	tw.TilingPanes = nil
+	tw.Tiling.SetPanes(nil)
+	tw.Desktop.LastSurfaceRects = nil
+	tw.DrawState.PreviousCells = nil
+	tw.DrawState.CanvasPrinted = false
+	tw.TilingFullDraw = true
+	os.Stdout.WriteString(escapecodes.ClearScreen)
+}
+
+/**
+ * Every toplevel in its own pane, each drawn
+ * by its own DrawState. Returns false when
+ * there is nothing to tile.
+ */
+func (tw *TerminalDrawLoop) DrawTilesToTerminal(status_line string, split TilingSplit, full bool) bool {
+	panes := tw.ToplevelPanes()
+	if len(panes) == 0 {
+		return false
+	}
+
+	haveStatusLine := !tw.HideStatusBar && status_line != ""
+	statusLineHeight := 0
+	if haveStatusLine {
+		statusLineHeight = 1
+	}
+	termSize := framebuffertoansi.MakeTermSize()
+	if termSize.WidthCells <= 0 || termSize.HeightCells <= statusLineHeight {
+		return false
+	}
+	if winsize, err := framebuffertoansi.GetWinsize(os.Stdout.Fd()); err == nil {
+		tw.Recorder.UpdateSize(int(winsize.Col), int(winsize.Row))
+	}
+	if termSize != tw.LastTilingTermSize || haveStatusLine != tw.LastTilingHadStatusLine ||
+		len(panes) != len(tw.TilingPanes) {
+		full = true
+	}
+	regions := TilingRegions(split, len(panes), termSize, statusLineHeight)
+	if regions == nil {
+		return false
+	}
+
+	var sb strings.Builder
+	if full {
+		sb.WriteString(escapecodes.ClearScreen)
+		writeTilingBorders(&sb, split, regions)
+	}
+	if haveStatusLine {
+		sb.WriteString(escapecodes.MoveCursorToHome)
+		sb.WriteString(status_line)
+		sb.WriteString(escapecodes.ClearLineAfterCursor)
+	}
+
+	pointerPane := tw.Tiling.GetPointerPane()
+	monitor := tw.VirtualMonitorSize
+	nextPanes := make(map[*wayland.WlSurface]*TilingPane, len(panes))
+	layout := make([]TilingPaneLayout, len(panes))
+	for i, pane := range panes {
+		region := regions[i]
+		/**
+		 * Each window gets the same share of the
+		 * monitor as its pane gets of the terminal.
+		 */
+		size := wayland.Size{Width: monitor.Width, Height: monitor.Height}
+		if split == TilingSplit_Vertical {
+			size.Height = max(monitor.Height*uint32(region.HeightCells)/uint32(termSize.HeightCells-statusLineHeight), 1)
+		} else {
+			size.Width = max(monitor.Width*uint32(region.WidthCells)/uint32(termSize.WidthCells), 1)
+		}
+		if toplevel := wayland.GetXdgToplevelObject(pane.Client, pane.ToplevelID); toplevel != nil {
+			logicalSize := wayland.LogicalSizeOf(size)
+			toplevel.SetConfiguredSize(pane.Client, pane.ToplevelID, &logicalSize)
+		}
+		if pane.Desktop == nil || pane.Desktop.Width != int(size.Width) || pane.Desktop.Height != int(size.Height) {
+			pane.Desktop = wayland.MakeDesktop(size, false, nil)
+			pane.Desktop.OnlyToplevel = pane.Surface
+			pane.Desktop.OnlyToplevelClient = pane.Client
+		}
+		pane.Desktop.Output = tw.Desktop.Output
+		pane.Desktop.Workspace = tw.Desktop.Workspace
+		pane.Desktop.HideCursorSurfaces = tw.TerminalDrawsPointer() || i != pointerPane
+		pane.Desktop.DrawClients([]*wayland.Client{pane.Client})
+
+		tw.Quality.Apply(pane.DrawState)
+		placed := pane.DrawState.DrawRegion(&sb,
+			pane.Desktop.Buffer,
+			size.Width,
+			size.Height,
+			region,
+			termSize,
+			pane.Desktop.DamageBounds(),
+			full,
+		)
+		layout[i] = TilingPaneLayout{
+			Client: pane.Client,
+			Placed: placed,
+			Width:  int(size.Width),
+			Height: int(size.Height),
+		}
+		nextPanes[pane.Surface] = pane
+	}
+	for surface, pane := range tw.TilingPanes {
+		if _, ok := nextPanes[surface]; !ok {
+			pane.DrawState.Destroy()
+		}
+	}
+	tw.TilingPanes = nextPanes
+	tw.Tiling.SetPanes(layout)
+	tw.LastTilingTermSize = termSize
+	tw.LastTilingHadStatusLine = haveStatusLine
+
+	output := sb.String()
+	startOfWrite := time.Now()
+	os.Stdout.WriteString(output)
+	_ = os.Stdout.Sync()
+	tw.Quality.Update(len(output), time.Since(startOfWrite))
+	if tw.DrawState.Tee != nil {
+		tw.DrawState.Tee.Write([]byte(output))
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package termeverything
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mmulet/term.everything/framebuffertoansi"
+)
+
+func region(row, col, width, height int) framebuffertoansi.CellRegion {
+	return framebuffertoansi.CellRegion{Row: row, Col: col, WidthCells: width, HeightCells: height}
+}
+
+func TestTilingRegions(t *testing.T) {
+	tests := []struct {
+		name             string
+		split            TilingSplit
+		count            int
+		width, height    int
+		statusLineHeight int
+		want             []framebuffertoansi.CellRegion
+	}{
+		{
+			name:  "one pane gets everything",
+			split: TilingSplit_Horizontal, count: 1,
+			width: 80, height: 24,
+			want: []framebuffertoansi.CellRegion{region(1, 1, 80, 24)},
+		},
+		{
+			name:  "status line is left out",
+			split: TilingSplit_Vertical, count: 1,
+			width: 80, height: 24, statusLineHeight: 1,
+			want: []framebuffertoansi.CellRegion{region(2, 1, 80, 23)},
+		},
+		{
+			name:  "side by side",
+			split: TilingSplit_Horizontal, count: 2,
+			width: 81, height: 24,
+			want: []framebuffertoansi.CellRegion{region(1, 1, 40, 24), region(1, 42, 40, 24)},
+		},
+		{
+			name:  "even split",
+			split: TilingSplit_Horizontal, count: 3,
+			width: 80, height: 24,
+			want: []framebuffertoansi.CellRegion{region(1, 1, 26, 24), region(1, 28, 26, 24), region(1, 55, 26, 24)},
+		},
+		{
+			name:  "leftover cells go to the first panes",
+			split: TilingSplit_Horizontal, count: 3,
+			width: 10, height: 5,
+			want: []framebuffertoansi.CellRegion{region(1, 1, 3, 5), region(1, 5, 3, 5), region(1, 9, 2, 5)},
+		},
+		{
+			name:  "stacked under the status line",
+			split: TilingSplit_Vertical, count: 2,
+			width: 80, height: 24, statusLineHeight: 1,
+			want: []framebuffertoansi.CellRegion{region(2, 1, 80, 11), region(14, 1, 80, 11)},
+		},
+		{
+			name:  "just enough room",
+			split: TilingSplit_Horizontal, count: 3,
+			width: 5, height: 1,
+			want: []framebuffertoansi.CellRegion{region(1, 1, 1, 1), region(1, 3, 1, 1), region(1, 5, 1, 1)},
+		},
+		{
+			name:  "too many panes",
+			split: TilingSplit_Horizontal, count: 3,
+			width: 4, height: 24,
+			want: nil,
+		},
+		{
+			name:  "too many panes under the status line",
+			split: TilingSplit_Vertical, count: 2,
+			width: 80, height: 3, statusLineHeight: 1,
+			want: nil,
+		},
+		{
+			name:  "no panes",
+			split: TilingSplit_Horizontal, count: 0,
+			width: 80, height: 24,
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			termSize := framebuffertoansi.TermSize{WidthCells: test.width, HeightCells: test.height}
+			got := TilingRegions(test.split, test.count, termSize, test.statusLineHeight)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTilingRegionsFitTheTerminal(t *testing.T) {
+	for _, split := range []TilingSplit{TilingSplit_Horizontal, TilingSplit_Vertical} {
+		for length := 1; length <= 20; length++ {
+			for count := 1; count <= 8; count++ {
+				termSize := framebuffertoansi.TermSize{WidthCells: length, HeightCells: length + 1}
+				regions := TilingRegions(split, count, termSize, 1)
+				if regions == nil {
+					continue
+				}
+				next := 1
+				for _, r := range regions {
+					start, size := r.Col, r.WidthCells
+					if split == TilingSplit_Vertical {
+						start, size = r.Row-1, r.HeightCells
+					}
+					if start != next || size < 1 {
+						t.Errorf("split %d, length %d, %d panes: %v", split, length, count, regions)
+						break
+					}
+					next = start + size + 1
+				}
+				if next != length+2 {
+					t.Errorf("split %d, length %d, %d panes: %v doesn't fill the terminal", split, length, count, regions)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package termeverything
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+var zoomLevels = []float64{1, 1.5, 2, 3, 4, 6, 8}
+
+/**
+ * The part of the desktop that is drawn to the terminal.
+ * Changed by the input loop (hotkeys, Ctrl+wheel) and
+ * read by the draw loop, so everything goes through Access.
+ */
+type Viewport struct {
+	Access sync.Mutex
+
+	DesktopWidth  int
+	DesktopHeight int
+
+	/**
+	 * Index into zoomLevels
+	 */
+	ZoomLevel int
+	/**
+	 * In desktop pixels
+	 */
+	CenterX float64
+	CenterY float64
+
+	/**
+	 * Only show the focused window (and its popups),
+	 * WindowRect is kept up to date by the draw loop.
+	 */
+	FitWindow  bool
+	WindowRect image.Rectangle
+
+	/**
+	 * The view moved since the draw loop last
+	 * called TakeRect, so everything needs
+	 * to be redrawn.
+	 */
+	Changed bool
+}
+
+func MakeViewport(desktopSize wayland.Size) *Viewport {
+	return &Viewport{
+		DesktopWidth:  int(desktopSize.Width),
+		DesktopHeight: int(desktopSize.Height),
+		CenterX:       float64(desktopSize.Width) / 2,
+		CenterY:       float64(desktopSize.Height) / 2,
+	}
+}
+
+/**
+ * The desktop changed size (another output
+ * is shown), back to the whole thing.
+ */
+func (v *Viewport) SetDesktopSize(desktopSize wayland.Size) {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	v.DesktopWidth = int(desktopSize.Width)
+	v.DesktopHeight = int(desktopSize.Height)
+	v.ZoomLevel = 0
+	v.CenterX = float64(desktopSize.Width) / 2
+	v.CenterY = float64(desktopSize.Height) / 2
+	v.WindowRect = image.Rectangle{}
+	v.Changed = true
+}
+
+func (v *Viewport) Zoom() float64 {
+	return zoomLevels[v.ZoomLevel]
+}
+
+func (v *Viewport) IsZoomed() bool {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	return v.ZoomLevel != 0
+}
+
+/**
+ * What is shown when not zoomed in, the
+ * whole desktop or just the focused window.
+ * Must hold Access.
+ */
+func (v *Viewport) bounds() image.Rectangle {
+	desktop := image.Rect(0, 0, v.DesktopWidth, v.DesktopHeight)
+	if v.FitWindow {
+		if window := v.WindowRect.Intersect(desktop); !window.Empty() {
+			return window
+		}
+	}
+	return desktop
+}
+
+/**
+ * Must hold Access. Keeps the view inside bounds.
+ */
+func (v *Viewport) rect() image.Rectangle {
+	bounds := v.bounds()
+	zoom := v.Zoom()
+	width := max(int(float64(bounds.Dx())/zoom), 1)
+	height := max(int(float64(bounds.Dy())/zoom), 1)
+	x := int(v.CenterX) - width/2
+	y := int(v.CenterY) - height/2
+	x = min(max(x, bounds.Min.X), bounds.Max.X-width)
+	y = min(max(y, bounds.Min.Y), bounds.Max.Y-height)
+	return image.Rect(x, y, x+width, y+height)
+}
+
+/**
+ * Move the center back to the middle
+ * of what is actually shown.
+ */
+func (v *Viewport) clampCenter() {
+	r := v.rect()
+	v.CenterX = float64(r.Min.X) + float64(r.Dx())/2
+	v.CenterY = float64(r.Min.Y) + float64(r.Dy())/2
+}
+
+func (v *Viewport) Rect() image.Rectangle {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	return v.rect()
+}
+
+/**
+ * For the draw loop, also returns if the view
+ * changed since the last call.
+ */
+func (v *Viewport) TakeRect() (image.Rectangle, bool) {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	changed := v.Changed
+	v.Changed = false
+	return v.rect(), changed
+}
+
+func (v *Viewport) HasChanged() bool {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	return v.Changed
+}
+
+/**
+ * steps > 0 zooms in, keeping the desktop
+ * pixel x, y in the same place on screen.
+ */
+func (v *Viewport) ZoomAt(steps int, x, y float64) {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	level := min(max(v.ZoomLevel+steps, 0), len(zoomLevels)-1)
+	if level == v.ZoomLevel {
+		return
+	}
+	ratio := zoomLevels[v.ZoomLevel] / zoomLevels[level]
+	v.CenterX = x + (v.CenterX-x)*ratio
+	v.CenterY = y + (v.CenterY-y)*ratio
+	v.ZoomLevel = level
+	v.clampCenter()
+	v.Changed = true
+}
+
+/**
+ * dx, dy are fractions of the view
+ */
+func (v *Viewport) Pan(dx, dy float64) {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	if v.ZoomLevel == 0 {
+		return
+	}
+	r := v.rect()
+	v.CenterX += dx * float64(r.Dx())
+	v.CenterY += dy * float64(r.Dy())
+	v.clampCenter()
+	if v.rect() != r {
+		v.Changed = true
+	}
+}
+
+func (v *Viewport) Reset() {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	if v.ZoomLevel == 0 {
+		return
+	}
+	v.ZoomLevel = 0
+	v.clampCenter()
+	v.Changed = true
+}
+
+func (v *Viewport) FitsWindow() bool {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	return v.FitWindow
+}
+
+func (v *Viewport) ToggleFitWindow() {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	v.FitWindow = !v.FitWindow
+	v.clampCenter()
+	v.Changed = true
+}
+
+/**
+ * Called by the draw loop every frame,
+ * so the view follows the focused window.
+ */
+func (v *Viewport) SetWindowRect(rect image.Rectangle) {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	if rect == v.WindowRect {
+		return
+	}
+	v.WindowRect = rect
+	if v.FitWindow {
+		v.clampCenter()
+		v.Changed = true
+	}
+}
+
+/**
+ * Terminal cell to desktop pixel
+ */
+func (v *Viewport) ToDesktop(col, row, cols, rows int) (float32, float32) {
+	r := v.Rect()
+	x := float32(r.Min.X) + float32(col)*(float32(r.Dx())/float32(cols))
+	y := float32(r.Min.Y) + float32(row)*(float32(r.Dy())/float32(rows))
+	return x, y
+}
+
+/**
+ * For the status line, empty when not zoomed
+ */
+func (v *Viewport) Describe() string {
+	v.Access.Lock()
+	defer v.Access.Unlock()
+	zoom := ""
+	if v.ZoomLevel != 0 {
+		zoom = fmt.Sprintf("zoom %.0f%%", v.Zoom()*100)
+	}
+	if !v.FitWindow {
+		return zoom
+	}
+	if zoom == "" {
+		return "fit window"
+	}
+	return "fit window, " + zoom
+}
+
+/**
+ * Copy rect out of the desktop into dst
+ * (reused between frames), tightly packed.
+ */
+func CropBGRA(dst []byte, desktop *wayland.Desktop, rect image.Rectangle) []byte {
+	size := rect.Dx() * rect.Dy() * 4
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	rowBytes := rect.Dx() * 4
+	for y := range rect.Dy() {
+		start := (rect.Min.Y+y)*desktop.Stride + rect.Min.X*4
+		copy(dst[y*rowBytes:(y+1)*rowBytes], desktop.Buffer[start:start+rowBytes])
+	}
+	return dst
+}
+
+/**
+ * The fraction of the view one pan moves
+ */
+const viewportPanStep = 0.1
+
+/**
+ * For --zoom-keys and --workspace-keys,
+ * false turns the hotkeys off.
+ */
+func ParseHotkeys(keys string) (bool, error) {
+	switch keys {
+	case "alt":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected alt or off")
+}
+
+/**
+ * Zoom and pan hotkeys:
+ * Alt+= / Alt+- zoom in/out around the pointer,
+ * Alt+0 resets (all three unless --zoom-keys off),
+ * Alt+Shift+arrows pan, Alt+Shift+F shows only the
+ * focused window, Ctrl+wheel zooms, and touching the
+ * edge of the terminal with the pointer pans.
+ * Keys that would do nothing (zooming out or
+ * panning while not zoomed in) go to the app.
+ * Returns true if the code should not be
+ * sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleViewportCode(code XkbdCode) bool {
+	v := tw.Viewport
+	pointerX, pointerY := wayland.Pointer.DesktopPosition()
+	switch c := code.(type) {
+	case *KeyCode:
+		if c.Modifiers&ModAlt == 0 || c.Modifiers&ModControl != 0 {
+			return false
+		}
+		zoomed := v.IsZoomed()
+		if c.Modifiers&ModShift != 0 {
+			switch c.KeyCode {
+			case KEY_LEFT, KEY_RIGHT, KEY_UP, KEY_DOWN:
+				if !zoomed {
+					return false
+				}
+				dx, dy := arrowKeyDirection(c.KeyCode)
+				v.Pan(dx*viewportPanStep, dy*viewportPanStep)
+			case KEY_F:
+				v.ToggleFitWindow()
+			case KEY_EQUAL:
+				// Alt++
+				if !tw.ZoomKeys {
+					return false
+				}
+				v.ZoomAt(1, pointerX, pointerY)
+			default:
+				return false
+			}
+			return true
+		}
+		if !tw.ZoomKeys {
+			return false
+		}
+		switch c.KeyCode {
+		case KEY_EQUAL:
+			v.ZoomAt(1, pointerX, pointerY)
+		case KEY_MINUS:
+			if !zoomed {
+				return false
+			}
+			v.ZoomAt(-1, pointerX, pointerY)
+		case KEY_0:
+			if !zoomed {
+				return false
+			}
+			v.Reset()
+		default:
+			return false
+		}
+		return true
+	case *PointerWheel:
+		if c.Modifiers&ModControl == 0 {
+			return false
+		}
+		steps := 1
+		if tw.ScrollDirection(c.Up) > 0 {
+			steps = -1
+		}
+		v.ZoomAt(steps, pointerX, pointerY)
+		return true
+	case *PointerMove:
+		cols, rows := tw.CurrentTerminalSize()
+		/**
+		 * Leave the status line clickable
+		 */
+		top := 0
+		if tw.Args != nil && !tw.Args.HideStatusBar {
+			top = 1
+		}
+		switch {
+		case c.Col <= 0:
+			v.Pan(-viewportPanStep, 0)
+		case c.Col >= cols-1:
+			v.Pan(viewportPanStep, 0)
+		}
+		switch {
+		case c.Row == top:
+			v.Pan(0, -viewportPanStep)
+		case c.Row >= rows-1:
+			v.Pan(0, viewportPanStep)
+		}
+	}
+	return false
+}
+
+/**
+ * -1, 0 or 1 for x and y
+ */
+func arrowKeyDirection(key Linux_Event_Codes) (float64, float64) {
+	switch key {
+	case KEY_LEFT:
+		return -1, 0
+	case KEY_RIGHT:
+		return 1, 0
+	case KEY_UP:
+		return 0, -1
+	case KEY_DOWN:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+/**
+ * Where the focused toplevel is on the desktop,
+ * without the shadows (its window geometry),
+ * plus any popups it has open.
+ * Empty if there is no window.
+ */
+func (tw *TerminalDrawLoop) FocusedWindowRect() image.Rectangle {
+	client, surface := tw.GetFocusedToplevelClientAndSurface()
+	if surface == nil {
+		return image.Rectangle{}
+	}
+	rect, ok := tw.Desktop.LastSurfaceRects[surface]
+	if !ok {
+		return image.Rectangle{}
+	}
+	if surface.XdgSurfaceState != nil {
+		if xdgSurface := wayland.GetXdgSurfaceObject(client, *surface.XdgSurfaceState); xdgSurface != nil {
+			geometry := xdgSurface.WindowGeometry
+			if geometry.Width > 0 && geometry.Height > 0 {
+				// The geometry is in logical pixels
+				min := rect.Min.Add(image.Pt(
+					wayland.ToDesktopPixels(float64(geometry.X)),
+					wayland.ToDesktopPixels(float64(geometry.Y)),
+				))
+				rect = image.Rectangle{Min: min, Max: min.Add(image.Pt(
+					wayland.ToDesktopPixels(float64(geometry.Width)),
+					wayland.ToDesktopPixels(float64(geometry.Height)),
+				))}
+			}
+		}
+	}
+	for surfaceID := range client.DrawableSurfaces() {
+		other := wayland.GetWlSurfaceObject(client, surfaceID)
+		if other == nil {
+			continue
+		}
+		if popup, ok := other.Role.(*wayland.SurfaceRoleXdgPopup); !ok || !popup.HasData() {
+			continue
+		}
+		if otherRect, ok := tw.Desktop.LastSurfaceRects[other]; ok {
+			rect = rect.Union(otherRect)
+		}
+	}
+	return rect
+}
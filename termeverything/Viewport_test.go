@@ -0,0 +1,65 @@
+package termeverything
+
+import (
+	"testing"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+func TestHandleViewportCodeKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      KeyCode
+		zoomed    bool
+		zoomKeys  bool
+		wantTaken bool
+	}{
+		{"alt+= zooms in", KeyCode{KEY_EQUAL, ModAlt}, false, true, true},
+		{"alt+- while not zoomed", KeyCode{KEY_MINUS, ModAlt}, false, true, false},
+		{"alt+- while zoomed", KeyCode{KEY_MINUS, ModAlt}, true, true, true},
+		{"alt+0 while not zoomed", KeyCode{KEY_0, ModAlt}, false, true, false},
+		{"alt+0 while zoomed", KeyCode{KEY_0, ModAlt}, true, true, true},
+		{"pan while not zoomed", KeyCode{KEY_LEFT, ModAlt | ModShift}, false, true, false},
+		{"pan while zoomed", KeyCode{KEY_LEFT, ModAlt | ModShift}, true, true, true},
+		{"zoom keys off", KeyCode{KEY_EQUAL, ModAlt}, false, false, false},
+		{"alt++ with zoom keys off", KeyCode{KEY_EQUAL, ModAlt | ModShift}, false, false, false},
+		{"alt+0 zoomed with zoom keys off", KeyCode{KEY_0, ModAlt}, true, false, false},
+		{"fit window", KeyCode{KEY_F, ModAlt | ModShift}, false, false, true},
+		{"ctrl+alt+=", KeyCode{KEY_EQUAL, ModAlt | ModControl}, false, true, false},
+		{"no modifiers", KeyCode{KEY_EQUAL, 0}, false, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tw := &TerminalWindow{
+				Viewport: MakeViewport(wayland.Size{Width: 800, Height: 600}),
+				ZoomKeys: test.zoomKeys,
+			}
+			if test.zoomed {
+				tw.Viewport.ZoomAt(1, 400, 300)
+			}
+			code := test.code
+			if got := tw.HandleViewportCode(&code); got != test.wantTaken {
+				t.Errorf("HandleViewportCode = %v, want %v", got, test.wantTaken)
+			}
+		})
+	}
+}
+
+func TestParseHotkeys(t *testing.T) {
+	tests := []struct {
+		keys    string
+		want    bool
+		wantErr bool
+	}{
+		{"alt", true, false},
+		{"off", false, false},
+		{"", false, true},
+		{"ctrl", false, true},
+	}
+	for _, test := range tests {
+		got, err := ParseHotkeys(test.keys)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParseHotkeys(%q) = %v, %v", test.keys, got, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package termeverything
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmulet/term.everything/wayland"
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Alt+1 to Alt+9 show that workspace,
+ * Alt+Shift+1 to Alt+Shift+9 move the
+ * focused window to it (unless --workspace-keys
+ * off). Keys that would do nothing go to the app,
+ * so Alt+1 (ex: first tab in a browser) still
+ * works while on the first workspace.
+ * Returns true if the code should not be
+ * sent on to the clients.
+ */
+func (tw *TerminalWindow) HandleWorkspaceCode(code XkbdCode) bool {
+	c, ok := code.(*KeyCode)
+	if !ok || !tw.WorkspaceKeys || c.Modifiers&ModAlt == 0 || c.Modifiers&ModControl != 0 {
+		return false
+	}
+	workspace := slices.Index(numericKeys[1:], c.KeyCode)
+	if workspace < 0 || workspace >= wayland.WorkspaceCount {
+		return false
+	}
+	if c.Modifiers&ModShift == 0 {
+		if workspace == wayland.CurrentWorkspace() {
+			return false
+		}
+		wayland.SetCurrentWorkspace(workspace)
+		return true
+	}
+	s, toplevelID, ok := tw.FocusedToplevel()
+	if !ok || workspace == wayland.CurrentWorkspace() {
+		return false
+	}
+	wayland.MoveToplevelToWorkspace(s, toplevelID, workspace)
+	return true
+}
+
+/**
+ * The same window GetFocusedToplevel picks, must
+ * hold the clients' locks (see LockConnectedClients).
+ */
+func (tw *TerminalWindow) FocusedToplevel() (*wayland.Client, protocols.ObjectID[protocols.XdgToplevel], bool) {
+	s, topLevelID, top_level := wayland.TopmostToplevel(tw.Clients, (*wayland.XdgToplevel).InView)
+	return s, topLevelID, top_level != nil
+}
+
+/**
+ * Clients with a window on the workspace (and output)
+ * the terminal shows, and clients without any windows
+ * (like rootful Xwayland). Keys and the mouse only go
+ * to these. Must hold the clients' locks.
+ */
+func (tw *TerminalWindow) ShownClients() []*wayland.Client {
+	shown := make([]*wayland.Client, 0, len(tw.Clients))
+	for _, s := range tw.Clients {
+		hasToplevel, inView := false, false
+		for topLevelID := range s.TopLevelSurfaces() {
+			if top_level := wayland.GetXdgToplevelObject(s, topLevelID); top_level != nil {
+				hasToplevel = true
+				inView = inView || top_level.InView()
+			}
+		}
+		if inView || !hasToplevel {
+			shown = append(shown, s)
+		}
+	}
+	return shown
+}
+
+/**
+ * The client keys go to: the focused tiling
+ * pane, or the window FocusedToplevel picks.
+ * Must hold the clients' locks.
+ */
+func (tw *TerminalWindow) FocusedClient() *wayland.Client {
+	if client := tw.FocusedPaneClient(); client != nil {
+		return client
+	}
+	s, _, ok := tw.FocusedToplevel()
+	if !ok {
+		return nil
+	}
+	return s
+}
+
+/**
+ * Switch the desktop to another workspace,
+ * the desktop sees the new draw order
+ * and redraws everything.
+ */
+func (tw *TerminalDrawLoop) ShowWorkspace(index int) {
+	tw.Desktop.Workspace = index
+}
+
+/**
+ * Send the wl_surface.frame callbacks, except for windows
+ * that aren't shown, those wait until they are. So apps
+ * on other workspaces (or outputs) stop drawing.
+ * Returns the number of callbacks sent.
+ */
+func (tw *TerminalDrawLoop) SendFrameCallbacks() int {
+	for s := range tw.HeldFrameDrawRequests {
+		if !slices.Contains(tw.Clients, s) {
+			delete(tw.HeldFrameDrawRequests, s)
+		}
+	}
+	num_draw_requests := 0
+	for _, s := range tw.Clients {
+		requests := tw.HeldFrameDrawRequests[s]
+	drain:
+		for {
+			select {
+			case request := <-s.FrameDrawRequests:
+				requests = append(requests, request)
+			default:
+				break drain
+			}
+		}
+		held := requests[:0]
+		for _, request := range requests {
+			if tw.Desktop.HoldsFrameCallbacks(s, request.Surface) {
+				held = append(held, request)
+				continue
+			}
+			protocols.WlCallback_done(s, request.Callback, uint32(time.Now().UnixMilli()))
+			num_draw_requests++
+		}
+		if len(held) == 0 {
+			delete(tw.HeldFrameDrawRequests, s)
+		} else {
+			tw.HeldFrameDrawRequests[s] = held
+		}
+	}
+	return num_draw_requests
+}
+
+/**
+ * Toplevels that aren't shown get the suspended
+ * state and lose the pointer, the rest get them back.
+ * The keyboard and the activated state follow in
+ * UpdateKeyboardFocus, in the same frame.
+ */
+func (tw *TerminalDrawLoop) SuspendHiddenToplevels() {
+	for _, s := range tw.Clients {
+		for topLevelID := range s.TopLevelSurfaces() {
+			top_level := wayland.GetXdgToplevelObject(s, topLevelID)
+			if top_level == nil {
+				continue
+			}
+			hidden := !tw.Desktop.ShowsToplevel(top_level)
+			if top_level.Suspended != hidden {
+				wayland.SendPointerFocus(s, topLevelID, !hidden)
+			}
+			top_level.SetSuspended(s, topLevelID, hidden)
+		}
+	}
+}
+
+/**
+ * The window on top of the ones shown, or
+ * the focused tiling pane's window.
+ */
+func (tw *TerminalDrawLoop) TopmostFocusableToplevel() (*wayland.Client, protocols.ObjectID[protocols.XdgToplevel], *wayland.XdgToplevel) {
+	clients := tw.Clients
+	if paneClient := tw.Tiling.FocusedPaneClient(); paneClient != nil {
+		clients = []*wayland.Client{paneClient}
+	}
+	return wayland.TopmostToplevel(clients, tw.Desktop.ShowsToplevel)
+}
+
+/**
+ * While the terminal has focus, the keyboard goes
+ * to TopmostFocusableToplevel, which is the only
+ * activated window. Hidden windows lose both.
+ * Nothing has it while the terminal is unfocused.
+ */
+func (tw *TerminalDrawLoop) UpdateKeyboardFocus() {
+	var client *wayland.Client
+	var toplevelID protocols.ObjectID[protocols.XdgToplevel]
+	if tw.TerminalFocused {
+		client, toplevelID, _ = tw.TopmostFocusableToplevel()
+	}
+	wayland.SetKeyboardFocus(tw.Clients, client, toplevelID)
+}
+
+/**
+ * For the status line, ex: "workspace 2 [1 2 4]" with
+ * the workspaces that have windows in brackets. Empty
+ * while everything is on the first workspace.
+ */
+func (tw *TerminalDrawLoop) WorkspaceStatus() string {
+	used := make([]bool, wayland.WorkspaceCount)
+	used[tw.Desktop.Workspace] = true
+	for _, s := range tw.Clients {
+		for topLevelID := range s.TopLevelSurfaces() {
+			if top_level := wayland.GetXdgToplevelObject(s, topLevelID); top_level != nil && top_level.Output == tw.Desktop.Output {
+				used[top_level.Workspace] = true
+			}
+		}
+	}
+	numbers := make([]string, 0, wayland.WorkspaceCount)
+	for i, u := range used {
+		if u {
+			numbers = append(numbers, fmt.Sprint(i+1))
+		}
+	}
+	if len(numbers) == 1 && tw.Desktop.Workspace == 0 {
+		return ""
+	}
+	return fmt.Sprintf("workspace %d [%s]", tw.Desktop.Workspace+1, strings.Join(numbers, " "))
+}
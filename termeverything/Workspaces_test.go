@@ -0,0 +1,39 @@
+package termeverything
+
+import (
+	"testing"
+
+	"github.com/mmulet/term.everything/wayland"
+)
+
+func TestHandleWorkspaceCodeKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          KeyCode
+		workspaceKeys bool
+		wantTaken     bool
+		wantShown     int
+	}{
+		{"alt+2 shows workspace 2", KeyCode{KEY_2, ModAlt}, true, true, 1},
+		{"alt+1 on the first workspace", KeyCode{KEY_1, ModAlt}, true, false, 0},
+		{"workspace keys off", KeyCode{KEY_2, ModAlt}, false, false, 0},
+		{"alt+shift+2 without a window", KeyCode{KEY_2, ModAlt | ModShift}, true, false, 0},
+		{"ctrl+alt+2", KeyCode{KEY_2, ModAlt | ModControl}, true, false, 0},
+		{"alt+0 isn't a workspace", KeyCode{KEY_0, ModAlt}, true, false, 0},
+		{"2 without alt", KeyCode{KEY_2, 0}, true, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wayland.SetCurrentWorkspace(0)
+			defer wayland.SetCurrentWorkspace(0)
+			tw := &TerminalWindow{WorkspaceKeys: test.workspaceKeys}
+			code := test.code
+			if got := tw.HandleWorkspaceCode(&code); got != test.wantTaken {
+				t.Errorf("HandleWorkspaceCode = %v, want %v", got, test.wantTaken)
+			}
+			if got := wayland.CurrentWorkspace(); got != test.wantShown {
+				t.Errorf("CurrentWorkspace = %d, want %d", got, test.wantShown)
+			}
+		})
+	}
+}
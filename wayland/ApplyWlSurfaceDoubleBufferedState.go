@@ -43,10 +43,15 @@ func ApplyWlSurfaceDoubleBufferedState(
 		surface.BufferTransform = *update.BufferTransform
 	}
 
-	if update.Damage != nil || update.DamageBuffer != nil {
-		surface.Damaged = true
-	} else {
-		surface.Damaged = false
+	if update.Viewport != nil {
+		surface.Viewport = *update.Viewport
+	}
+
+	for _, damage := range update.DamageBuffer {
+		surface.BufferDamage = AddDamage(surface.BufferDamage, damage.Rectangle())
+	}
+	for _, damage := range update.Damage {
+		surface.BufferDamage = AddDamage(surface.BufferDamage, surface.SurfaceDamageToBuffer(damage))
 	}
 
 	// offset: add to current offset (doc semantics)
@@ -85,10 +90,7 @@ func ApplyWlSurfaceDoubleBufferedState(
 	}
 
 	if update.OpaqueRegion != nil {
-		if surface.OpaqueRegion != nil && !AreSame(surface.OpaqueRegion, update.OpaqueRegion) {
-			RemoveObject(s, *surface.OpaqueRegion)
-		}
-		surface.OpaqueRegion = update.OpaqueRegion
+		surface.OpaqueRegion = *update.OpaqueRegion
 	}
 
 	if update.AddSubSurface != nil {
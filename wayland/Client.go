@@ -43,17 +43,51 @@ type Client struct {
 
 	RolesToSurfaces map[protocols.AnyObjectID]protocols.ObjectID[protocols.WlSurface]
 
-	FrameDrawRequests chan protocols.ObjectID[protocols.WlCallback]
+	FrameDrawRequests chan FrameDrawRequest
+
+	/**
+	 * Toplevels that asked for attention
+	 * with xdg_activation_v1.activate
+	 */
+	ActivationRequests chan protocols.ObjectID[protocols.XdgToplevel]
 
 	GlobalBinds map[protocols.GlobalID]any
 
 	LastGetMessageTime time.Time
 
+	/**
+	 * Objects created by the server (like wl_data_offer)
+	 * take their ids from the server side range
+	 * 0xff000000 - 0xffffffff
+	 */
+	nextServerObjectID uint32
+
 	Access sync.Mutex
 }
 
-func (c *Client) AddFrameDrawRequest(cb protocols.ObjectID[protocols.WlCallback]) {
-	c.FrameDrawRequests <- cb
+/**
+ * A wl_surface.frame callback, with the surface
+ * so the callbacks of windows that aren't shown
+ * can wait until they are.
+ */
+type FrameDrawRequest struct {
+	Surface  protocols.ObjectID[protocols.WlSurface]
+	Callback protocols.ObjectID[protocols.WlCallback]
+}
+
+func (c *Client) AddFrameDrawRequest(surface protocols.ObjectID[protocols.WlSurface], cb protocols.ObjectID[protocols.WlCallback]) {
+	c.FrameDrawRequests <- FrameDrawRequest{Surface: surface, Callback: cb}
+}
+
+func (c *Client) AddActivationRequest(toplevel protocols.ObjectID[protocols.XdgToplevel]) {
+	select {
+	case c.ActivationRequests <- toplevel:
+	default:
+		/**
+		 * Nobody is reading fast enough, the
+		 * user already has plenty of notifications.
+		 */
+	}
 }
 
 func (c *Client) GetSurfaceIDFromRole(roleObjectID protocols.AnyObjectID) *protocols.ObjectID[protocols.WlSurface] {
@@ -198,6 +232,19 @@ func (c *Client) GetGlobalObjectByID(globalID uint32) any {
 		return Global_WlTouch
 	case uint32(protocols.GlobalID_ZxdgDecorationManagerV1):
 		return Global_ZxdgDecorationManagerV1
+	case uint32(protocols.GlobalID_WpCursorShapeManagerV1):
+		return Global_WpCursorShapeManagerV1
+	case uint32(protocols.GlobalID_XdgActivationV1):
+		return Global_XdgActivationV1
+	case uint32(protocols.GlobalID_ZxdgOutputManagerV1):
+		return Global_ZxdgOutputManagerV1
+	case uint32(protocols.GlobalID_WpViewporter):
+		return Global_WpViewporter
+	case uint32(protocols.GlobalID_WpFractionalScaleManagerV1):
+		return Global_WpFractionalScaleManagerV1
+	}
+	if VirtualOutputByGlobalID(protocols.GlobalID(globalID)) != nil {
+		return Global_WlOutput
 	}
 	return nil
 }
@@ -221,8 +268,11 @@ func MakeClient(conn *net.UnixConn) *Client {
 		drawableSurfaces: make(map[protocols.ObjectID[protocols.WlSurface]]bool),
 		topLevelSurfaces: make(map[protocols.ObjectID[protocols.XdgToplevel]]bool),
 
-		GlobalBinds:       make(map[protocols.GlobalID]any),
-		FrameDrawRequests: make(chan protocols.ObjectID[protocols.WlCallback], 1024),
+		GlobalBinds:        make(map[protocols.GlobalID]any),
+		FrameDrawRequests:  make(chan FrameDrawRequest, 1024),
+		ActivationRequests: make(chan protocols.ObjectID[protocols.XdgToplevel], 32),
+
+		nextServerObjectID: 0xff000000,
 	}
 }
 
@@ -369,6 +419,12 @@ func (c *Client) ClaimFileDescriptor() *protocols.FileDescriptor {
 	return &fd
 }
 
+func (c *Client) NextServerObjectID() protocols.AnyObjectID {
+	id := c.nextServerObjectID
+	c.nextServerObjectID++
+	return protocols.AnyObjectID(id)
+}
+
 func (c *Client) SetCompositorVersion(v uint32) { c.CompositorVersion = v }
 func (c *Client) GetCompositorVersion() uint32  { return c.CompositorVersion }
 
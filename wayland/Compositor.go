@@ -0,0 +1,187 @@
+package wayland
+
+import (
+	"image"
+	"runtime"
+	"sync"
+)
+
+/**
+ * The desktop, textures and wl_shm argb8888 buffers
+ * all hold premultiplied B, G, R, A bytes
+ * (see ShmFormats). These functions only work
+ * on that format, so they don't need to care
+ * about the byte order: the alpha is always the
+ * 4th byte and the color channels are
+ * blended the same way.
+ */
+
+/**
+ * v / 255, rounded, for v up to 255 * 255
+ */
+func div255(v uint32) uint32 {
+	v += 128
+	return (v + v>>8) >> 8
+}
+
+/**
+ * Porter-Duff over for premultiplied pixels:
+ * dst = src + dst * (255 - src alpha) / 255
+ * Opaque pixels are copied and fully transparent
+ * ones are skipped.
+ */
+func blendRowOver(dst, src []byte) {
+	for i := 0; i+4 <= len(src) && i+4 <= len(dst); i += 4 {
+		s := src[i : i+4 : i+4]
+		switch s[3] {
+		case 0xff:
+			copy(dst[i:i+4], s)
+			continue
+		case 0:
+			if s[0]|s[1]|s[2] == 0 {
+				continue
+			}
+		}
+		d := dst[i : i+4 : i+4]
+		inv := 255 - uint32(s[3])
+		/**
+		 * Clamped for buffers with colors brighter
+		 * than their alpha, which aren't really
+		 * premultiplied.
+		 */
+		d[0] = uint8(min(uint32(s[0])+div255(uint32(d[0])*inv), 255))
+		d[1] = uint8(min(uint32(s[1])+div255(uint32(d[1])*inv), 255))
+		d[2] = uint8(min(uint32(s[2])+div255(uint32(d[2])*inv), 255))
+		d[3] = uint8(min(uint32(s[3])+div255(uint32(d[3])*inv), 255))
+	}
+}
+
+/**
+ * Row y of img, from x = r.Min.X to r.Max.X.
+ * r must be inside img.
+ */
+func pixelRow(img *image.RGBA, r image.Rectangle, y int) []byte {
+	start := img.PixOffset(r.Min.X, y)
+	return img.Pix[start : start+r.Dx()*4]
+}
+
+/**
+ * Blend r of src over dst, sp is where r.Min
+ * is in src (like draw.Draw).
+ */
+func CompositeOver(dst *image.RGBA, r image.Rectangle, src *image.RGBA, sp image.Point) {
+	r, sp = clipComposite(dst, r, src, sp)
+	if r.Empty() {
+		return
+	}
+	srcRect := r.Add(sp.Sub(r.Min))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		blendRowOver(pixelRow(dst, r, y), pixelRow(src, srcRect, y-r.Min.Y+sp.Y))
+	}
+}
+
+/**
+ * Replace r of dst with src, for the
+ * parts of surfaces that are opaque.
+ */
+func CompositeCopy(dst *image.RGBA, r image.Rectangle, src *image.RGBA, sp image.Point) {
+	r, sp = clipComposite(dst, r, src, sp)
+	if r.Empty() {
+		return
+	}
+	srcRect := r.Add(sp.Sub(r.Min))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		copy(pixelRow(dst, r, y), pixelRow(src, srcRect, y-r.Min.Y+sp.Y))
+	}
+}
+
+/**
+ * Shrink r (and move sp to match) so
+ * it is inside both dst and src.
+ */
+func clipComposite(dst *image.RGBA, r image.Rectangle, src *image.RGBA, sp image.Point) (image.Rectangle, image.Point) {
+	orig := r.Min
+	r = r.Intersect(dst.Rect)
+	r = r.Intersect(src.Rect.Add(orig.Sub(sp)))
+	return r, sp.Add(r.Min.Sub(orig))
+}
+
+/**
+ * Below this many pixels it's faster
+ * to draw on one goroutine.
+ */
+const parallelCompositePixels = 64 * 1024
+
+/**
+ * Split r into bands of rows and call draw on
+ * each at the same time. The bands don't
+ * overlap, so draw can write to its band
+ * of the desktop without locking.
+ */
+func ParallelRows(r image.Rectangle, draw func(band image.Rectangle)) {
+	bands := min(runtime.GOMAXPROCS(0), r.Dy(), r.Dx()*r.Dy()/parallelCompositePixels)
+	if bands <= 1 {
+		draw(r)
+		return
+	}
+	var wg sync.WaitGroup
+	for i := range bands {
+		band := image.Rect(
+			r.Min.X, r.Min.Y+r.Dy()*i/bands,
+			r.Max.X, r.Min.Y+r.Dy()*(i+1)/bands,
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			draw(band)
+		}()
+	}
+	wg.Wait()
+}
+
+/**
+ * A surface's opaque region (logical, relative
+ * to the surface) in desktop pixels, rounded
+ * inward so the edges, which may be blended
+ * with their neighbours, aren't counted.
+ * pad shrinks it further, for surfaces that are
+ * resampled and pick up some of the
+ * pixels around the region.
+ */
+func OpaqueDesktopRects(region []image.Rectangle, x, y int, rect image.Rectangle, pad int) []image.Rectangle {
+	var out []image.Rectangle
+	for _, o := range region {
+		/**
+		 * Not image.Rect, which would swap the
+		 * corners of a region thinner than pad
+		 */
+		r := image.Rectangle{
+			Min: image.Pt(
+				ceilDesktopPixels(float64(x)+float64(o.Min.X))+pad,
+				ceilDesktopPixels(float64(y)+float64(o.Min.Y))+pad,
+			),
+			Max: image.Pt(
+				floorDesktopPixels(float64(x)+float64(o.Max.X))-pad,
+				floorDesktopPixels(float64(y)+float64(o.Max.Y))-pad,
+			),
+		}.Intersect(rect)
+		if !r.Empty() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+/**
+ * rects cover all of r
+ */
+func RectsCover(rects []image.Rectangle, r image.Rectangle) bool {
+	rest := []image.Rectangle{r}
+	for _, o := range rects {
+		rest = subtractRects(rest, o)
+		if len(rest) == 0 {
+			return true
+		}
+	}
+	return len(rest) == 0
+}
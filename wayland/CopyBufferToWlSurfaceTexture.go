@@ -2,6 +2,7 @@ package wayland
 
 import (
 	"fmt"
+	"image"
 
 	"github.com/mmulet/term.everything/wayland/protocols"
 )
@@ -110,26 +111,11 @@ func CopyBufferToWlSurfaceTexture(
 	surface.Position.Y = y
 	surface.Position.Z = int32(zIndex)
 
-	if surface.Texture != nil {
-		if surface.Texture.Stride != uint32(bufferInfo.Stride) ||
-			surface.Texture.Width != uint32(bufferInfo.Width) ||
-			surface.Texture.Height != uint32(bufferInfo.Height) {
-			surface.Texture = nil
-		}
-	}
-
-	if surface.Texture == nil {
-		size := int(bufferInfo.Stride) * int(bufferInfo.Height)
-		if size < 0 {
-			fmt.Println("Invalid buffer size; can't commit")
-			return
-		}
-		surface.Texture = &Texture{
-			Stride: uint32(bufferInfo.Stride),
-			Width:  uint32(bufferInfo.Width),
-			Height: uint32(bufferInfo.Height),
-			Data:   make([]byte, size),
-		}
+	transform := surface.BufferTransform
+	width, height := TransformedSize(transform, int(bufferInfo.Width), int(bufferInfo.Height))
+	if width <= 0 || height <= 0 {
+		fmt.Println("Invalid buffer size; can't commit")
+		return
 	}
 
 	memMap, ok := pool.MemMaps[pool.WlShmPoolObjectID]
@@ -138,8 +124,15 @@ func CopyBufferToWlSurfaceTexture(
 		return
 	}
 
-	total := int(bufferInfo.Stride) * int(bufferInfo.Height)
-	if total < 0 || total > len(surface.Texture.Data) {
+	format, ok := GetShmFormat(bufferInfo.Format)
+	if !ok {
+		fmt.Println("Unsupported buffer format; can't commit")
+		return
+	}
+
+	stride := int(bufferInfo.Stride)
+	total := stride * int(bufferInfo.Height)
+	if stride < int(bufferInfo.Width)*format.BytesPerPixel || total < 0 {
 		fmt.Println("Computed copy size out of bounds; can't commit")
 		return
 	}
@@ -153,7 +146,80 @@ func CopyBufferToWlSurfaceTexture(
 
 	}
 
-	copy(surface.Texture.Data, src[offset:offset+total])
+	/**
+	 * Only now that the buffer is known to be good,
+	 * otherwise a failed commit would leave an empty
+	 * texture that later damage is copied into.
+	 */
+	if surface.Texture != nil {
+		if surface.Texture.Width != uint32(width) ||
+			surface.Texture.Height != uint32(height) ||
+			surface.Texture.Transform != transform {
+			surface.Texture = nil
+		}
+	}
+
+	fullCopy := surface.Texture == nil
+	if surface.Texture == nil {
+		surface.Texture = &Texture{
+			Stride:    uint32(width * 4),
+			Width:     uint32(width),
+			Height:    uint32(height),
+			Data:      make([]byte, width*height*4),
+			Transform: transform,
+		}
+	}
+
+	bounds := image.Rect(0, 0, int(bufferInfo.Width), int(bufferInfo.Height))
+	damage := ClipDamage(surface.BufferDamage, bounds)
+	surface.BufferDamage = nil
+
+	/**
+	 * Otherwise the texture already has the last
+	 * buffer's content, and damage is relative to
+	 * that, so only copy what changed.
+	 */
+	if fullCopy {
+		damage = []image.Rectangle{bounds}
+	}
+	buffer := src[offset : offset+total]
+	for _, d := range damage {
+		surface.TextureDamage = AddDamage(surface.TextureDamage, surface.Texture.CopyFromBuffer(buffer, stride, bufferInfo.Format, d))
+	}
 
 	s.DrawableSurfaces()[surfaceID] = true
 }
+
+/**
+ * Copy the damaged part d of the buffer (in buffer
+ * coordinates) into the texture, converting it from
+ * format and undoing the transform.
+ * Returns where it landed.
+ */
+func (t *Texture) CopyFromBuffer(buffer []byte, stride int, formatID protocols.WlShmFormat_enum, d image.Rectangle) image.Rectangle {
+	width, height := int(t.Width), int(t.Height)
+	surfaceRect := BufferRectToSurface(t.Transform, width, height, d)
+	textureStride := int(t.Stride)
+	format, ok := GetShmFormat(formatID)
+	if !ok {
+		return image.Rectangle{}
+	}
+	bpp := format.BytesPerPixel
+	if t.Transform == protocols.WlOutputTransform_enum_normal && formatID == protocols.WlShmFormat_enum_argb8888 {
+		for y := d.Min.Y; y < d.Max.Y; y++ {
+			copy(
+				t.Data[y*textureStride+d.Min.X*4:y*textureStride+d.Max.X*4],
+				buffer[y*stride+d.Min.X*4:y*stride+d.Max.X*4],
+			)
+		}
+		return surfaceRect
+	}
+	for y := surfaceRect.Min.Y; y < surfaceRect.Max.Y; y++ {
+		row := t.Data[y*textureStride:]
+		for x := surfaceRect.Min.X; x < surfaceRect.Max.X; x++ {
+			bx, by := SurfacePixelToBuffer(t.Transform, width, height, x, y)
+			format.Convert(row[x*4:x*4+4], buffer[by*stride+bx*bpp:])
+		}
+	}
+	return surfaceRect
+}
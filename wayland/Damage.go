@@ -0,0 +1,104 @@
+package wayland
+
+import (
+	"image"
+	"math"
+)
+
+/**
+ * Past this many rectangles, damage is collapsed
+ * into its bounding box. Lots of tiny rectangles
+ * cost more to walk than they save.
+ */
+const maxDamageRects = 32
+
+func (r Rect) Rectangle() image.Rectangle {
+	return image.Rect(
+		int(r.X),
+		int(r.Y),
+		int(r.X)+int(r.Width),
+		int(r.Y)+int(r.Height),
+	)
+}
+
+/**
+ * Surface coordinates to buffer coordinates,
+ * before the buffer transform.
+ */
+func (r Rect) ScaledRectangle(scale int32) image.Rectangle {
+	if scale <= 1 {
+		return r.Rectangle()
+	}
+	s := int(scale)
+	return image.Rect(
+		int(r.X)*s,
+		int(r.Y)*s,
+		(int(r.X)+int(r.Width))*s,
+		(int(r.Y)+int(r.Height))*s,
+	)
+}
+
+/**
+ * Surface coordinates to buffer coordinates, through
+ * the wp_viewport when there is one (rounded out
+ * so nothing is missed), then the buffer transform.
+ */
+func (w *WlSurface) SurfaceDamageToBuffer(r Rect) image.Rectangle {
+	if w.Texture == nil {
+		/**
+		 * The whole buffer is copied anyway
+		 */
+		return r.ScaledRectangle(w.BufferScale)
+	}
+	textureRect := r.ScaledRectangle(w.BufferScale)
+	if w.Viewport.Source != nil || w.Viewport.Destination != nil {
+		source := w.SourceRect()
+		size := w.LogicalSize()
+		if size.Width == 0 || size.Height == 0 {
+			return image.Rectangle{}
+		}
+		sx := source.Width / float64(size.Width)
+		sy := source.Height / float64(size.Height)
+		textureRect = image.Rect(
+			int(math.Floor(source.X+float64(r.X)*sx)),
+			int(math.Floor(source.Y+float64(r.Y)*sy)),
+			int(math.Ceil(source.X+float64(r.X+r.Width)*sx)),
+			int(math.Ceil(source.Y+float64(r.Y+r.Height)*sy)),
+		)
+	}
+	return SurfaceRectToBuffer(w.Texture.Transform, int(w.Texture.Width), int(w.Texture.Height), textureRect)
+}
+
+func AddDamage(damage []image.Rectangle, r image.Rectangle) []image.Rectangle {
+	if r.Empty() {
+		return damage
+	}
+	for _, d := range damage {
+		if r.In(d) {
+			return damage
+		}
+	}
+	damage = append(damage, r)
+	if len(damage) > maxDamageRects {
+		return []image.Rectangle{DamageBounds(damage)}
+	}
+	return damage
+}
+
+func ClipDamage(damage []image.Rectangle, bounds image.Rectangle) []image.Rectangle {
+	out := damage[:0]
+	for _, d := range damage {
+		if d = d.Intersect(bounds); !d.Empty() {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func DamageBounds(damage []image.Rectangle) image.Rectangle {
+	var bounds image.Rectangle
+	for _, d := range damage {
+		bounds = bounds.Union(d)
+	}
+	return bounds
+}
@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/draw"
 	_ "image/png"
+	"slices"
 	"sort"
 	"time"
 
@@ -20,10 +21,52 @@ type Desktop struct {
 	Buffer []byte
 	RGBA   *image.RGBA
 
-	IconImg *image.NRGBA
+	IconImg *image.RGBA
 
 	CreatedAt                 time.Time
 	WillShowAppRightAtStartup bool
+
+	/**
+	 * When the terminal draws the pointer itself
+	 * (see OSC 22), don't draw cursor surfaces
+	 * into the desktop.
+	 */
+	HideCursorSurfaces bool
+
+	/**
+	 * Only draw this toplevel, with its subsurfaces,
+	 * popups and the client's cursor. nil draws
+	 * every client. Used for tiling panes.
+	 */
+	OnlyToplevel       *WlSurface
+	OnlyToplevelClient *Client
+
+	/**
+	 * Index into VirtualOutputs, only windows
+	 * on this output are drawn.
+	 */
+	Output int
+
+	/**
+	 * 0 based, only windows on this
+	 * workspace are drawn.
+	 */
+	Workspace int
+
+	/**
+	 * Where each surface was drawn last frame, and in
+	 * what order, so we know what to redraw when
+	 * surfaces move, resize, restack or go away.
+	 */
+	LastSurfaceRects map[*WlSurface]image.Rectangle
+	LastDrawOrder    []*WlSurface
+	ShowingIcon      bool
+
+	/**
+	 * The parts of the desktop that changed in
+	 * the last call to DrawClients.
+	 */
+	Damage []image.Rectangle
 }
 
 func MakeDesktop(size Size, willShowAppRightAtStartup bool, iconPNG []byte) *Desktop {
@@ -44,16 +87,20 @@ func MakeDesktop(size Size, willShowAppRightAtStartup bool, iconPNG []byte) *Des
 		CreatedAt:                 time.Now(),
 		WillShowAppRightAtStartup: willShowAppRightAtStartup,
 	}
-	cd.IconImg = RgbaToBgra(DecodeIconToNRGBA(iconPNG))
+	cd.IconImg = ToPremultipliedBgra(DecodeIconToNRGBA(iconPNG))
 	return cd
 }
 
-func RgbaToBgra(src *image.NRGBA) *image.NRGBA {
+/**
+ * To the desktop's format, premultiplied
+ * B, G, R, A (see Compositor.go)
+ */
+func ToPremultipliedBgra(src *image.NRGBA) *image.RGBA {
 	if src == nil {
 		return nil
 	}
 	b := src.Bounds()
-	dst := image.NewNRGBA(b)
+	dst := image.NewRGBA(b)
 	for y := b.Min.Y; y < b.Max.Y; y++ {
 		for x := b.Min.X; x < b.Max.X; x++ {
 			off := src.PixOffset(x, y)
@@ -63,9 +110,9 @@ func RgbaToBgra(src *image.NRGBA) *image.NRGBA {
 			a := src.Pix[off+3]
 
 			dstOff := dst.PixOffset(x, y)
-			dst.Pix[dstOff+0] = bb
-			dst.Pix[dstOff+1] = g
-			dst.Pix[dstOff+2] = r
+			dst.Pix[dstOff+0] = uint8(div255(uint32(bb) * uint32(a)))
+			dst.Pix[dstOff+1] = uint8(div255(uint32(g) * uint32(a)))
+			dst.Pix[dstOff+2] = uint8(div255(uint32(r) * uint32(a)))
 			dst.Pix[dstOff+3] = a
 		}
 	}
@@ -90,13 +137,13 @@ func DecodeIconToNRGBA(data []byte) *image.NRGBA {
 	return rgba
 }
 
-func (cd *Desktop) DrawImage(src image.Image, dx, dy int) {
+func (cd *Desktop) DrawImage(src *image.RGBA, dx, dy int) {
 	if src == nil {
 		return
 	}
 	sb := src.Bounds()
 	r := image.Rect(dx, dy, dx+sb.Dx(), dy+sb.Dy())
-	draw.Draw(cd.RGBA, r, src, sb.Min, draw.Over)
+	CompositeOver(cd.RGBA, r, src, sb.Min)
 }
 
 /*
@@ -118,10 +165,72 @@ func (cd *Desktop) Clear() {
 	clear(cd.Buffer)
 }
 
+func (cd *Desktop) ClearRect(r image.Rectangle) {
+	r = r.Intersect(cd.RGBA.Rect)
+	if r.Empty() {
+		return
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		start := y*cd.Stride + r.Min.X*4
+		clear(cd.Buffer[start : start+r.Dx()*4])
+	}
+}
+
+/**
+ * The toplevel is on this desktop's
+ * output and workspace.
+ */
+func (cd *Desktop) ShowsToplevel(toplevel *XdgToplevel) bool {
+	return toplevel.Output == cd.Output && toplevel.Workspace == cd.Workspace
+}
+
+/**
+ * Cursors are shown wherever the pointer is.
+ * Other surfaces that aren't part of a toplevel
+ * (like rootful Xwayland) are on every
+ * workspace of the first output.
+ */
+func (cd *Desktop) ShowsSurface(s *Client, surfaceID protocols.ObjectID[protocols.WlSurface]) bool {
+	toplevel := ToplevelOfSurface(s, surfaceID)
+	if toplevel == nil {
+		if surface := GetWlSurfaceObject(s, surfaceID); surface != nil {
+			if _, isCursor := surface.Role.(*SurfaceRoleCursor); isCursor {
+				return true
+			}
+		}
+		return cd.Output == 0
+	}
+	return cd.ShowsToplevel(toplevel)
+}
+
+/**
+ * The surface is part of a window this desktop
+ * doesn't show, so its frame callbacks wait.
+ * Surfaces without a toplevel (cursors, surfaces
+ * without a role yet, subsurfaces of those) never
+ * wait, the client could be waiting on them
+ * to draw its first frame.
+ */
+func (cd *Desktop) HoldsFrameCallbacks(s *Client, surfaceID protocols.ObjectID[protocols.WlSurface]) bool {
+	toplevel := ToplevelOfSurface(s, surfaceID)
+	return toplevel != nil && !cd.ShowsToplevel(toplevel)
+}
+
 type SortedSurfaceEntry struct {
 	Surface   *WlSurface
 	Src       *image.RGBA
 	SurfaceID protocols.ObjectID[protocols.WlSurface]
+	Stacking  uint64
+	/**
+	 * TextureDamage, cropped and scaled like Src,
+	 * relative to Src.Rect.Min
+	 */
+	Damage []image.Rectangle
+	/**
+	 * The surface's opaque region on the
+	 * desktop, see OpaqueDesktopRects
+	 */
+	Opaque []image.Rectangle
 }
 
 type SortedSurfaceEntryParentLocation struct {
@@ -139,11 +248,24 @@ func (cd *Desktop) DrawClients(clients []*Client) {
 		if c == nil {
 			continue
 		}
+		if cd.OnlyToplevel != nil && c != cd.OnlyToplevelClient {
+			continue
+		}
 		for surface_id := range c.DrawableSurfaces() {
 			surface := GetWlSurfaceObject(c, surface_id)
 			if surface == nil {
 				continue
 			}
+			_, isCursor := surface.Role.(*SurfaceRoleCursor)
+			if cd.HideCursorSurfaces && isCursor {
+				continue
+			}
+			if cd.OnlyToplevel != nil && !isCursor && ToplevelSurfaceOf(c, surface_id) != cd.OnlyToplevel {
+				continue
+			}
+			if !isCursor && !cd.ShowsSurface(c, surface_id) {
+				continue
+			}
 			tex := surface.Texture.AsRGBA()
 			if tex == nil {
 				continue
@@ -164,11 +286,15 @@ func (cd *Desktop) DrawClients(clients []*Client) {
 				Surface:   surface,
 				Src:       tex,
 				SurfaceID: surface_id,
+				Stacking:  StackingOfSurface(c, surface_id, surface),
 			})
 		}
 	}
 
 	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Stacking != sorted[j].Stacking {
+			return sorted[i].Stacking < sorted[j].Stacking
+		}
 		zi := sorted[i].Surface.Position.Z
 		zj := sorted[j].Surface.Position.Z
 		if zi == zj {
@@ -177,14 +303,11 @@ func (cd *Desktop) DrawClients(clients []*Client) {
 		return zi < zj
 	})
 
-	cd.Clear()
-
-	if len(sorted) == 0 && cd.AfterOpeningTimeout() {
-		cd.DrawImage(cd.IconImg, 0, 0)
-		return
-	}
-
-	for _, it := range sorted {
+	bounds := cd.RGBA.Rect
+	rects := make(map[*WlSurface]image.Rectangle, len(sorted))
+	order := make([]*WlSurface, 0, len(sorted))
+	for i := range sorted {
+		it := &sorted[i]
 		/**
 		 * Recursively get the position by adding
 		 * all ancestor position
@@ -197,6 +320,146 @@ func (cd *Desktop) DrawClients(clients []*Client) {
 			y += parent.y
 			parent, ok = childToParent[parent.parentID]
 		}
-		cd.DrawImage(it.Src, x, y)
+		/**
+		 * Positions and sizes are logical,
+		 * the desktop is in OutputScale pixels.
+		 */
+		size := it.Surface.LogicalSize()
+		rect := image.Rect(
+			ToDesktopPixels(float64(x)),
+			ToDesktopPixels(float64(y)),
+			ToDesktopPixels(float64(x)+float64(size.Width)),
+			ToDesktopPixels(float64(y)+float64(size.Height)),
+		)
+		source := it.Surface.SourceRect()
+		sourceRect := source.Rectangle()
+		it.Opaque = OpaqueDesktopRects(it.Surface.OpaqueRegion, x, y, rect, 0)
+		switch {
+		case rect.Empty() || !sourceRect.In(it.Src.Rect):
+			/**
+			 * A wp_viewport source outside the
+			 * buffer is an error, draw nothing.
+			 */
+			rect = image.Rectangle{Min: rect.Min}
+			it.Damage = nil
+			it.Opaque = nil
+		case source.IsWholePixels() && rect.Size() == sourceRect.Size():
+			it.Surface.ScaledTexture = nil
+			it.Src = it.Src.SubImage(sourceRect).(*image.RGBA)
+			it.Damage = nil
+			for _, d := range it.Surface.TextureDamage {
+				it.Damage = AddDamage(it.Damage, d.Intersect(sourceRect).Sub(sourceRect.Min))
+			}
+		default:
+			it.Src, it.Damage = it.Surface.Scaled(it.Src, source, rect.Size())
+			it.Opaque = OpaqueDesktopRects(it.Surface.OpaqueRegion, x, y, rect, 1)
+		}
+		rects[it.Surface] = rect
+		order = append(order, it.Surface)
+	}
+
+	showIcon := len(sorted) == 0 && cd.AfterOpeningTimeout()
+
+	var damage []image.Rectangle
+	if cd.LastSurfaceRects == nil ||
+		showIcon != cd.ShowingIcon ||
+		!slices.Equal(order, cd.LastDrawOrder) {
+		damage = []image.Rectangle{bounds}
+	} else {
+		for _, it := range sorted {
+			rect := rects[it.Surface]
+			if last, ok := cd.LastSurfaceRects[it.Surface]; !ok || last != rect {
+				damage = AddDamage(damage, last)
+				damage = AddDamage(damage, rect)
+				continue
+			}
+			for _, d := range it.Damage {
+				damage = AddDamage(damage, d.Add(rect.Min))
+			}
+		}
+		for surface, last := range cd.LastSurfaceRects {
+			if _, ok := rects[surface]; !ok {
+				damage = AddDamage(damage, last)
+			}
+		}
+	}
+	for _, it := range sorted {
+		it.Surface.TextureDamage = nil
+	}
+
+	cd.LastSurfaceRects = rects
+	cd.LastDrawOrder = order
+	cd.ShowingIcon = showIcon
+	cd.Damage = ClipDamage(damage, bounds)
+
+	if showIcon {
+		if len(cd.Damage) > 0 {
+			cd.Clear()
+			cd.DrawImage(cd.IconImg, 0, 0)
+		}
+		return
 	}
+
+	/**
+	 * Only redraw what changed, everything
+	 * else is still in the buffer from
+	 * last frame. Damage rects can overlap,
+	 * so only the rows of one are drawn
+	 * at the same time.
+	 */
+	for _, d := range cd.Damage {
+		ParallelRows(d, func(band image.Rectangle) {
+			cd.drawBand(sorted, rects, band)
+		})
+	}
+}
+
+/**
+ * Draw the surfaces, bottom to top, into band.
+ * Everything under a surface that is opaque over
+ * all of band is hidden, so start from there
+ * (and don't clear). Opaque parts of a surface
+ * are copied, the rest is blended.
+ */
+func (cd *Desktop) drawBand(sorted []SortedSurfaceEntry, rects map[*WlSurface]image.Rectangle, band image.Rectangle) {
+	first := -1
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if RectsCover(sorted[i].Opaque, band) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		cd.ClearRect(band)
+		first = 0
+	}
+	for _, it := range sorted[first:] {
+		rect := rects[it.Surface]
+		dst := rect.Intersect(band)
+		if dst.Empty() {
+			continue
+		}
+		srcPoint := func(r image.Rectangle) image.Point {
+			return r.Min.Sub(rect.Min).Add(it.Src.Rect.Min)
+		}
+		blend := []image.Rectangle{dst}
+		for _, o := range it.Opaque {
+			if o = o.Intersect(dst); o.Empty() {
+				continue
+			}
+			CompositeCopy(cd.RGBA, o, it.Src, srcPoint(o))
+			blend = subtractRects(blend, o)
+		}
+		for _, b := range blend {
+			CompositeOver(cd.RGBA, b, it.Src, srcPoint(b))
+		}
+	}
+}
+
+/**
+ * Bounding box of what changed in
+ * the last call to DrawClients.
+ */
+func (cd *Desktop) DamageBounds() image.Rectangle {
+	return DamageBounds(cd.Damage)
 }
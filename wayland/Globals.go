@@ -21,3 +21,13 @@ var Global_WlDataDevice = Global_WlSeat
 var Global_WlTouch = MakeWlTouch()
 
 var Global_ZxdgDecorationManagerV1 = MakeZxdgDecorationManagerV1()
+
+var Global_WpCursorShapeManagerV1 = MakeWpCursorShapeManagerV1()
+
+var Global_XdgActivationV1 = MakeXdgActivationV1()
+
+var Global_ZxdgOutputManagerV1 = MakeZxdgOutputManagerV1()
+
+var Global_WpViewporter = MakeWpViewporter()
+
+var Global_WpFractionalScaleManagerV1 = MakeWpFractionalScaleManagerV1()
@@ -77,6 +77,27 @@ func SendPointerAxis(clients []*Client, axis protocols.WlPointerAxis_enum, value
 	}
 }
 
+/**
+ * wl_pointer.enter (or leave) on the toplevel's
+ * surface, for windows the terminal starts
+ * (or stops) showing.
+ */
+func SendPointerFocus(client *Client, toplevelID protocols.ObjectID[protocols.XdgToplevel], entered bool) {
+	surfaceID := GetSurfaceIDFromRole(client, toplevelID)
+	if surfaceID == nil || client.Status != ClientStatus_Connected {
+		return
+	}
+	ser := getNextSerial()
+	for pointerID, version := range protocols.GetGlobalWlPointerBinds(client) {
+		if entered {
+			protocols.WlPointer_enter(client, pointerID, ser, *surfaceID, Pointer.WindowX, Pointer.WindowY)
+		} else {
+			protocols.WlPointer_leave(client, pointerID, ser, *surfaceID)
+		}
+		protocols.WlPointer_frame(client, uint32(version), pointerID)
+	}
+}
+
 func SendKeyboardKey(clients []*Client, key uint32, pressed bool) {
 	timestamp := uint32(time.Now().UnixMilli())
 	ser := getNextSerial()
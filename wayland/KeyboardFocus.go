@@ -0,0 +1,70 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * There is one seat, so at most one surface
+ * has the keyboard. Only changed by
+ * SetKeyboardFocus, which holds every
+ * client's lock.
+ */
+var keyboardFocus struct {
+	client   *Client
+	toplevel protocols.ObjectID[protocols.XdgToplevel]
+	surface  protocols.ObjectID[protocols.WlSurface]
+}
+
+/**
+ * Move the keyboard to the toplevel's surface (a nil
+ * client for none), sending wl_keyboard.leave to the
+ * surface that had it first. Only the focused toplevel
+ * is activated. Must hold the lock of every client.
+ */
+func SetKeyboardFocus(clients []*Client, client *Client, toplevelID protocols.ObjectID[protocols.XdgToplevel]) {
+	var surfaceID protocols.ObjectID[protocols.WlSurface]
+	if client != nil {
+		if id := GetSurfaceIDFromRole(client, toplevelID); id != nil {
+			surfaceID = *id
+		} else {
+			client = nil
+		}
+	}
+	if client != keyboardFocus.client || surfaceID != keyboardFocus.surface {
+		ser := getNextSerial()
+		if old := keyboardFocus.client; old != nil &&
+			old.Status == ClientStatus_Connected &&
+			GetWlSurfaceObject(old, keyboardFocus.surface) != nil {
+			for keyboardID := range protocols.GetGlobalWlKeyboardBinds(old) {
+				protocols.WlKeyboard_leave(old, keyboardID, ser, keyboardFocus.surface)
+			}
+		}
+		if client != nil {
+			for keyboardID := range protocols.GetGlobalWlKeyboardBinds(client) {
+				protocols.WlKeyboard_enter(client, keyboardID, ser, surfaceID, []byte{})
+			}
+		}
+		keyboardFocus.client = client
+		keyboardFocus.toplevel = toplevelID
+		keyboardFocus.surface = surfaceID
+	}
+	for _, s := range clients {
+		for id := range s.TopLevelSurfaces() {
+			if toplevel := GetXdgToplevelObject(s, id); toplevel != nil {
+				toplevel.SetActivated(s, id, s == client && id == toplevelID)
+			}
+		}
+	}
+}
+
+/**
+ * The toplevel is going away, don't send
+ * wl_keyboard.leave to its surface later
+ * (the id could be used again).
+ */
+func forgetKeyboardFocus(s protocols.ClientState, toplevelID protocols.ObjectID[protocols.XdgToplevel]) {
+	if keyboardFocus.client != nil && s == protocols.ClientState(keyboardFocus.client) && keyboardFocus.toplevel == toplevelID {
+		keyboardFocus.client = nil
+	}
+}
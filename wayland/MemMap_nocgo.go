@@ -0,0 +1,49 @@
+//go:build !cgo
+
+package wayland
+
+import (
+	"fmt"
+	"log"
+	"syscall"
+)
+
+/**
+ * Same as MemMap.go, for builds without cgo.
+ */
+type MemMapInfo struct {
+	Bytes          []byte
+	Size           uint64
+	FileDescriptor int
+	UnMapped       bool
+}
+
+func NewMemMapInfo(fd int, size uint64) (MemMapInfo, error) {
+	bytes, err := syscall.Mmap(fd, 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return MemMapInfo{
+			Size:           size,
+			FileDescriptor: fd,
+			UnMapped:       true,
+		}, fmt.Errorf("failed to mmap fd %d: %w", fd, err)
+	}
+
+	return MemMapInfo{
+		Bytes:          bytes,
+		Size:           size,
+		FileDescriptor: fd,
+		UnMapped:       false,
+	}, nil
+}
+
+func (m *MemMapInfo) Unmap() {
+	if m.UnMapped {
+		return
+	}
+
+	if err := syscall.Munmap(m.Bytes); err != nil {
+		log.Printf("munmap in unmap: %v", err)
+	}
+	m.UnMapped = true
+	m.Bytes = nil
+}
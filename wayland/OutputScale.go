@@ -0,0 +1,105 @@
+package wayland
+
+import (
+	"math"
+)
+
+/**
+ * Desktop pixels per logical (surface) pixel.
+ * Outputs keep their size in desktop pixels,
+ * windows are told the logical size and are
+ * scaled up (or down) when they are drawn.
+ * Set once at startup, see SetOutputScale.
+ */
+var OutputScale float64 = 1
+
+/**
+ * Call once at startup, before SetVirtualOutputs
+ */
+func SetOutputScale(scale float64) {
+	if scale <= 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		return
+	}
+	OutputScale = scale
+}
+
+/**
+ * For wl_output.scale and wl_surface.preferred_buffer_scale,
+ * rounded up so apps without fractional scaling
+ * draw too many pixels instead of too few.
+ */
+func IntegerOutputScale() int32 {
+	return int32(max(math.Ceil(OutputScale), 1))
+}
+
+/**
+ * wp_fractional_scale_v1 sends the
+ * scale times 120.
+ */
+func FractionalScale120() uint32 {
+	return uint32(max(math.Round(OutputScale*120), 1))
+}
+
+/**
+ * Desktop pixels to logical pixels
+ */
+func LogicalSizeOf(size Size) Size {
+	return Size{
+		Width:  max(uint32(math.Round(float64(size.Width)/OutputScale)), 1),
+		Height: max(uint32(math.Round(float64(size.Height)/OutputScale)), 1),
+	}
+}
+
+/**
+ * Logical pixels to desktop pixels
+ */
+func DesktopSizeOf(size Size) Size {
+	return Size{
+		Width:  max(uint32(math.Round(float64(size.Width)*OutputScale)), 1),
+		Height: max(uint32(math.Round(float64(size.Height)*OutputScale)), 1),
+	}
+}
+
+/**
+ * A logical coordinate to a desktop pixel,
+ * rounded so neighbouring surfaces
+ * still line up.
+ */
+func ToDesktopPixels(v float64) int {
+	return int(math.Round(v * OutputScale))
+}
+
+/**
+ * ToDesktopPixels, but rounded down
+ * or up instead of to the nearest.
+ */
+func floorDesktopPixels(v float64) int {
+	return int(math.Floor(v * OutputScale))
+}
+
+func ceilDesktopPixels(v float64) int {
+	return int(math.Ceil(v * OutputScale))
+}
+
+/**
+ * The size windows on this output are told about
+ */
+func (o *VirtualOutput) LogicalSize() Size {
+	return LogicalSizeOf(o.DesktopSize())
+}
+
+/**
+ * A position on the desktop (ex: the terminal's
+ * pointer) to logical pixels, which is what
+ * SendPointerMotion takes.
+ */
+func LogicalPosition(x, y float32) (float32, float32) {
+	return x / float32(OutputScale), y / float32(OutputScale)
+}
+
+/**
+ * The pointer in desktop pixels
+ */
+func (p *WlPointer) DesktopPosition() (float64, float64) {
+	return float64(p.WindowX) * OutputScale, float64(p.WindowY) * OutputScale
+}
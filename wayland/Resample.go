@@ -0,0 +1,153 @@
+package wayland
+
+import (
+	"image"
+	"math"
+)
+
+type resampleTap struct {
+	index  int
+	weight float32
+}
+
+/**
+ * For each destination pixel along one axis, the
+ * source pixels it is made of, for the part of the
+ * source from srcStart to srcStart+srcLen (which
+ * can be fractional, see wp_viewport). Shrinking
+ * averages everything the pixel covers (a box
+ * filter), so thin lines and text don't drop out.
+ * Growing blends the two nearest (bilinear).
+ */
+func resampleTaps(srcStart, srcLen float64, dstLen int) [][]resampleTap {
+	taps := make([][]resampleTap, dstLen)
+	ratio := srcLen / float64(dstLen)
+	/**
+	 * Don't blend in pixels from outside the crop
+	 */
+	first := int(math.Floor(srcStart))
+	last := max(int(math.Ceil(srcStart+srcLen))-1, first)
+	for i := range taps {
+		if ratio > 1 {
+			start := srcStart + float64(i)*ratio
+			end := start + ratio
+			for j := int(math.Floor(start)); j <= last && float64(j) < end; j++ {
+				overlap := min(end, float64(j+1)) - max(start, float64(j))
+				if overlap > 0 {
+					taps[i] = append(taps[i], resampleTap{index: j, weight: float32(overlap / ratio)})
+				}
+			}
+			continue
+		}
+		center := srcStart + (float64(i)+0.5)*ratio - 0.5
+		j := math.Floor(center)
+		frac := float32(center - j)
+		taps[i] = []resampleTap{
+			{index: min(max(int(j), first), last), weight: 1 - frac},
+			{index: min(max(int(j)+1, first), last), weight: frac},
+		}
+	}
+	return taps
+}
+
+/**
+ * A surface's texture at the size it is drawn on
+ * the desktop (see OutputScale and wp_viewport).
+ * Kept between frames so only damage is resampled.
+ */
+type ScaledTexture struct {
+	RGBA *image.RGBA
+
+	/**
+	 * The part of the texture that is scaled,
+	 * see WlSurface.SourceRect
+	 */
+	Source ViewportSource
+	xTaps  [][]resampleTap
+	yTaps  [][]resampleTap
+}
+
+func MakeScaledTexture(source ViewportSource, size image.Point) *ScaledTexture {
+	return &ScaledTexture{
+		RGBA:   image.NewRGBA(image.Rectangle{Max: size}),
+		Source: source,
+		xTaps:  resampleTaps(source.X, source.Width, size.X),
+		yTaps:  resampleTaps(source.Y, source.Height, size.Y),
+	}
+}
+
+func (t *ScaledTexture) Fits(source ViewportSource, size image.Point) bool {
+	return t != nil && t.Source == source && t.RGBA.Rect.Size() == size
+}
+
+/**
+ * Where damage to the texture lands in the scaled
+ * texture, padded by how far a filter reaches.
+ */
+func (t *ScaledTexture) ScaleDamage(d image.Rectangle) image.Rectangle {
+	size := t.RGBA.Rect.Size()
+	sx := float64(size.X) / t.Source.Width
+	sy := float64(size.Y) / t.Source.Height
+	padX := int(math.Ceil(sx)) + 1
+	padY := int(math.Ceil(sy)) + 1
+	return image.Rect(
+		int(math.Floor((float64(d.Min.X)-t.Source.X)*sx))-padX,
+		int(math.Floor((float64(d.Min.Y)-t.Source.Y)*sy))-padY,
+		int(math.Ceil((float64(d.Max.X)-t.Source.X)*sx))+padX,
+		int(math.Ceil((float64(d.Max.Y)-t.Source.Y)*sy))+padY,
+	).Intersect(t.RGBA.Rect)
+}
+
+/**
+ * Resample area (in scaled coordinates) from src,
+ * the whole texture. Channels are filtered
+ * independently, which is right for premultiplied
+ * alpha whatever the byte order.
+ */
+func (t *ScaledTexture) Update(src *image.RGBA, area image.Rectangle) {
+	area = area.Intersect(t.RGBA.Rect)
+	dst := t.RGBA
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		row := dst.Pix[y*dst.Stride:]
+		for x := area.Min.X; x < area.Max.X; x++ {
+			var b, g, r, a float32
+			for _, ty := range t.yTaps[y] {
+				srcRow := src.Pix[ty.index*src.Stride:]
+				for _, tx := range t.xTaps[x] {
+					w := ty.weight * tx.weight
+					p := srcRow[tx.index*4 : tx.index*4+4]
+					b += w * float32(p[0])
+					g += w * float32(p[1])
+					r += w * float32(p[2])
+					a += w * float32(p[3])
+				}
+			}
+			p := row[x*4 : x*4+4]
+			p[0] = uint8(min(b+0.5, 255))
+			p[1] = uint8(min(g+0.5, 255))
+			p[2] = uint8(min(r+0.5, 255))
+			p[3] = uint8(min(a+0.5, 255))
+		}
+	}
+}
+
+/**
+ * The source part of the texture resampled to size,
+ * updating the ScaledTexture with the TextureDamage
+ * (or all of it if the source or size changed).
+ * Also returns where the damage landed.
+ */
+func (w *WlSurface) Scaled(tex *image.RGBA, source ViewportSource, size image.Point) (*image.RGBA, []image.Rectangle) {
+	if !w.ScaledTexture.Fits(source, size) {
+		w.ScaledTexture = MakeScaledTexture(source, size)
+		w.ScaledTexture.Update(tex, w.ScaledTexture.RGBA.Rect)
+		return w.ScaledTexture.RGBA, []image.Rectangle{w.ScaledTexture.RGBA.Rect}
+	}
+	var damage []image.Rectangle
+	for _, d := range w.TextureDamage {
+		scaled := w.ScaledTexture.ScaleDamage(d)
+		w.ScaledTexture.Update(tex, scaled)
+		damage = AddDamage(damage, scaled)
+	}
+	return w.ScaledTexture.RGBA, damage
+}
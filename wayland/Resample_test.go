@@ -0,0 +1,100 @@
+package wayland
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+func describeTaps(taps [][]resampleTap) []string {
+	out := make([]string, len(taps))
+	for i, pixel := range taps {
+		for _, tap := range pixel {
+			out[i] += fmt.Sprintf("%d:%.3g ", tap.index, tap.weight)
+		}
+	}
+	return out
+}
+
+func TestResampleTaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		srcStart float64
+		srcLen   float64
+		dstLen   int
+		want     []string
+	}{
+		{
+			name:   "same size copies",
+			srcLen: 3, dstLen: 3,
+			want: []string{"0:1 1:0 ", "1:1 2:0 ", "2:1 2:0 "},
+		},
+		{
+			name:   "half size averages pairs",
+			srcLen: 4, dstLen: 2,
+			want: []string{"0:0.5 1:0.5 ", "2:0.5 3:0.5 "},
+		},
+		{
+			name:   "uneven shrink splits the shared pixel",
+			srcLen: 3, dstLen: 2,
+			want: []string{"0:0.667 1:0.333 ", "1:0.333 2:0.667 "},
+		},
+		{
+			name:   "everything to one pixel",
+			srcLen: 4, dstLen: 1,
+			want: []string{"0:0.25 1:0.25 2:0.25 3:0.25 "},
+		},
+		{
+			name:     "fractional crop when shrinking",
+			srcStart: 0.5, srcLen: 3, dstLen: 1,
+			want: []string{"0:0.167 1:0.333 2:0.333 3:0.167 "},
+		},
+		{
+			name:   "double size blends neighbours",
+			srcLen: 2, dstLen: 4,
+			want: []string{"0:0.25 0:0.75 ", "0:0.75 1:0.25 ", "0:0.25 1:0.75 ", "1:0.75 1:0.25 "},
+		},
+		{
+			name:     "growing stays inside the crop",
+			srcStart: 2, srcLen: 1, dstLen: 2,
+			want: []string{"2:0.25 2:0.75 ", "2:0.75 2:0.25 "},
+		},
+		{
+			name:   "nothing to draw",
+			srcLen: 4, dstLen: 0,
+			want: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := describeTaps(resampleTaps(test.srcStart, test.srcLen, test.dstLen))
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResampleTapsWeightsAndBounds(t *testing.T) {
+	for _, srcStart := range []float64{0, 0.25, 1, 7.5} {
+		for _, srcLen := range []float64{1, 2.5, 3, 10, 33.3} {
+			for dstLen := 1; dstLen <= 40; dstLen++ {
+				first := int(math.Floor(srcStart))
+				last := int(math.Ceil(srcStart+srcLen)) - 1
+				for i, pixel := range resampleTaps(srcStart, srcLen, dstLen) {
+					var sum float64
+					for _, tap := range pixel {
+						if tap.index < first || tap.index > last || tap.weight < 0 {
+							t.Fatalf("%v+%v to %d, pixel %d: bad tap %+v", srcStart, srcLen, dstLen, i, tap)
+						}
+						sum += float64(tap.weight)
+					}
+					if math.Abs(sum-1) > 1e-4 {
+						t.Fatalf("%v+%v to %d, pixel %d: weights add up to %v", srcStart, srcLen, dstLen, i, sum)
+					}
+				}
+			}
+		}
+	}
+}
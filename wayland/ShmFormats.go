@@ -0,0 +1,95 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Converts one pixel from a wl_shm format into
+ * the desktop's format, which is argb8888
+ * (premultiplied B, G, R, A bytes).
+ */
+type ShmPixelConverter func(dst, src []byte)
+
+type ShmFormat struct {
+	BytesPerPixel int
+	Convert       ShmPixelConverter
+}
+
+/**
+ * Advertised in this order, argb8888 and
+ * xrgb8888 are required by the protocol.
+ * The formats are little endian, so argb8888
+ * is B, G, R, A in memory.
+ */
+var ShmFormats = []protocols.WlShmFormat_enum{
+	protocols.WlShmFormat_enum_argb8888,
+	protocols.WlShmFormat_enum_xrgb8888,
+	protocols.WlShmFormat_enum_abgr8888,
+	protocols.WlShmFormat_enum_xbgr8888,
+	protocols.WlShmFormat_enum_rgb565,
+	protocols.WlShmFormat_enum_rgb888,
+	protocols.WlShmFormat_enum_bgr888,
+	protocols.WlShmFormat_enum_argb2101010,
+	protocols.WlShmFormat_enum_xrgb2101010,
+	protocols.WlShmFormat_enum_abgr2101010,
+	protocols.WlShmFormat_enum_xbgr2101010,
+}
+
+var shmFormats = map[protocols.WlShmFormat_enum]ShmFormat{
+	protocols.WlShmFormat_enum_argb8888: {4, func(dst, src []byte) {
+		copy(dst[:4], src[:4])
+	}},
+	protocols.WlShmFormat_enum_xrgb8888: {4, func(dst, src []byte) {
+		dst[0], dst[1], dst[2], dst[3] = src[0], src[1], src[2], 0xff
+	}},
+	protocols.WlShmFormat_enum_abgr8888: {4, func(dst, src []byte) {
+		dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], src[3]
+	}},
+	protocols.WlShmFormat_enum_xbgr8888: {4, func(dst, src []byte) {
+		dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], 0xff
+	}},
+	protocols.WlShmFormat_enum_rgb565: {2, func(dst, src []byte) {
+		v := uint16(src[0]) | uint16(src[1])<<8
+		r := byte(v >> 11)
+		g := byte(v>>5) & 0x3f
+		b := byte(v) & 0x1f
+		dst[0], dst[1], dst[2], dst[3] = b<<3|b>>2, g<<2|g>>4, r<<3|r>>2, 0xff
+	}},
+	protocols.WlShmFormat_enum_rgb888: {3, func(dst, src []byte) {
+		dst[0], dst[1], dst[2], dst[3] = src[0], src[1], src[2], 0xff
+	}},
+	protocols.WlShmFormat_enum_bgr888: {3, func(dst, src []byte) {
+		dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], 0xff
+	}},
+	protocols.WlShmFormat_enum_argb2101010: {4, func(dst, src []byte) {
+		r, g, b, a := unpack2101010(src)
+		dst[0], dst[1], dst[2], dst[3] = b, g, r, a
+	}},
+	protocols.WlShmFormat_enum_xrgb2101010: {4, func(dst, src []byte) {
+		r, g, b, _ := unpack2101010(src)
+		dst[0], dst[1], dst[2], dst[3] = b, g, r, 0xff
+	}},
+	protocols.WlShmFormat_enum_abgr2101010: {4, func(dst, src []byte) {
+		b, g, r, a := unpack2101010(src)
+		dst[0], dst[1], dst[2], dst[3] = b, g, r, a
+	}},
+	protocols.WlShmFormat_enum_xbgr2101010: {4, func(dst, src []byte) {
+		b, g, r, _ := unpack2101010(src)
+		dst[0], dst[1], dst[2], dst[3] = b, g, r, 0xff
+	}},
+}
+
+/**
+ * The three 10 bit channels (highest first) and the
+ * 2 bit alpha of a 2101010 pixel, cut down to 8 bits.
+ */
+func unpack2101010(src []byte) (byte, byte, byte, byte) {
+	v := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16 | uint32(src[3])<<24
+	return byte(v >> 22), byte(v >> 12), byte(v >> 2), byte(v>>30) * 0x55
+}
+
+func GetShmFormat(format protocols.WlShmFormat_enum) (ShmFormat, bool) {
+	f, ok := shmFormats[format]
+	return f, ok
+}
@@ -0,0 +1,72 @@
+package wayland
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+func TestShmFormatConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		format protocols.WlShmFormat_enum
+		src    []byte
+		/**
+		 * B, G, R, A
+		 */
+		want []byte
+	}{
+		{"argb8888", protocols.WlShmFormat_enum_argb8888, []byte{0x10, 0x20, 0x30, 0x40}, []byte{0x10, 0x20, 0x30, 0x40}},
+		{"argb8888 transparent", protocols.WlShmFormat_enum_argb8888, []byte{0, 0, 0, 0}, []byte{0, 0, 0, 0}},
+		{"xrgb8888 ignores x", protocols.WlShmFormat_enum_xrgb8888, []byte{0x10, 0x20, 0x30, 0x00}, []byte{0x10, 0x20, 0x30, 0xff}},
+		{"abgr8888", protocols.WlShmFormat_enum_abgr8888, []byte{0x30, 0x20, 0x10, 0x40}, []byte{0x10, 0x20, 0x30, 0x40}},
+		{"xbgr8888 ignores x", protocols.WlShmFormat_enum_xbgr8888, []byte{0x30, 0x20, 0x10, 0x7f}, []byte{0x10, 0x20, 0x30, 0xff}},
+		{"rgb565 white", protocols.WlShmFormat_enum_rgb565, []byte{0xff, 0xff}, []byte{0xff, 0xff, 0xff, 0xff}},
+		{"rgb565 black", protocols.WlShmFormat_enum_rgb565, []byte{0x00, 0x00}, []byte{0x00, 0x00, 0x00, 0xff}},
+		{"rgb565 red", protocols.WlShmFormat_enum_rgb565, []byte{0x00, 0xf8}, []byte{0x00, 0x00, 0xff, 0xff}},
+		{"rgb565 green", protocols.WlShmFormat_enum_rgb565, []byte{0xe0, 0x07}, []byte{0x00, 0xff, 0x00, 0xff}},
+		{"rgb565 blue", protocols.WlShmFormat_enum_rgb565, []byte{0x1f, 0x00}, []byte{0xff, 0x00, 0x00, 0xff}},
+		{"rgb565 middle", protocols.WlShmFormat_enum_rgb565, []byte{0x10, 0x84}, []byte{0x84, 0x82, 0x84, 0xff}},
+		{"rgb888", protocols.WlShmFormat_enum_rgb888, []byte{0x10, 0x20, 0x30}, []byte{0x10, 0x20, 0x30, 0xff}},
+		{"bgr888", protocols.WlShmFormat_enum_bgr888, []byte{0x30, 0x20, 0x10}, []byte{0x10, 0x20, 0x30, 0xff}},
+		{"argb2101010 white", protocols.WlShmFormat_enum_argb2101010, []byte{0xff, 0xff, 0xff, 0xff}, []byte{0xff, 0xff, 0xff, 0xff}},
+		/**
+		 * A=1, R=0x3ff, G=0x200, B=0x004
+		 */
+		{"argb2101010", protocols.WlShmFormat_enum_argb2101010, []byte{0x04, 0x00, 0xf8, 0x7f}, []byte{0x01, 0x80, 0xff, 0x55}},
+		{"xrgb2101010 ignores x", protocols.WlShmFormat_enum_xrgb2101010, []byte{0x04, 0x00, 0xf8, 0x3f}, []byte{0x01, 0x80, 0xff, 0xff}},
+		/**
+		 * A=2, B=0x3ff, G=0x200, R=0x004
+		 */
+		{"abgr2101010", protocols.WlShmFormat_enum_abgr2101010, []byte{0x04, 0x00, 0xf8, 0xbf}, []byte{0xff, 0x80, 0x01, 0xaa}},
+		{"xbgr2101010 ignores x", protocols.WlShmFormat_enum_xbgr2101010, []byte{0x04, 0x00, 0xf8, 0x3f}, []byte{0xff, 0x80, 0x01, 0xff}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			format, ok := GetShmFormat(test.format)
+			if !ok {
+				t.Fatalf("no converter")
+			}
+			if len(test.src) != format.BytesPerPixel {
+				t.Fatalf("%d bytes per pixel, test has %d", format.BytesPerPixel, len(test.src))
+			}
+			dst := make([]byte, 4)
+			format.Convert(dst, test.src)
+			if !slices.Equal(dst, test.want) {
+				t.Errorf("got % x, want % x", dst, test.want)
+			}
+		})
+	}
+}
+
+func TestShmFormatsCanBeConverted(t *testing.T) {
+	for _, format := range ShmFormats {
+		if f, ok := GetShmFormat(format); !ok || f.Convert == nil || f.BytesPerPixel < 2 {
+			t.Errorf("advertised format %d has no converter", format)
+		}
+	}
+	if _, ok := GetShmFormat(protocols.WlShmFormat_enum_nv12); ok {
+		t.Errorf("nv12 is not a packed format")
+	}
+}
@@ -0,0 +1,66 @@
+package wayland
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+var lastStacking atomic.Uint64
+
+/**
+ * Higher is drawn on top, every new or
+ * raised toplevel gets the next one.
+ */
+func NextStacking() uint64 {
+	return lastStacking.Add(1)
+}
+
+/**
+ * Draw the toplevel above every other
+ * window, it becomes the focused one.
+ */
+func RaiseToplevel(s protocols.ClientState, toplevelID protocols.ObjectID[protocols.XdgToplevel]) {
+	if toplevel := GetXdgToplevelObject(s, toplevelID); toplevel != nil {
+		toplevel.Stacking = NextStacking()
+	}
+}
+
+/**
+ * The toplevel drawn on top of the ones
+ * shown returns true for, nil if none.
+ */
+func TopmostToplevel(clients []*Client, shown func(*XdgToplevel) bool) (*Client, protocols.ObjectID[protocols.XdgToplevel], *XdgToplevel) {
+	var topClient *Client
+	var topID protocols.ObjectID[protocols.XdgToplevel]
+	var top *XdgToplevel
+	for _, s := range clients {
+		for toplevelID := range s.TopLevelSurfaces() {
+			toplevel := GetXdgToplevelObject(s, toplevelID)
+			if toplevel == nil || !shown(toplevel) {
+				continue
+			}
+			if top == nil || toplevel.Stacking > top.Stacking {
+				topClient, topID, top = s, toplevelID, toplevel
+			}
+		}
+	}
+	return topClient, topID, top
+}
+
+/**
+ * The stacking of the window a surface is part of.
+ * Cursors are above every window, and surfaces
+ * without a toplevel (like rootful Xwayland)
+ * below them.
+ */
+func StackingOfSurface(s *Client, surfaceID protocols.ObjectID[protocols.WlSurface], surface *WlSurface) uint64 {
+	if _, isCursor := surface.Role.(*SurfaceRoleCursor); isCursor {
+		return math.MaxUint64
+	}
+	if toplevel := ToplevelOfSurface(s, surfaceID); toplevel != nil {
+		return toplevel.Stacking
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package wayland
+
+import "github.com/mmulet/term.everything/wayland/protocols"
+
+/**
+ * Deeper than any real app nests
+ * subsurfaces and popups, in case
+ * a client made a loop.
+ */
+const maxSurfaceTreeDepth = 64
+
+/**
+ * The toplevel surface that surfaceID belongs to,
+ * following subsurfaces up to their parents and
+ * popups up to the surface they were opened from.
+ * nil for cursors, and anything not under a toplevel.
+ */
+func ToplevelSurfaceOf(s *Client, surfaceID protocols.ObjectID[protocols.WlSurface]) *WlSurface {
+	for range maxSurfaceTreeDepth {
+		surface := GetWlSurfaceObject(s, surfaceID)
+		if surface == nil {
+			return nil
+		}
+		switch role := surface.Role.(type) {
+		case *SurfaceRoleXdgToplevel:
+			if role.Data == nil {
+				return nil
+			}
+			return surface
+		case *SurfaceRoleSubSurface:
+			if role.Data == nil {
+				return nil
+			}
+			subsurface := GetWlSubsurfaceObject(s, *role.Data)
+			if subsurface == nil {
+				return nil
+			}
+			surfaceID = subsurface.Parent
+		case *SurfaceRoleXdgPopup:
+			if role.Data == nil {
+				return nil
+			}
+			popup := GetXdgPopupObject(s, *role.Data)
+			if popup == nil || popup.Parent == nil {
+				return nil
+			}
+			parentID := GetSurfaceIDFromRole(s, *popup.Parent)
+			if parentID == nil {
+				return nil
+			}
+			surfaceID = *parentID
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+/**
+ * The xdg_toplevel of ToplevelSurfaceOf,
+ * nil if there is none.
+ */
+func ToplevelOfSurface(s *Client, surfaceID protocols.ObjectID[protocols.WlSurface]) *XdgToplevel {
+	toplevelSurface := ToplevelSurfaceOf(s, surfaceID)
+	if toplevelSurface == nil {
+		return nil
+	}
+	role, ok := toplevelSurface.Role.(*SurfaceRoleXdgToplevel)
+	if !ok || role.Data == nil {
+		return nil
+	}
+	return GetXdgToplevelObject(s, *role.Data)
+}
@@ -1,6 +1,10 @@
 package wayland
 
-import "github.com/mmulet/term.everything/wayland/protocols"
+import (
+	"image"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
 
 type SurfaceUpdate struct {
 	Offset *Point
@@ -13,9 +17,14 @@ type SurfaceUpdate struct {
 
 	BufferTransform *protocols.WlOutputTransform_enum
 
+	Viewport *SurfaceViewport
+
 	InputRegion *protocols.ObjectID[protocols.WlRegion]
 
-	OpaqueRegion *protocols.ObjectID[protocols.WlRegion]
+	/**
+	 * A copy of the region, empty to unset it
+	 */
+	OpaqueRegion *[]image.Rectangle
 
 	Buffer *protocols.ObjectID[protocols.WlBuffer]
 
@@ -0,0 +1,152 @@
+package wayland
+
+import (
+	"image"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * 90 and 270 (flipped or not) turn
+ * the image on its side.
+ */
+func TransformSwapsSize(transform protocols.WlOutputTransform_enum) bool {
+	switch transform {
+	case protocols.WlOutputTransform_enum__90,
+		protocols.WlOutputTransform_enum__270,
+		protocols.WlOutputTransform_enum_flipped_90,
+		protocols.WlOutputTransform_enum_flipped_270:
+		return true
+	}
+	return false
+}
+
+/**
+ * The size of a buffer once the
+ * transform is undone.
+ */
+func TransformedSize(transform protocols.WlOutputTransform_enum, width, height int) (int, int) {
+	if TransformSwapsSize(transform) {
+		return height, width
+	}
+	return width, height
+}
+
+/**
+ * Which buffer pixel is shown at (x, y) of the
+ * surface (width x height, after TransformedSize).
+ * Same as weston_transformed_coord, but for
+ * pixels instead of points.
+ */
+func SurfacePixelToBuffer(transform protocols.WlOutputTransform_enum, width, height, x, y int) (int, int) {
+	switch transform {
+	case protocols.WlOutputTransform_enum__90:
+		return y, width - 1 - x
+	case protocols.WlOutputTransform_enum__180:
+		return width - 1 - x, height - 1 - y
+	case protocols.WlOutputTransform_enum__270:
+		return height - 1 - y, x
+	case protocols.WlOutputTransform_enum_flipped:
+		return width - 1 - x, y
+	case protocols.WlOutputTransform_enum_flipped_90:
+		return y, x
+	case protocols.WlOutputTransform_enum_flipped_180:
+		return x, height - 1 - y
+	case protocols.WlOutputTransform_enum_flipped_270:
+		return height - 1 - y, width - 1 - x
+	}
+	return x, y
+}
+
+/**
+ * Like SurfacePixelToBuffer, but for the corners
+ * of a rectangle of the surface.
+ */
+func surfacePointToBuffer(transform protocols.WlOutputTransform_enum, width, height, x, y int) (int, int) {
+	switch transform {
+	case protocols.WlOutputTransform_enum__90:
+		return y, width - x
+	case protocols.WlOutputTransform_enum__180:
+		return width - x, height - y
+	case protocols.WlOutputTransform_enum__270:
+		return height - y, x
+	case protocols.WlOutputTransform_enum_flipped:
+		return width - x, y
+	case protocols.WlOutputTransform_enum_flipped_90:
+		return y, x
+	case protocols.WlOutputTransform_enum_flipped_180:
+		return x, height - y
+	case protocols.WlOutputTransform_enum_flipped_270:
+		return height - y, width - x
+	}
+	return x, y
+}
+
+/**
+ * The inverse of surfacePointToBuffer, width and
+ * height are still the surface's.
+ */
+func bufferPointToSurface(transform protocols.WlOutputTransform_enum, width, height, x, y int) (int, int) {
+	switch transform {
+	case protocols.WlOutputTransform_enum__90:
+		return width - y, x
+	case protocols.WlOutputTransform_enum__270:
+		return y, height - x
+	case protocols.WlOutputTransform_enum_flipped_270:
+		return width - y, height - x
+	}
+	/**
+	 * The rest undo themselves
+	 */
+	return surfacePointToBuffer(transform, width, height, x, y)
+}
+
+/**
+ * A rectangle of the surface (width x height,
+ * in buffer pixels) to the buffer.
+ */
+func SurfaceRectToBuffer(transform protocols.WlOutputTransform_enum, width, height int, r image.Rectangle) image.Rectangle {
+	x0, y0 := surfacePointToBuffer(transform, width, height, r.Min.X, r.Min.Y)
+	x1, y1 := surfacePointToBuffer(transform, width, height, r.Max.X, r.Max.Y)
+	return image.Rect(x0, y0, x1, y1)
+}
+
+/**
+ * A rectangle of the buffer to the surface
+ * (width x height, after TransformedSize).
+ */
+func BufferRectToSurface(transform protocols.WlOutputTransform_enum, width, height int, r image.Rectangle) image.Rectangle {
+	x0, y0 := bufferPointToSurface(transform, width, height, r.Min.X, r.Min.Y)
+	x1, y1 := bufferPointToSurface(transform, width, height, r.Max.X, r.Max.Y)
+	return image.Rect(x0, y0, x1, y1)
+}
+
+/**
+ * Set with --rotate, every output is turned
+ * the same way. Only the size is turned: the
+ * desktop is drawn upright in the terminal and
+ * the pointer maps to it directly, so apps are
+ * told the transform is normal.
+ */
+var OutputTransform = protocols.WlOutputTransform_enum_normal
+
+/**
+ * Call once at startup, before SetVirtualOutputs
+ */
+func SetOutputTransform(transform protocols.WlOutputTransform_enum) {
+	OutputTransform = transform
+}
+
+/**
+ * The output turned by OutputTransform: 90 gives
+ * a portrait desktop for a landscape
+ * --virtual-monitor-size. This is the size of
+ * the picture in the terminal.
+ */
+func (o *VirtualOutput) DesktopSize() Size {
+	width, height := TransformedSize(OutputTransform, int(o.Size.Width), int(o.Size.Height))
+	return Size{
+		Width:  uint32(width),
+		Height: uint32(height),
+	}
+}
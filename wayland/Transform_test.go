@@ -0,0 +1,140 @@
+package wayland
+
+import (
+	"image"
+	"testing"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+var allTransforms = []protocols.WlOutputTransform_enum{
+	protocols.WlOutputTransform_enum_normal,
+	protocols.WlOutputTransform_enum__90,
+	protocols.WlOutputTransform_enum__180,
+	protocols.WlOutputTransform_enum__270,
+	protocols.WlOutputTransform_enum_flipped,
+	protocols.WlOutputTransform_enum_flipped_90,
+	protocols.WlOutputTransform_enum_flipped_180,
+	protocols.WlOutputTransform_enum_flipped_270,
+}
+
+func TestTransformedSize(t *testing.T) {
+	tests := []struct {
+		transform     protocols.WlOutputTransform_enum
+		width, height int
+	}{
+		{protocols.WlOutputTransform_enum_normal, 3, 2},
+		{protocols.WlOutputTransform_enum__90, 2, 3},
+		{protocols.WlOutputTransform_enum__180, 3, 2},
+		{protocols.WlOutputTransform_enum__270, 2, 3},
+		{protocols.WlOutputTransform_enum_flipped, 3, 2},
+		{protocols.WlOutputTransform_enum_flipped_90, 2, 3},
+		{protocols.WlOutputTransform_enum_flipped_180, 3, 2},
+		{protocols.WlOutputTransform_enum_flipped_270, 2, 3},
+		/**
+		 * Not a transform apps can send
+		 */
+		{protocols.WlOutputTransform_enum(99), 3, 2},
+	}
+	for _, test := range tests {
+		width, height := TransformedSize(test.transform, 3, 2)
+		if width != test.width || height != test.height {
+			t.Errorf("TransformedSize(%d, 3, 2) = %d, %d, want %d, %d", test.transform, width, height, test.width, test.height)
+		}
+	}
+}
+
+func TestSurfacePixelToBuffer(t *testing.T) {
+	/**
+	 * Where the top left and top right pixels of a
+	 * 3x2 surface come from in the buffer
+	 */
+	tests := []struct {
+		transform protocols.WlOutputTransform_enum
+		topLeft   image.Point
+		topRight  image.Point
+	}{
+		{protocols.WlOutputTransform_enum_normal, image.Pt(0, 0), image.Pt(2, 0)},
+		{protocols.WlOutputTransform_enum__90, image.Pt(0, 2), image.Pt(0, 0)},
+		{protocols.WlOutputTransform_enum__180, image.Pt(2, 1), image.Pt(0, 1)},
+		{protocols.WlOutputTransform_enum__270, image.Pt(1, 0), image.Pt(1, 2)},
+		{protocols.WlOutputTransform_enum_flipped, image.Pt(2, 0), image.Pt(0, 0)},
+		{protocols.WlOutputTransform_enum_flipped_90, image.Pt(0, 0), image.Pt(0, 2)},
+		{protocols.WlOutputTransform_enum_flipped_180, image.Pt(0, 1), image.Pt(2, 1)},
+		{protocols.WlOutputTransform_enum_flipped_270, image.Pt(1, 2), image.Pt(1, 0)},
+	}
+	for _, test := range tests {
+		x, y := SurfacePixelToBuffer(test.transform, 3, 2, 0, 0)
+		if got := image.Pt(x, y); got != test.topLeft {
+			t.Errorf("transform %d: top left from %v, want %v", test.transform, got, test.topLeft)
+		}
+		x, y = SurfacePixelToBuffer(test.transform, 3, 2, 2, 0)
+		if got := image.Pt(x, y); got != test.topRight {
+			t.Errorf("transform %d: top right from %v, want %v", test.transform, got, test.topRight)
+		}
+	}
+}
+
+func TestSurfacePixelToBufferCoversBuffer(t *testing.T) {
+	const width, height = 4, 3
+	for _, transform := range allTransforms {
+		bufferWidth, bufferHeight := TransformedSize(transform, width, height)
+		seen := map[image.Point]bool{}
+		for y := range height {
+			for x := range width {
+				bx, by := SurfacePixelToBuffer(transform, width, height, x, y)
+				p := image.Pt(bx, by)
+				if !p.In(image.Rect(0, 0, bufferWidth, bufferHeight)) {
+					t.Errorf("transform %d: (%d, %d) maps outside the buffer to %v", transform, x, y, p)
+				}
+				if seen[p] {
+					t.Errorf("transform %d: buffer pixel %v used twice", transform, p)
+				}
+				seen[p] = true
+			}
+		}
+	}
+}
+
+func TestBufferPointToSurfaceRoundTrip(t *testing.T) {
+	const width, height = 4, 3
+	for _, transform := range allTransforms {
+		/**
+		 * Points, so the far edges are included
+		 */
+		for y := 0; y <= height; y++ {
+			for x := 0; x <= width; x++ {
+				bx, by := surfacePointToBuffer(transform, width, height, x, y)
+				sx, sy := bufferPointToSurface(transform, width, height, bx, by)
+				if sx != x || sy != y {
+					t.Errorf("transform %d: (%d, %d) -> (%d, %d) -> (%d, %d)", transform, x, y, bx, by, sx, sy)
+				}
+			}
+		}
+	}
+}
+
+func TestRectRoundTrip(t *testing.T) {
+	const width, height = 4, 3
+	rects := []image.Rectangle{
+		image.Rect(0, 0, width, height),
+		image.Rect(1, 0, 3, 2),
+		image.Rect(0, 2, 1, 3),
+		image.Rect(2, 1, 2, 1),
+	}
+	for _, transform := range allTransforms {
+		bufferWidth, bufferHeight := TransformedSize(transform, width, height)
+		for _, r := range rects {
+			buffer := SurfaceRectToBuffer(transform, width, height, r)
+			if buffer.Dx()*buffer.Dy() != r.Dx()*r.Dy() {
+				t.Errorf("transform %d: %v -> %v changed the area", transform, r, buffer)
+			}
+			if !buffer.In(image.Rect(0, 0, bufferWidth, bufferHeight)) && !buffer.Empty() {
+				t.Errorf("transform %d: %v -> %v is outside the buffer", transform, r, buffer)
+			}
+			if back := BufferRectToSurface(transform, width, height, buffer); back != r {
+				t.Errorf("transform %d: %v -> %v -> %v", transform, r, buffer, back)
+			}
+		}
+	}
+}
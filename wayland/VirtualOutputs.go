@@ -0,0 +1,147 @@
+package wayland
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * One virtual monitor, each is advertised as its own
+ * wl_output global. The terminal shows one at a time.
+ */
+type VirtualOutput struct {
+	Index    int
+	GlobalID protocols.GlobalID
+	Size     PixelSize
+	/**
+	 * Outputs are laid out left to right
+	 * in the global compositor space,
+	 * in logical pixels (see OutputScale).
+	 */
+	X int32
+}
+
+func (o *VirtualOutput) Name() string {
+	return fmt.Sprintf("TE-%d", o.Index+1)
+}
+
+func (o *VirtualOutput) Description() string {
+	if o.Index == 0 {
+		return "term.everything Virtual Monitor"
+	}
+	return fmt.Sprintf("term.everything Virtual Monitor %d", o.Index+1)
+}
+
+/**
+ * The first one is always VirtualMonitorSize
+ */
+var VirtualOutputs = []*VirtualOutput{
+	{
+		Index:    0,
+		GlobalID: protocols.GlobalID_WlOutput,
+		Size:     VirtualMonitorSize,
+	},
+}
+
+/**
+ * Call once at startup (after VirtualMonitorSize
+ * and OutputScale are set),
+ * with the sizes of the outputs after the first.
+ */
+func SetVirtualOutputs(extraSizes []PixelSize) {
+	VirtualOutputs = VirtualOutputs[:1]
+	VirtualOutputs[0].Size = VirtualMonitorSize
+	x := int32(VirtualOutputs[0].LogicalSize().Width)
+	for i, size := range extraSizes {
+		output := &VirtualOutput{
+			Index:    i + 1,
+			GlobalID: protocols.GlobalID_ExtraWlOutputs + protocols.GlobalID(i),
+			Size:     size,
+			X:        x,
+		}
+		x += int32(output.LogicalSize().Width)
+		VirtualOutputs = append(VirtualOutputs, output)
+		protocols.AdvertisedGlobalObjectNames = append(protocols.AdvertisedGlobalObjectNames,
+			protocols.AdvertisedGlobalObjectName{Name: "wl_output", Id: output.GlobalID, Version: 5},
+		)
+	}
+}
+
+func VirtualOutputByGlobalID(globalID protocols.GlobalID) *VirtualOutput {
+	for _, output := range VirtualOutputs {
+		if output.GlobalID == globalID {
+			return output
+		}
+	}
+	return nil
+}
+
+func GetVirtualOutput(index int) *VirtualOutput {
+	if index < 0 || index >= len(VirtualOutputs) {
+		return VirtualOutputs[0]
+	}
+	return VirtualOutputs[index]
+}
+
+var currentVirtualOutput = struct {
+	sync.Mutex
+	index int
+}{}
+
+/**
+ * The output shown in the terminal,
+ * new windows open on it.
+ */
+func CurrentVirtualOutput() int {
+	currentVirtualOutput.Lock()
+	defer currentVirtualOutput.Unlock()
+	return currentVirtualOutput.index
+}
+
+func SetCurrentVirtualOutput(index int) {
+	currentVirtualOutput.Lock()
+	defer currentVirtualOutput.Unlock()
+	currentVirtualOutput.index = (index + len(VirtualOutputs)) % len(VirtualOutputs)
+}
+
+/**
+ * Send wl_surface.enter (or leave) for every
+ * wl_output the client bound for output.
+ */
+func SendSurfaceOutputEvent(s protocols.ClientState, surfaceID protocols.ObjectID[protocols.WlSurface], output int, enter bool) {
+	outputBinds := protocols.GetGlobalWlOutputBinds(s)
+	for outputID := range outputBinds {
+		bound := GetWlOutputObject(s, outputID)
+		if bound == nil || bound.Output == nil || bound.Output.Index != output {
+			continue
+		}
+		if enter {
+			protocols.WlSurface_enter(s, surfaceID, outputID)
+		} else {
+			protocols.WlSurface_leave(s, surfaceID, outputID)
+		}
+	}
+}
+
+/**
+ * Move a toplevel to another output: leave the old one,
+ * enter the new one and resize it to fit.
+ * Must hold the client's lock.
+ */
+func MoveToplevelToOutput(s protocols.ClientState, toplevelID protocols.ObjectID[protocols.XdgToplevel], output int) {
+	toplevel := GetXdgToplevelObject(s, toplevelID)
+	if toplevel == nil || toplevel.Output == output {
+		return
+	}
+	surfaceID := GetSurfaceIDFromRole(s, toplevelID)
+	if surfaceID != nil {
+		SendSurfaceOutputEvent(s, *surfaceID, toplevel.Output, false)
+	}
+	toplevel.Output = output
+	if surfaceID != nil {
+		SendSurfaceOutputEvent(s, *surfaceID, output, true)
+	}
+	toplevel.stateConfiguration(s, toplevelID, toplevel.Maximized, toplevel.Fullscreen)
+}
@@ -0,0 +1,58 @@
+package wayland
+
+import (
+	"sync"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Workspaces are numbered 1 to WorkspaceCount
+ * for the user, 0 based everywhere else.
+ */
+const WorkspaceCount = 9
+
+var currentWorkspace = struct {
+	sync.Mutex
+	index int
+}{}
+
+/**
+ * The workspace shown in the terminal,
+ * new windows open on it.
+ */
+func CurrentWorkspace() int {
+	currentWorkspace.Lock()
+	defer currentWorkspace.Unlock()
+	return currentWorkspace.index
+}
+
+func SetCurrentWorkspace(index int) {
+	if index < 0 || index >= WorkspaceCount {
+		return
+	}
+	currentWorkspace.Lock()
+	defer currentWorkspace.Unlock()
+	currentWorkspace.index = index
+}
+
+/**
+ * On the output and the workspace
+ * the terminal is switched to.
+ */
+func (t *XdgToplevel) InView() bool {
+	return t.Output == CurrentVirtualOutput() && t.Workspace == CurrentWorkspace()
+}
+
+/**
+ * The window stays where it is on its output,
+ * it is suspended (see SetSuspended) once
+ * the terminal stops showing it.
+ */
+func MoveToplevelToWorkspace(s protocols.ClientState, toplevelID protocols.ObjectID[protocols.XdgToplevel], workspace int) {
+	toplevel := GetXdgToplevelObject(s, toplevelID)
+	if toplevel == nil || workspace < 0 || workspace >= WorkspaceCount {
+		return
+	}
+	toplevel.Workspace = workspace
+}
@@ -52,8 +52,8 @@
 // Handle frame callbacks to know when clients want to redraw:
 //
 //	func handleFrameRequests(client *wayland.Client) {
-//		for callbackID := range client.FrameDrawRequests {
-//			protocols.WlCallback_done(client, callbackID, uint32(time.Now().UnixMilli()))
+//		for request := range client.FrameDrawRequests {
+//			protocols.WlCallback_done(client, request.Callback, uint32(time.Now().UnixMilli()))
 //			if client.Status != wayland.ClientStatus_Connected {
 //				break
 //			}
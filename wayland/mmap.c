@@ -1,3 +1,5 @@
+//go:build cgo
+
 #include <fcntl.h>
 #include <sys/mman.h>
 #include <unistd.h>
@@ -0,0 +1,10 @@
+package protocols
+
+/**
+ * Interfaces that are referenced by the protocols
+ * we generate, but come from protocols we don't
+ * implement (so they never get generated).
+ */
+
+// From tablet-v2.xml, used by wp_cursor_shape_manager_v1.get_tablet_tool_v2
+type ZwpTabletToolV2 struct{}
@@ -19,6 +19,16 @@ const (
 	GlobalID_WlDataDevice                     GlobalID = 0xff00012
 	GlobalID_WlTouch                          GlobalID = 0xff00013
 	GlobalID_ZxdgDecorationManagerV1          GlobalID = 0xff00014
+	GlobalID_WpCursorShapeManagerV1           GlobalID = 0xff00015
+	GlobalID_XdgActivationV1                  GlobalID = 0xff00016
+	GlobalID_ZxdgOutputManagerV1              GlobalID = 0xff00017
+	GlobalID_WpViewporter                     GlobalID = 0xff00018
+	GlobalID_WpFractionalScaleManagerV1       GlobalID = 0xff00019
+	/**
+	 * Outputs after the first one (see wayland.SetVirtualOutputs)
+	 * get GlobalID_ExtraWlOutputs, GlobalID_ExtraWlOutputs + 1, ...
+	 */
+	GlobalID_ExtraWlOutputs GlobalID = 0xff00100
 )
 
 type AdvertisedGlobalObjectName struct {
@@ -46,6 +56,11 @@ var AdvertisedGlobalObjectNames = []AdvertisedGlobalObjectName{
 	{"xdg_wm_base", GlobalID_XdgWmBase, 6},
 	{"wl_data_device_manager", GlobalID_WlDataDeviceManager, 3},
 	{"zxdg_decoration_manager_v1", GlobalID_ZxdgDecorationManagerV1, 1},
+	{"wp_cursor_shape_manager_v1", GlobalID_WpCursorShapeManagerV1, 1},
+	{"xdg_activation_v1", GlobalID_XdgActivationV1, 1},
+	{"zxdg_output_manager_v1", GlobalID_ZxdgOutputManagerV1, 3},
+	{"wp_viewporter", GlobalID_WpViewporter, 1},
+	{"wp_fractional_scale_manager_v1", GlobalID_WpFractionalScaleManagerV1, 1},
 	/**
 	 * @TODO only advertise these to Xwayland clients
 	 */
@@ -0,0 +1,197 @@
+// Code generated by `cmd/protocols`; DO NOT EDIT.
+
+package protocols
+
+import "fmt"
+
+type WpCursorShapeManagerV1_delegate interface {
+	WpCursorShapeManagerV1_destroy(s ClientState, object_id ObjectID[WpCursorShapeManagerV1]) bool
+	WpCursorShapeManagerV1_get_pointer(s ClientState, object_id ObjectID[WpCursorShapeManagerV1], cursor_shape_device ObjectID[WpCursorShapeDeviceV1], pointer ObjectID[WlPointer])
+	WpCursorShapeManagerV1_get_tablet_tool_v2(s ClientState, object_id ObjectID[WpCursorShapeManagerV1], cursor_shape_device ObjectID[WpCursorShapeDeviceV1], tablet_tool ObjectID[ZwpTabletToolV2])
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type WpCursorShapeManagerV1 struct {
+	Delegate WpCursorShapeManagerV1_delegate
+}
+
+func (p *WpCursorShapeManagerV1) GetDelegate() WpCursorShapeManagerV1_delegate {
+	return p.Delegate
+}
+func (p *WpCursorShapeManagerV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func (p *WpCursorShapeManagerV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("WpCursorShapeManagerV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.WpCursorShapeManagerV1_destroy(s, ObjectID[WpCursorShapeManagerV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	case 1:
+		{
+
+			cursor_shape_deviceVal := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			cursor_shape_device := ObjectID[WpCursorShapeDeviceV1](cursor_shape_deviceVal)
+			_data_in_offset__ += 4
+
+			pointer := ObjectID[WlPointer](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("WpCursorShapeManagerV1@", message.ObjectID, ".get_pointer(")
+				fmt.Println("cursor_shape_device: ", cursor_shape_device, ", ", "pointer: ", pointer, ")")
+			}
+
+			d.WpCursorShapeManagerV1_get_pointer(s, ObjectID[WpCursorShapeManagerV1](message.ObjectID), cursor_shape_device, pointer)
+			break
+		}
+
+	case 2:
+		{
+
+			cursor_shape_deviceVal := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			cursor_shape_device := ObjectID[WpCursorShapeDeviceV1](cursor_shape_deviceVal)
+			_data_in_offset__ += 4
+
+			tablet_tool := ObjectID[ZwpTabletToolV2](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("WpCursorShapeManagerV1@", message.ObjectID, ".get_tablet_tool_v2(")
+				fmt.Println("cursor_shape_device: ", cursor_shape_device, ", ", "tablet_tool: ", tablet_tool, ")")
+			}
+
+			d.WpCursorShapeManagerV1_get_tablet_tool_v2(s, ObjectID[WpCursorShapeManagerV1](message.ObjectID), cursor_shape_device, tablet_tool)
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on WpCursorShapeManagerV1", message.Opcode)
+	}
+}
+
+type WpCursorShapeDeviceV1_delegate interface {
+	WpCursorShapeDeviceV1_destroy(s ClientState, object_id ObjectID[WpCursorShapeDeviceV1]) bool
+	WpCursorShapeDeviceV1_set_shape(s ClientState, object_id ObjectID[WpCursorShapeDeviceV1], serial uint32, shape WpCursorShapeDeviceV1Shape_enum)
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type WpCursorShapeDeviceV1 struct {
+	Delegate WpCursorShapeDeviceV1_delegate
+}
+
+func (p *WpCursorShapeDeviceV1) GetDelegate() WpCursorShapeDeviceV1_delegate {
+	return p.Delegate
+}
+func (p *WpCursorShapeDeviceV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func (p *WpCursorShapeDeviceV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("WpCursorShapeDeviceV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.WpCursorShapeDeviceV1_destroy(s, ObjectID[WpCursorShapeDeviceV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	case 1:
+		{
+
+			serial := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			_data_in_offset__ += 4
+
+			shape := WpCursorShapeDeviceV1Shape_enum(uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("WpCursorShapeDeviceV1@", message.ObjectID, ".set_shape(")
+				fmt.Println("serial: ", serial, ", ", "shape: ", shape, ")")
+			}
+
+			d.WpCursorShapeDeviceV1_set_shape(s, ObjectID[WpCursorShapeDeviceV1](message.ObjectID), serial, shape)
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on WpCursorShapeDeviceV1", message.Opcode)
+	}
+}
+
+type WpCursorShapeDeviceV1Shape_enum uint32
+
+const (
+	WpCursorShapeDeviceV1Shape_enum_default_      WpCursorShapeDeviceV1Shape_enum = 1
+	WpCursorShapeDeviceV1Shape_enum_context_menu  WpCursorShapeDeviceV1Shape_enum = 2
+	WpCursorShapeDeviceV1Shape_enum_help          WpCursorShapeDeviceV1Shape_enum = 3
+	WpCursorShapeDeviceV1Shape_enum_pointer       WpCursorShapeDeviceV1Shape_enum = 4
+	WpCursorShapeDeviceV1Shape_enum_progress      WpCursorShapeDeviceV1Shape_enum = 5
+	WpCursorShapeDeviceV1Shape_enum_wait          WpCursorShapeDeviceV1Shape_enum = 6
+	WpCursorShapeDeviceV1Shape_enum_cell          WpCursorShapeDeviceV1Shape_enum = 7
+	WpCursorShapeDeviceV1Shape_enum_crosshair     WpCursorShapeDeviceV1Shape_enum = 8
+	WpCursorShapeDeviceV1Shape_enum_text          WpCursorShapeDeviceV1Shape_enum = 9
+	WpCursorShapeDeviceV1Shape_enum_vertical_text WpCursorShapeDeviceV1Shape_enum = 10
+	WpCursorShapeDeviceV1Shape_enum_alias         WpCursorShapeDeviceV1Shape_enum = 11
+	WpCursorShapeDeviceV1Shape_enum_copy          WpCursorShapeDeviceV1Shape_enum = 12
+	WpCursorShapeDeviceV1Shape_enum_move          WpCursorShapeDeviceV1Shape_enum = 13
+	WpCursorShapeDeviceV1Shape_enum_no_drop       WpCursorShapeDeviceV1Shape_enum = 14
+	WpCursorShapeDeviceV1Shape_enum_not_allowed   WpCursorShapeDeviceV1Shape_enum = 15
+	WpCursorShapeDeviceV1Shape_enum_grab          WpCursorShapeDeviceV1Shape_enum = 16
+	WpCursorShapeDeviceV1Shape_enum_grabbing      WpCursorShapeDeviceV1Shape_enum = 17
+	WpCursorShapeDeviceV1Shape_enum_e_resize      WpCursorShapeDeviceV1Shape_enum = 18
+	WpCursorShapeDeviceV1Shape_enum_n_resize      WpCursorShapeDeviceV1Shape_enum = 19
+	WpCursorShapeDeviceV1Shape_enum_ne_resize     WpCursorShapeDeviceV1Shape_enum = 20
+	WpCursorShapeDeviceV1Shape_enum_nw_resize     WpCursorShapeDeviceV1Shape_enum = 21
+	WpCursorShapeDeviceV1Shape_enum_s_resize      WpCursorShapeDeviceV1Shape_enum = 22
+	WpCursorShapeDeviceV1Shape_enum_se_resize     WpCursorShapeDeviceV1Shape_enum = 23
+	WpCursorShapeDeviceV1Shape_enum_sw_resize     WpCursorShapeDeviceV1Shape_enum = 24
+	WpCursorShapeDeviceV1Shape_enum_w_resize      WpCursorShapeDeviceV1Shape_enum = 25
+	WpCursorShapeDeviceV1Shape_enum_ew_resize     WpCursorShapeDeviceV1Shape_enum = 26
+	WpCursorShapeDeviceV1Shape_enum_ns_resize     WpCursorShapeDeviceV1Shape_enum = 27
+	WpCursorShapeDeviceV1Shape_enum_nesw_resize   WpCursorShapeDeviceV1Shape_enum = 28
+	WpCursorShapeDeviceV1Shape_enum_nwse_resize   WpCursorShapeDeviceV1Shape_enum = 29
+	WpCursorShapeDeviceV1Shape_enum_col_resize    WpCursorShapeDeviceV1Shape_enum = 30
+	WpCursorShapeDeviceV1Shape_enum_row_resize    WpCursorShapeDeviceV1Shape_enum = 31
+	WpCursorShapeDeviceV1Shape_enum_all_scroll    WpCursorShapeDeviceV1Shape_enum = 32
+	WpCursorShapeDeviceV1Shape_enum_zoom_in       WpCursorShapeDeviceV1Shape_enum = 33
+	WpCursorShapeDeviceV1Shape_enum_zoom_out      WpCursorShapeDeviceV1Shape_enum = 34
+)
+
+type WpCursorShapeDeviceV1Error_enum uint32
+
+const (
+	WpCursorShapeDeviceV1Error_enum_invalid_shape WpCursorShapeDeviceV1Error_enum = 1
+)
@@ -0,0 +1,129 @@
+// Code generated by `cmd/protocols`; DO NOT EDIT.
+
+package protocols
+
+import "fmt"
+
+type WpFractionalScaleManagerV1_delegate interface {
+	WpFractionalScaleManagerV1_destroy(s ClientState, object_id ObjectID[WpFractionalScaleManagerV1]) bool
+	WpFractionalScaleManagerV1_get_fractional_scale(s ClientState, object_id ObjectID[WpFractionalScaleManagerV1], id ObjectID[WpFractionalScaleV1], surface ObjectID[WlSurface])
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type WpFractionalScaleManagerV1 struct {
+	Delegate WpFractionalScaleManagerV1_delegate
+}
+
+func (p *WpFractionalScaleManagerV1) GetDelegate() WpFractionalScaleManagerV1_delegate {
+	return p.Delegate
+}
+func (p *WpFractionalScaleManagerV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func (p *WpFractionalScaleManagerV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("WpFractionalScaleManagerV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.WpFractionalScaleManagerV1_destroy(s, ObjectID[WpFractionalScaleManagerV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	case 1:
+		{
+
+			idVal := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			id := ObjectID[WpFractionalScaleV1](idVal)
+			_data_in_offset__ += 4
+
+			surface := ObjectID[WlSurface](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("WpFractionalScaleManagerV1@", message.ObjectID, ".get_fractional_scale(")
+				fmt.Println("id: ", id, ", ", "surface: ", surface, ")")
+			}
+
+			d.WpFractionalScaleManagerV1_get_fractional_scale(s, ObjectID[WpFractionalScaleManagerV1](message.ObjectID), id, surface)
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on WpFractionalScaleManagerV1", message.Opcode)
+	}
+}
+
+type WpFractionalScaleManagerV1Error_enum uint32
+
+const (
+	WpFractionalScaleManagerV1Error_enum_fractional_scale_exists WpFractionalScaleManagerV1Error_enum = 0
+)
+
+type WpFractionalScaleV1_delegate interface {
+	WpFractionalScaleV1_destroy(s ClientState, object_id ObjectID[WpFractionalScaleV1]) bool
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type WpFractionalScaleV1 struct {
+	Delegate WpFractionalScaleV1_delegate
+}
+
+func (p *WpFractionalScaleV1) GetDelegate() WpFractionalScaleV1_delegate {
+	return p.Delegate
+}
+func (p *WpFractionalScaleV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func WpFractionalScaleV1_preferred_scale(s Sender, eventObjectID ObjectID[WpFractionalScaleV1], scale uint32) {
+	data := make([]byte, 0)
+	putUint32 := func(v uint32) { data = append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+	var fileDescriptor *FileDescriptor
+	putUint32(uint32(scale))
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         0,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func (p *WpFractionalScaleV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("WpFractionalScaleV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.WpFractionalScaleV1_destroy(s, ObjectID[WpFractionalScaleV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on WpFractionalScaleV1", message.Opcode)
+	}
+}
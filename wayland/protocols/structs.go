@@ -38,7 +38,8 @@ type ClientState interface {
 
 	DrawableSurfaces() map[ObjectID[WlSurface]]bool
 	TopLevelSurfaces() map[ObjectID[XdgToplevel]]bool
-	AddFrameDrawRequest(ObjectID[WlCallback])
+	AddFrameDrawRequest(ObjectID[WlSurface], ObjectID[WlCallback])
+	AddActivationRequest(ObjectID[XdgToplevel])
 
 	GetSurfaceIDFromRole(AnyObjectID) *ObjectID[WlSurface]
 
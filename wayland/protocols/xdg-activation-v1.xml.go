@@ -0,0 +1,237 @@
+// Code generated by `cmd/protocols`; DO NOT EDIT.
+
+package protocols
+
+import "fmt"
+
+type XdgActivationV1_delegate interface {
+	XdgActivationV1_destroy(s ClientState, object_id ObjectID[XdgActivationV1]) bool
+	XdgActivationV1_get_activation_token(s ClientState, object_id ObjectID[XdgActivationV1], id ObjectID[XdgActivationTokenV1])
+	XdgActivationV1_activate(s ClientState, object_id ObjectID[XdgActivationV1], token string, surface ObjectID[WlSurface])
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type XdgActivationV1 struct {
+	Delegate XdgActivationV1_delegate
+}
+
+func (p *XdgActivationV1) GetDelegate() XdgActivationV1_delegate {
+	return p.Delegate
+}
+func (p *XdgActivationV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func (p *XdgActivationV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("XdgActivationV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.XdgActivationV1_destroy(s, ObjectID[XdgActivationV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	case 1:
+		{
+
+			idVal := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			id := ObjectID[XdgActivationTokenV1](idVal)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("XdgActivationV1@", message.ObjectID, ".get_activation_token(")
+				fmt.Println("id: ", id, ")")
+			}
+
+			d.XdgActivationV1_get_activation_token(s, ObjectID[XdgActivationV1](message.ObjectID), id)
+			break
+		}
+
+	case 2:
+		{
+
+			tokenLen := int(uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+			token := string(message.Data[_data_in_offset__ : _data_in_offset__+tokenLen-1]) // NUL-terminated
+			// 4-byte alignment
+			if tokenLen%4 != 0 {
+				_data_in_offset__ += tokenLen + (4 - (tokenLen % 4))
+			} else {
+				_data_in_offset__ += tokenLen
+			}
+
+			surface := ObjectID[WlSurface](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("XdgActivationV1@", message.ObjectID, ".activate(")
+				fmt.Println("token: ", token, ", ", "surface: ", surface, ")")
+			}
+
+			d.XdgActivationV1_activate(s, ObjectID[XdgActivationV1](message.ObjectID), token, surface)
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on XdgActivationV1", message.Opcode)
+	}
+}
+
+type XdgActivationTokenV1_delegate interface {
+	XdgActivationTokenV1_set_serial(s ClientState, object_id ObjectID[XdgActivationTokenV1], serial uint32, seat ObjectID[WlSeat])
+	XdgActivationTokenV1_set_app_id(s ClientState, object_id ObjectID[XdgActivationTokenV1], app_id string)
+	XdgActivationTokenV1_set_surface(s ClientState, object_id ObjectID[XdgActivationTokenV1], surface ObjectID[WlSurface])
+	XdgActivationTokenV1_commit(s ClientState, object_id ObjectID[XdgActivationTokenV1])
+	XdgActivationTokenV1_destroy(s ClientState, object_id ObjectID[XdgActivationTokenV1]) bool
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type XdgActivationTokenV1 struct {
+	Delegate XdgActivationTokenV1_delegate
+}
+
+func (p *XdgActivationTokenV1) GetDelegate() XdgActivationTokenV1_delegate {
+	return p.Delegate
+}
+func (p *XdgActivationTokenV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func XdgActivationTokenV1_done(s Sender, eventObjectID ObjectID[XdgActivationTokenV1], token string) {
+	data := make([]byte, 0)
+	putUint32 := func(v uint32) { data = append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+	var fileDescriptor *FileDescriptor
+	{
+		b := []byte(token)
+		total := len(b) + 1 // include null terminator
+		putUint32(uint32(total))
+		data = append(data, b...)
+		data = append(data, 0)
+		if pad := (4 - (total % 4)) % 4; pad != 0 {
+			data = append(data, make([]byte, pad)...)
+		}
+	}
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         0,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func (p *XdgActivationTokenV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			serial := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			_data_in_offset__ += 4
+
+			seat := ObjectID[WlSeat](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("XdgActivationTokenV1@", message.ObjectID, ".set_serial(")
+				fmt.Println("serial: ", serial, ", ", "seat: ", seat, ")")
+			}
+
+			d.XdgActivationTokenV1_set_serial(s, ObjectID[XdgActivationTokenV1](message.ObjectID), serial, seat)
+			break
+		}
+
+	case 1:
+		{
+
+			app_idLen := int(uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+			app_id := string(message.Data[_data_in_offset__ : _data_in_offset__+app_idLen-1]) // NUL-terminated
+			// 4-byte alignment
+			if app_idLen%4 != 0 {
+				_data_in_offset__ += app_idLen + (4 - (app_idLen % 4))
+			} else {
+				_data_in_offset__ += app_idLen
+			}
+
+			if DebugRequests {
+				fmt.Print("XdgActivationTokenV1@", message.ObjectID, ".set_app_id(")
+				fmt.Println("app_id: ", app_id, ")")
+			}
+
+			d.XdgActivationTokenV1_set_app_id(s, ObjectID[XdgActivationTokenV1](message.ObjectID), app_id)
+			break
+		}
+
+	case 2:
+		{
+
+			surface := ObjectID[WlSurface](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("XdgActivationTokenV1@", message.ObjectID, ".set_surface(")
+				fmt.Println("surface: ", surface, ")")
+			}
+
+			d.XdgActivationTokenV1_set_surface(s, ObjectID[XdgActivationTokenV1](message.ObjectID), surface)
+			break
+		}
+
+	case 3:
+		{
+
+			if DebugRequests {
+				fmt.Print("XdgActivationTokenV1@", message.ObjectID, ".commit(")
+				fmt.Println(")")
+			}
+
+			d.XdgActivationTokenV1_commit(s, ObjectID[XdgActivationTokenV1](message.ObjectID))
+			break
+		}
+
+	case 4:
+		{
+
+			if DebugRequests {
+				fmt.Print("XdgActivationTokenV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.XdgActivationTokenV1_destroy(s, ObjectID[XdgActivationTokenV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on XdgActivationTokenV1", message.Opcode)
+	}
+}
+
+type XdgActivationTokenV1Error_enum uint32
+
+const (
+	XdgActivationTokenV1Error_enum_already_used XdgActivationTokenV1Error_enum = 0
+)
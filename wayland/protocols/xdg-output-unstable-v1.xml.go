@@ -0,0 +1,205 @@
+// Code generated by `cmd/protocols`; DO NOT EDIT.
+
+package protocols
+
+import "fmt"
+
+type ZxdgOutputManagerV1_delegate interface {
+	ZxdgOutputManagerV1_destroy(s ClientState, object_id ObjectID[ZxdgOutputManagerV1]) bool
+	ZxdgOutputManagerV1_get_xdg_output(s ClientState, object_id ObjectID[ZxdgOutputManagerV1], id ObjectID[ZxdgOutputV1], output ObjectID[WlOutput])
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type ZxdgOutputManagerV1 struct {
+	Delegate ZxdgOutputManagerV1_delegate
+}
+
+func (p *ZxdgOutputManagerV1) GetDelegate() ZxdgOutputManagerV1_delegate {
+	return p.Delegate
+}
+func (p *ZxdgOutputManagerV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func (p *ZxdgOutputManagerV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("ZxdgOutputManagerV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.ZxdgOutputManagerV1_destroy(s, ObjectID[ZxdgOutputManagerV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	case 1:
+		{
+
+			idVal := uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24
+			id := ObjectID[ZxdgOutputV1](idVal)
+			_data_in_offset__ += 4
+
+			output := ObjectID[WlOutput](uint32(message.Data[_data_in_offset__+0]) | uint32(message.Data[_data_in_offset__+1])<<8 |
+				uint32(message.Data[_data_in_offset__+2])<<16 | uint32(message.Data[_data_in_offset__+3])<<24)
+			_data_in_offset__ += 4
+
+			if DebugRequests {
+				fmt.Print("ZxdgOutputManagerV1@", message.ObjectID, ".get_xdg_output(")
+				fmt.Println("id: ", id, ", ", "output: ", output, ")")
+			}
+
+			d.ZxdgOutputManagerV1_get_xdg_output(s, ObjectID[ZxdgOutputManagerV1](message.ObjectID), id, output)
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on ZxdgOutputManagerV1", message.Opcode)
+	}
+}
+
+type ZxdgOutputV1_delegate interface {
+	ZxdgOutputV1_destroy(s ClientState, object_id ObjectID[ZxdgOutputV1]) bool
+	OnBind(s ClientState, name AnyObjectID, interface_ string, new_id AnyObjectID, version_number uint32)
+}
+
+type ZxdgOutputV1 struct {
+	Delegate ZxdgOutputV1_delegate
+}
+
+func (p *ZxdgOutputV1) GetDelegate() ZxdgOutputV1_delegate {
+	return p.Delegate
+}
+func (p *ZxdgOutputV1) GetBindable() OnBindable {
+	return p.Delegate
+}
+
+func ZxdgOutputV1_logical_position(s Sender, eventObjectID ObjectID[ZxdgOutputV1], x int32, y int32) {
+	data := make([]byte, 0)
+	putInt32 := func(v int32) { uv := uint32(v); data = append(data, byte(uv), byte(uv>>8), byte(uv>>16), byte(uv>>24)) }
+	var fileDescriptor *FileDescriptor
+	putInt32(int32(x))
+	putInt32(int32(y))
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         0,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func ZxdgOutputV1_logical_size(s Sender, eventObjectID ObjectID[ZxdgOutputV1], width int32, height int32) {
+	data := make([]byte, 0)
+	putInt32 := func(v int32) { uv := uint32(v); data = append(data, byte(uv), byte(uv>>8), byte(uv>>16), byte(uv>>24)) }
+	var fileDescriptor *FileDescriptor
+	putInt32(int32(width))
+	putInt32(int32(height))
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         1,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func ZxdgOutputV1_done(s Sender, eventObjectID ObjectID[ZxdgOutputV1]) {
+	data := make([]byte, 0)
+	var fileDescriptor *FileDescriptor
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         2,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func ZxdgOutputV1_name(s Sender, boundVersion uint32, eventObjectID ObjectID[ZxdgOutputV1], name string) {
+	if boundVersion < 2 {
+		// Event not available in this version; skip
+		return
+	}
+	data := make([]byte, 0)
+	putUint32 := func(v uint32) { data = append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+	var fileDescriptor *FileDescriptor
+	{
+		b := []byte(name)
+		total := len(b) + 1 // include null terminator
+		putUint32(uint32(total))
+		data = append(data, b...)
+		data = append(data, 0)
+		if pad := (4 - (total % 4)) % 4; pad != 0 {
+			data = append(data, make([]byte, pad)...)
+		}
+	}
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         3,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func ZxdgOutputV1_description(s Sender, boundVersion uint32, eventObjectID ObjectID[ZxdgOutputV1], description string) {
+	if boundVersion < 2 {
+		// Event not available in this version; skip
+		return
+	}
+	data := make([]byte, 0)
+	putUint32 := func(v uint32) { data = append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+	var fileDescriptor *FileDescriptor
+	{
+		b := []byte(description)
+		total := len(b) + 1 // include null terminator
+		putUint32(uint32(total))
+		data = append(data, b...)
+		data = append(data, 0)
+		if pad := (4 - (total % 4)) % 4; pad != 0 {
+			data = append(data, make([]byte, pad)...)
+		}
+	}
+	obj := OutgoingEvent{
+		ObjectID:       AnyObjectID(eventObjectID),
+		Opcode:         4,
+		Data:           data,
+		FileDescriptor: fileDescriptor,
+	}
+	s.Send(obj)
+}
+
+func (p *ZxdgOutputV1) OnRequest(s FileDescriptorClaimClientState, message Message) {
+	_data_in_offset__ := 0
+	_ = _data_in_offset__
+	d := p.Delegate
+	switch message.Opcode {
+	case 0:
+		{
+
+			if DebugRequests {
+				fmt.Print("ZxdgOutputV1@", message.ObjectID, ".destroy(")
+				fmt.Println(")")
+			}
+
+			autoRemove := d.ZxdgOutputV1_destroy(s, ObjectID[ZxdgOutputV1](message.ObjectID))
+			if autoRemove {
+				s.RemoveObject(message.ObjectID)
+			}
+			break
+		}
+
+	default:
+		fmt.Println("Unknown opcode on ZxdgOutputV1", message.Opcode)
+	}
+}
@@ -24,6 +24,26 @@ func GetWlPointerObject(cs protocols.ClientState, id protocols.ObjectID[protocol
 	return d.(*WlPointer)
 }
 
+func GetWlOutputObject(cs protocols.ClientState, id protocols.ObjectID[protocols.WlOutput]) *WlOutput {
+	v := cs.GetObject(protocols.AnyObjectID(id))
+	if v == nil {
+		return nil
+	}
+	o := v.(protocols.WaylandObject[protocols.WlOutput_delegate])
+	d := o.GetDelegate()
+	return d.(*WlOutput)
+}
+
+func GetWlRegionObject(cs protocols.ClientState, id protocols.ObjectID[protocols.WlRegion]) *WlRegion {
+	v := cs.GetObject(protocols.AnyObjectID(id))
+	if v == nil {
+		return nil
+	}
+	o := v.(protocols.WaylandObject[protocols.WlRegion_delegate])
+	d := o.GetDelegate()
+	return d.(*WlRegion)
+}
+
 func GetWlSubsurfaceObject(cs protocols.ClientState, id protocols.ObjectID[protocols.WlSubsurface]) *WlSubsurface {
 	v := cs.GetObject(protocols.AnyObjectID(id))
 	if v == nil {
@@ -15,6 +15,12 @@ func (c *WlCompositor) WlCompositor_create_surface(
 
 	AddObject(s, id, surface)
 
+	/**
+	 * Every output has the same scale,
+	 * so it never changes.
+	 */
+	protocols.WlSurface_preferred_buffer_scale(s, s.GetCompositorVersion(), id, IntegerOutputScale())
+
 	// // s.bound_compositor_info?.surfaces.set(id, new Surface_Info(surface, 1));
 	// // console.log("create surface", id);
 	// /**
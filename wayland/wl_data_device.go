@@ -29,22 +29,25 @@ func (w *wl_data_device) WlDataDevice_set_selection(
 }
 
 func (w *wl_data_device) WlDataDevice_release(
-	_s protocols.ClientState,
-	_object_id protocols.ObjectID[protocols.WlDataDevice],
+	s protocols.ClientState,
+	object_id protocols.ObjectID[protocols.WlDataDevice],
 ) bool {
+	s.RemoveGlobalWlDataDeviceBind(object_id)
 	return true
 }
 
-func (w *wl_data_device) WlDataDevice_on_bind(
+func (w *wl_data_device) OnBind(
 	_s protocols.ClientState,
-	_name protocols.ObjectID[protocols.WlDataDevice],
-	_interface_ string,
-	_new_id protocols.ObjectID[protocols.WlDataDevice],
-	_version_number uint32,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
 ) {
 	/** @TODO: Implement wl_data_device_on_bind */
 }
 
-func MakeWlDataDevice(seat protocols.ObjectID[protocols.WlSeat]) *wl_data_device {
-	return &wl_data_device{Seat: seat}
+func MakeWlDataDevice(seat protocols.ObjectID[protocols.WlSeat]) *protocols.WlDataDevice {
+	return &protocols.WlDataDevice{
+		Delegate: &wl_data_device{Seat: seat},
+	}
 }
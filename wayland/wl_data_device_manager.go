@@ -4,15 +4,21 @@ import (
 	"github.com/mmulet/term.everything/wayland/protocols"
 )
 
-type WlDataDeviceManagerImpl struct{}
+type WlDataDeviceManagerImpl struct {
+	Version uint32
+}
 
 func (w *WlDataDeviceManagerImpl) WlDataDeviceManager_create_data_source(s protocols.ClientState, _object_id protocols.ObjectID[protocols.WlDataDeviceManager], id protocols.ObjectID[protocols.WlDataSource]) {
 	s.AddObject(protocols.AnyObjectID(id), MakeWlDataSource())
 }
 
 func (w *WlDataDeviceManagerImpl) WlDataDeviceManager_get_data_device(s protocols.ClientState, _object_id protocols.ObjectID[protocols.WlDataDeviceManager], id protocols.ObjectID[protocols.WlDataDevice], seat protocols.ObjectID[protocols.WlSeat]) {
-	s.AddObject(protocols.AnyObjectID(id), MakeWlDataDevice(seat))
-	/** @TODO: Implement wl_data_device_manager_get_data_device */
+	AddObject(s, id, MakeWlDataDevice(seat))
+	/**
+	 * Keep track of the data devices so that we
+	 * can hand them a selection (see SetSelectionText)
+	 */
+	s.AddGlobalWlDataDeviceBind(id, protocols.Version(w.Version))
 }
 
 func (w *WlDataDeviceManagerImpl) OnBind(
@@ -22,11 +28,13 @@ func (w *WlDataDeviceManagerImpl) OnBind(
 	_ protocols.AnyObjectID,
 	version uint32,
 ) {
-	/** @TODO: Implement wl_data_device_manager_on_bind */
+	w.Version = version
 }
 
 func MakeWlDataDeviceManager() *protocols.WlDataDeviceManager {
 	return &protocols.WlDataDeviceManager{
-		Delegate: &WlDataDeviceManagerImpl{},
+		Delegate: &WlDataDeviceManagerImpl{
+			Version: 1,
+		},
 	}
 }
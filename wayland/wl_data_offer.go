@@ -0,0 +1,127 @@
+package wayland
+
+import (
+	"log"
+	"os"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * A wl_data_offer that the compositor itself
+ * creates, used to hand text (like a terminal paste)
+ * to a client as the current selection.
+ */
+type WlDataOffer struct {
+	Text      string
+	MimeTypes []string
+}
+
+var TextMimeTypes = []string{
+	"text/plain;charset=utf-8",
+	"text/plain",
+	"UTF8_STRING",
+	"TEXT",
+	"STRING",
+}
+
+func (w *WlDataOffer) WlDataOffer_accept(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WlDataOffer],
+	_ uint32,
+	_ string,
+) {
+	// Only used for drag and drop
+}
+
+func (w *WlDataOffer) WlDataOffer_receive(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WlDataOffer],
+	_ string,
+	fd *protocols.FileDescriptor,
+) {
+	if fd == nil {
+		return
+	}
+	f := os.NewFile(uintptr(*fd), "wl_data_offer")
+	/**
+	 * Write in a goroutine, the client will not
+	 * read from the pipe until it has gotten
+	 * back to its event loop.
+	 */
+	go func() {
+		defer f.Close()
+		if _, err := f.WriteString(w.Text); err != nil {
+			log.Printf("wl_data_offer: failed to write selection: %v", err)
+		}
+	}()
+}
+
+func (w *WlDataOffer) WlDataOffer_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WlDataOffer],
+) bool {
+	return true
+}
+
+func (w *WlDataOffer) WlDataOffer_finish(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WlDataOffer],
+) {
+	// Only used for drag and drop
+}
+
+func (w *WlDataOffer) WlDataOffer_set_actions(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WlDataOffer],
+	_ protocols.WlDataDeviceManagerDndAction_enum,
+	_ protocols.WlDataDeviceManagerDndAction_enum,
+) {
+	// Only used for drag and drop
+}
+
+func (w *WlDataOffer) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeWlDataOffer(text string) *protocols.WlDataOffer {
+	return &protocols.WlDataOffer{
+		Delegate: &WlDataOffer{
+			Text:      text,
+			MimeTypes: TextMimeTypes,
+		},
+	}
+}
+
+/**
+ * Make text the current selection (the clipboard)
+ * of every data device of every client.
+ * Afterwards a paste (ctrl+v) in the client will
+ * read the text.
+ */
+func SetSelectionText(clients []*Client, text string) {
+	for _, client := range clients {
+		if client.Status != ClientStatus_Connected {
+			continue
+		}
+		dataDeviceBinds := protocols.GetGlobalWlDataDeviceBinds(client)
+		if dataDeviceBinds == nil {
+			continue
+		}
+		for dataDeviceID := range dataDeviceBinds {
+			offerID := protocols.ObjectID[protocols.WlDataOffer](client.NextServerObjectID())
+			AddObject(client, offerID, MakeWlDataOffer(text))
+
+			protocols.WlDataDevice_data_offer(client, dataDeviceID, offerID)
+			for _, mimeType := range TextMimeTypes {
+				protocols.WlDataOffer_offer(client, offerID, mimeType)
+			}
+			protocols.WlDataDevice_selection(client, dataDeviceID, &offerID)
+		}
+	}
+}
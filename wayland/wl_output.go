@@ -6,6 +6,11 @@ import (
 
 type WlOutput struct {
 	Version uint32
+	/**
+	 * Which output this bind is for, nil for
+	 * the global that hands out the binds.
+	 */
+	Output *VirtualOutput
 }
 
 func (o *WlOutput) WlOutput_release(s protocols.ClientState, _ protocols.ObjectID[protocols.WlOutput]) bool {
@@ -14,26 +19,50 @@ func (o *WlOutput) WlOutput_release(s protocols.ClientState, _ protocols.ObjectI
 
 func (o *WlOutput) OnBind(
 	s protocols.ClientState,
-	_ protocols.AnyObjectID,
+	name protocols.AnyObjectID,
 	_ string,
 	newId_any protocols.AnyObjectID,
 	version uint32,
 ) {
 	newID := protocols.ObjectID[protocols.WlOutput](newId_any)
-	o.Version = version
+	output := VirtualOutputByGlobalID(protocols.GlobalID(name))
+	if output == nil {
+		output = VirtualOutputs[0]
+	}
+	/**
+	 * Every output shares the same global object,
+	 * give this bind its own so we know which
+	 * output it is for later.
+	 */
+	AddObject(s, newID, &protocols.WlOutput{
+		Delegate: &WlOutput{
+			Version: version,
+			Output:  output,
+		},
+	})
 
-	protocols.WlOutput_scale(s, o.Version, newID, 1)
+	/**
+	 * Apps that know wp_fractional_scale_v1 get
+	 * the exact scale from it instead.
+	 */
+	protocols.WlOutput_scale(s, version, newID, IntegerOutputScale())
 
-	protocols.WlOutput_name(s, o.Version, newID, "term.everything Virtual Monitor")
-	protocols.WlOutput_description(s, o.Version, newID, "The best monitor")
+	protocols.WlOutput_name(s, version, newID, output.Name())
+	protocols.WlOutput_description(s, version, newID, output.Description())
 
+	/**
+	 * A --rotate output is shown upright in the
+	 * terminal, so it is a portrait monitor with
+	 * no transform as far as apps can tell.
+	 */
+	size := output.DesktopSize()
 	protocols.WlOutput_geometry(
 		s,
 		newID,
+		output.X,
 		0,
-		0,
-		int32(VirtualMonitorSize.Width),
-		int32(VirtualMonitorSize.Height),
+		int32(size.Width),
+		int32(size.Height),
 		int32(protocols.WlOutputSubpixel_enum_unknown),
 		"Very Good",
 		"The best model",
@@ -44,8 +73,8 @@ func (o *WlOutput) OnBind(
 		s,
 		newID,
 		protocols.WlOutputMode_enum_current,
-		int32(VirtualMonitorSize.Width),
-		int32(VirtualMonitorSize.Height),
+		int32(size.Width),
+		int32(size.Height),
 		60_000,
 	)
 
@@ -12,6 +12,33 @@ type WlPointer struct {
 
 	WindowX float32
 	WindowY float32
+
+	/**
+	 * Last shape set via wp_cursor_shape_device_v1.set_shape,
+	 * nil if the cursor is a surface (or was never set).
+	 */
+	CursorShape *protocols.WpCursorShapeDeviceV1Shape_enum
+}
+
+/**
+ * Stop using the client's cursor surface (if any)
+ * as the cursor.
+ */
+func (p *WlPointer) RemoveCursorSurface(s protocols.ClientState) {
+	pointerSurfaceID, ok := p.PointerSurfaceID[s]
+	if !ok || pointerSurfaceID == nil {
+		return
+	}
+	if oldPointerSurface := GetWlSurfaceObject(s, *pointerSurfaceID); oldPointerSurface != nil {
+		oldPointerSurface.Texture = nil
+		if oldPointerSurface.Role != nil {
+			if _, isCursor := oldPointerSurface.Role.(*SurfaceRoleCursor); isCursor {
+				oldPointerSurface.Role = nil
+			}
+
+		}
+	}
+	p.PointerSurfaceID[s] = nil
 }
 
 func (p *WlPointer) WlPointer_set_cursor(
@@ -31,20 +58,17 @@ func (p *WlPointer) WlPointer_set_cursor(
 	//   return;
 	// }
 
-	pointerSurfaceID, ok := p.PointerSurfaceID[s]
-	if ok && !AreSame(pointerSurfaceID, surface_id) {
-		if oldPointerSurface := GetWlSurfaceObject(s, *pointerSurfaceID); oldPointerSurface != nil {
-			oldPointerSurface.Texture = nil
-			if oldPointerSurface.Role != nil {
-				if _, isCursor := oldPointerSurface.Role.(*SurfaceRoleCursor); isCursor {
-					oldPointerSurface.Role = nil
-				}
-
-			}
-		}
+	pointerSurfaceID := p.PointerSurfaceID[s]
+	if !AreSame(pointerSurfaceID, surface_id) {
+		p.RemoveCursorSurface(s)
 	}
 
 	p.PointerSurfaceID[s] = surface_id
+	/**
+	 * A surface replaces the cursor shape
+	 * set from wp_cursor_shape_device_v1
+	 */
+	p.CursorShape = nil
 
 	if surface_id == nil {
 		return
@@ -1,6 +1,8 @@
 package wayland
 
 import (
+	"image"
+
 	"github.com/mmulet/term.everything/wayland/protocols"
 )
 
@@ -9,7 +11,12 @@ func auto_release(s protocols.ClientState,
 	return true
 }
 
+/**
+ * A region is a list of rectangles that don't
+ * overlap, in surface (logical) coordinates.
+ */
 type WlRegion struct {
+	Rects []image.Rectangle
 }
 
 func (r *WlRegion) WlRegion_destroy(
@@ -27,18 +34,43 @@ func (r *WlRegion) WlRegion_add(
 	width int32,
 	height int32,
 ) {
-	// TODO: Implement proper region union semantics.
+	rect := regionRect(x, y, width, height)
+	if rect.Empty() {
+		return
+	}
+	/**
+	 * Only add the parts that aren't in
+	 * the region already.
+	 */
+	pieces := []image.Rectangle{rect}
+	for _, existing := range r.Rects {
+		pieces = subtractRects(pieces, existing)
+	}
+	r.Rects = append(r.Rects, pieces...)
 }
 
 func (r *WlRegion) WlRegion_subtract(
 	_ protocols.ClientState,
 	_ protocols.ObjectID[protocols.WlRegion],
-	_x int32,
-	_y int32,
-	_width int32,
-	_height int32,
+	x int32,
+	y int32,
+	width int32,
+	height int32,
 ) {
-	// TODO: Implement proper region subtraction semantics.
+	rect := regionRect(x, y, width, height)
+	if rect.Empty() {
+		return
+	}
+	r.Rects = subtractRects(r.Rects, rect)
+}
+
+/**
+ * A copy, for surfaces, since the
+ * region can change (or be destroyed)
+ * after it is set.
+ */
+func (r *WlRegion) Snapshot() []image.Rectangle {
+	return append([]image.Rectangle{}, r.Rects...)
 }
 
 func (r *WlRegion) OnBind(
@@ -51,6 +83,51 @@ func (r *WlRegion) OnBind(
 	// No-op for wl_region
 }
 
+/**
+ * Apps use huge sizes for "everything",
+ * do the math in 64 bits so it doesn't
+ * wrap around.
+ */
+func regionRect(x, y, width, height int32) image.Rectangle {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(
+		int(x), int(y),
+		int(min(int64(x)+int64(width), 1<<31-1)),
+		int(min(int64(y)+int64(height), 1<<31-1)),
+	)
+}
+
+/**
+ * Every rect in rects, minus hole. Each rect
+ * is split into at most 4 pieces: the bands
+ * above and below the hole, and the parts
+ * left and right of it.
+ */
+func subtractRects(rects []image.Rectangle, hole image.Rectangle) []image.Rectangle {
+	out := make([]image.Rectangle, 0, len(rects))
+	for _, r := range rects {
+		if !r.Overlaps(hole) {
+			out = append(out, r)
+			continue
+		}
+		inner := r.Intersect(hole)
+		pieces := [4]image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, inner.Min.Y),
+			image.Rect(r.Min.X, inner.Max.Y, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, inner.Min.Y, inner.Min.X, inner.Max.Y),
+			image.Rect(inner.Max.X, inner.Min.Y, r.Max.X, inner.Max.Y),
+		}
+		for _, p := range pieces {
+			if !p.Empty() {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
 func MakeWlRegion() *protocols.WlRegion {
 	return &protocols.WlRegion{
 		Delegate: &WlRegion{},
@@ -31,6 +31,14 @@ func (w *WlRegistryDelegateImpl) WlRegistry_bind(s protocols.ClientState, object
 		// const set = s.global_binds.get(name) ?? new Set();
 		// set.add(id_id);
 		// s.global_binds.set(name, set);
+	default:
+		/**
+		 * All outputs share the same binds,
+		 * see WlOutput.Output for which is which.
+		 */
+		if VirtualOutputByGlobalID(protocols.GlobalID(name)) != nil {
+			s.AddGlobalWlOutputBind(protocols.ObjectID[protocols.WlOutput](idID), version)
+		}
 	}
 	// TODO turn this back on
 	//  if (wayland_debug_time_only()) {
@@ -49,7 +49,9 @@ func (s *WlShm) OnBind(
 	// ) {
 	newID := protocols.ObjectID[protocols.WlShm](newId_any)
 
-	protocols.WlShm_format(cs, newID, protocols.WlShmFormat_enum_argb8888)
+	for _, format := range ShmFormats {
+		protocols.WlShm_format(cs, newID, format)
+	}
 }
 
 // Helper to construct a protocol object with this delegate (like static make() in TS)
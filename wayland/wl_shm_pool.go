@@ -31,7 +31,7 @@ type WlShmPool struct {
 
 func (p *WlShmPool) WlShmPool_create_buffer(
 	s protocols.ClientState,
-	_objectID protocols.ObjectID[protocols.WlShmPool],
+	objectID protocols.ObjectID[protocols.WlShmPool],
 	id protocols.ObjectID[protocols.WlBuffer],
 	offset int32,
 	width int32,
@@ -39,6 +39,15 @@ func (p *WlShmPool) WlShmPool_create_buffer(
 	stride int32,
 	format protocols.WlShmFormat_enum,
 ) {
+	shmFormat, ok := GetShmFormat(format)
+	if !ok {
+		SendError(s, objectID, protocols.WlShmError_enum_invalid_format, fmt.Sprintf("unsupported format 0x%x", uint32(format)))
+		return
+	}
+	if width <= 0 || height <= 0 || offset < 0 || stride < width*int32(shmFormat.BytesPerPixel) {
+		SendError(s, objectID, protocols.WlShmError_enum_invalid_stride, "invalid size or stride")
+		return
+	}
 	buf := &protocols.WlBuffer{
 		Delegate: p,
 	}
@@ -3,15 +3,25 @@ package wayland
 import (
 	"fmt"
 	"image"
+	"math"
 
 	"github.com/mmulet/term.everything/wayland/protocols"
 )
 
+/**
+ * The surface's last buffer, turned the right
+ * way up (see BufferTransform).
+ */
 type Texture struct {
 	Stride uint32
 	Width  uint32
 	Height uint32
 	Data   []byte
+	/**
+	 * The buffer transform that was undone
+	 * when copying into Data.
+	 */
+	Transform protocols.WlOutputTransform_enum
 }
 
 func (t *Texture) AsRGBA() *image.RGBA {
@@ -55,20 +65,167 @@ type WlSurface struct {
 	 */
 	InputRegion *protocols.ObjectID[protocols.WlRegion]
 	/**
-	 * Unlink opaque region, null means empty!
+	 * Unlike the input region, null means empty!
+	 * Copied from the wl_region when it is set,
+	 * see Desktop.DrawClients
 	 */
-	OpaqueRegion *protocols.ObjectID[protocols.WlRegion]
+	OpaqueRegion []image.Rectangle
 
 	PendingUpdate SurfaceUpdate
 
 	Offset Point
 
 	/**
-	 * Don't care about regions for now.
-	 * Just need to clear this when the surface has
-	 * been drawn.
+	 * Damage (in buffer coordinates) from commits
+	 * since a buffer was last copied into Texture.
+	 */
+	BufferDamage []image.Rectangle
+
+	/**
+	 * Parts of Texture that changed since the
+	 * desktop last drew this surface.
+	 */
+	TextureDamage []image.Rectangle
+
+	/**
+	 * Set with wp_viewport
+	 */
+	Viewport SurfaceViewport
+	/**
+	 * A surface can only have one wp_viewport
+	 * and one wp_fractional_scale_v1.
 	 */
-	Damaged bool
+	ViewportObject     *protocols.ObjectID[protocols.WpViewport]
+	HasFractionalScale bool
+
+	/**
+	 * Texture resampled to the size it is drawn
+	 * at on the desktop, nil when they are
+	 * the same size.
+	 */
+	ScaledTexture *ScaledTexture
+}
+
+/**
+ * From wp_viewport, nil fields are unset.
+ */
+type SurfaceViewport struct {
+	Source      *ViewportSource
+	Destination *Size
+}
+
+/**
+ * In surface coordinates (the buffer divided
+ * by the buffer scale), or texture pixels
+ * for SourceRect.
+ */
+type ViewportSource struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+func (v ViewportSource) IsWholePixels() bool {
+	return v.X == math.Trunc(v.X) && v.Y == math.Trunc(v.Y) &&
+		v.Width == math.Trunc(v.Width) && v.Height == math.Trunc(v.Height)
+}
+
+/**
+ * Rounded out to whole pixels
+ */
+func (v ViewportSource) Rectangle() image.Rectangle {
+	return image.Rect(
+		int(math.Floor(v.X)),
+		int(math.Floor(v.Y)),
+		int(math.Ceil(v.X+v.Width)),
+		int(math.Ceil(v.Y+v.Height)),
+	)
+}
+
+/**
+ * The part of Texture that is shown, in texture
+ * pixels: the wp_viewport source if there is
+ * one, otherwise all of it.
+ */
+func (w *WlSurface) SourceRect() ViewportSource {
+	if w.Texture == nil {
+		return ViewportSource{}
+	}
+	if source := w.Viewport.Source; source != nil {
+		scale := float64(max(w.BufferScale, 1))
+		return ViewportSource{
+			X:      source.X * scale,
+			Y:      source.Y * scale,
+			Width:  source.Width * scale,
+			Height: source.Height * scale,
+		}
+	}
+	return ViewportSource{
+		Width:  float64(w.Texture.Width),
+		Height: float64(w.Texture.Height),
+	}
+}
+
+/**
+ * The size of the surface in logical pixels:
+ * the wp_viewport destination if there is one,
+ * then the wp_viewport source, otherwise the
+ * buffer divided by its scale.
+ */
+func (w *WlSurface) LogicalSize() Size {
+	if w.Viewport.Destination != nil {
+		return *w.Viewport.Destination
+	}
+	if w.Texture == nil {
+		return Size{}
+	}
+	if source := w.Viewport.Source; source != nil {
+		return Size{
+			Width:  uint32(source.Width),
+			Height: uint32(source.Height),
+		}
+	}
+	scale := uint32(max(w.BufferScale, 1))
+	return Size{
+		Width:  w.Texture.Width / scale,
+		Height: w.Texture.Height / scale,
+	}
+}
+
+/**
+ * After a commit, the wp_viewport errors that
+ * depend on the buffer: a fractional source
+ * size with no destination, or a source
+ * that is outside the buffer.
+ */
+func (w *WlSurface) CheckViewport(s protocols.ClientState) {
+	source := w.Viewport.Source
+	if w.ViewportObject == nil || source == nil || w.Texture == nil {
+		return
+	}
+	if w.Viewport.Destination == nil &&
+		(source.Width != math.Trunc(source.Width) || source.Height != math.Trunc(source.Height)) {
+		SendError(s, *w.ViewportObject, protocols.WpViewportError_enum_bad_size, "source size is not an integer")
+		return
+	}
+	rect := w.SourceRect()
+	if rect.X+rect.Width > float64(w.Texture.Width) || rect.Y+rect.Height > float64(w.Texture.Height) {
+		SendError(s, *w.ViewportObject, protocols.WpViewportError_enum_out_of_buffer, "source rectangle extends outside of the buffer")
+	}
+}
+
+/**
+ * The pending viewport, starting from the
+ * current one the first time it is changed
+ * before a commit.
+ */
+func (w *WlSurface) PendingViewport() *SurfaceViewport {
+	if w.PendingUpdate.Viewport == nil {
+		viewport := w.Viewport
+		w.PendingUpdate.Viewport = &viewport
+	}
+	return w.PendingUpdate.Viewport
 }
 
 func (w *WlSurface) ClearRoleData() {
@@ -161,18 +318,28 @@ func (w *WlSurface) WlSurface_damage(
 
 func (w *WlSurface) WlSurface_frame(
 	s protocols.ClientState,
-	_ protocols.ObjectID[protocols.WlSurface],
+	object_id protocols.ObjectID[protocols.WlSurface],
 	callback protocols.ObjectID[protocols.WlCallback],
 ) {
-	s.AddFrameDrawRequest(callback)
+	s.AddFrameDrawRequest(object_id, callback)
 }
 
 func (w *WlSurface) WlSurface_set_opaque_region(
-	_ protocols.ClientState,
+	s protocols.ClientState,
 	_ protocols.ObjectID[protocols.WlSurface],
 	region *protocols.ObjectID[protocols.WlRegion],
 ) {
-	w.PendingUpdate.OpaqueRegion = region
+	/**
+	 * The region is copied now, the app
+	 * usually destroys it right after.
+	 */
+	opaque := []image.Rectangle{}
+	if region != nil {
+		if r := GetWlRegionObject(s, *region); r != nil {
+			opaque = r.Snapshot()
+		}
+	}
+	w.PendingUpdate.OpaqueRegion = &opaque
 }
 
 func (w *WlSurface) WlSurface_set_input_region(
@@ -193,6 +360,7 @@ func (w *WlSurface) WlSurface_commit(
 	for _, upd := range pendingBufferTextureUpdates {
 		CopyBufferToWlSurfaceTexture(s, upd.Surface, upd.ZIndex, upd.Buffer)
 	}
+	w.CheckViewport(s)
 
 	for _, upd := range pendingBufferTextureUpdates {
 		/**
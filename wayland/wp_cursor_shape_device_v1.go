@@ -0,0 +1,122 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type WpCursorShapeDeviceV1 struct {
+	/**
+	 * nil for tablet tools, which we
+	 * don't support.
+	 */
+	Pointer *protocols.ObjectID[protocols.WlPointer]
+}
+
+func (d *WpCursorShapeDeviceV1) WpCursorShapeDeviceV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpCursorShapeDeviceV1],
+) bool {
+	return true
+}
+
+func (d *WpCursorShapeDeviceV1) WpCursorShapeDeviceV1_set_shape(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.WpCursorShapeDeviceV1],
+	_ uint32, // serial - TODO: validate most recent serial if/when tracked
+	shape protocols.WpCursorShapeDeviceV1Shape_enum,
+) {
+	if _, ok := CursorShapeNames[shape]; !ok {
+		SendError(s, objectID, protocols.WpCursorShapeDeviceV1Error_enum_invalid_shape, "invalid shape")
+		return
+	}
+	if d.Pointer == nil {
+		return
+	}
+	/**
+	 * From the docs:
+	 * If any, the previous cursor image (surface or shape) is replaced.
+	 */
+	Pointer.RemoveCursorSurface(s)
+	Pointer.CursorShape = &shape
+}
+
+func (d *WpCursorShapeDeviceV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeWpCursorShapeDeviceV1(pointer *protocols.ObjectID[protocols.WlPointer]) *protocols.WpCursorShapeDeviceV1 {
+	return &protocols.WpCursorShapeDeviceV1{
+		Delegate: &WpCursorShapeDeviceV1{
+			Pointer: pointer,
+		},
+	}
+}
+
+/**
+ * The shapes are named after the CSS cursor names,
+ * which are also the names terminals accept in
+ * OSC 22 (set pointer shape).
+ */
+var CursorShapeNames = map[protocols.WpCursorShapeDeviceV1Shape_enum]string{
+	protocols.WpCursorShapeDeviceV1Shape_enum_default_:      "default",
+	protocols.WpCursorShapeDeviceV1Shape_enum_context_menu:  "context-menu",
+	protocols.WpCursorShapeDeviceV1Shape_enum_help:          "help",
+	protocols.WpCursorShapeDeviceV1Shape_enum_pointer:       "pointer",
+	protocols.WpCursorShapeDeviceV1Shape_enum_progress:      "progress",
+	protocols.WpCursorShapeDeviceV1Shape_enum_wait:          "wait",
+	protocols.WpCursorShapeDeviceV1Shape_enum_cell:          "cell",
+	protocols.WpCursorShapeDeviceV1Shape_enum_crosshair:     "crosshair",
+	protocols.WpCursorShapeDeviceV1Shape_enum_text:          "text",
+	protocols.WpCursorShapeDeviceV1Shape_enum_vertical_text: "vertical-text",
+	protocols.WpCursorShapeDeviceV1Shape_enum_alias:         "alias",
+	protocols.WpCursorShapeDeviceV1Shape_enum_copy:          "copy",
+	protocols.WpCursorShapeDeviceV1Shape_enum_move:          "move",
+	protocols.WpCursorShapeDeviceV1Shape_enum_no_drop:       "no-drop",
+	protocols.WpCursorShapeDeviceV1Shape_enum_not_allowed:   "not-allowed",
+	protocols.WpCursorShapeDeviceV1Shape_enum_grab:          "grab",
+	protocols.WpCursorShapeDeviceV1Shape_enum_grabbing:      "grabbing",
+	protocols.WpCursorShapeDeviceV1Shape_enum_e_resize:      "e-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_n_resize:      "n-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_ne_resize:     "ne-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_nw_resize:     "nw-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_s_resize:      "s-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_se_resize:     "se-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_sw_resize:     "sw-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_w_resize:      "w-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_ew_resize:     "ew-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_ns_resize:     "ns-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_nesw_resize:   "nesw-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_nwse_resize:   "nwse-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_col_resize:    "col-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_row_resize:    "row-resize",
+	protocols.WpCursorShapeDeviceV1Shape_enum_all_scroll:    "all-scroll",
+	protocols.WpCursorShapeDeviceV1Shape_enum_zoom_in:       "zoom-in",
+	protocols.WpCursorShapeDeviceV1Shape_enum_zoom_out:      "zoom-out",
+}
+
+/**
+ * The cursor is a shape the terminal can show
+ * itself (see CursorShapeName), not a
+ * surface that has to be drawn.
+ */
+func CursorIsShape() bool {
+	return Pointer.CursorShape != nil
+}
+
+/**
+ * The css name of the current cursor shape
+ */
+func CursorShapeName() string {
+	if Pointer.CursorShape == nil {
+		return "default"
+	}
+	if name, ok := CursorShapeNames[*Pointer.CursorShape]; ok {
+		return name
+	}
+	return "default"
+}
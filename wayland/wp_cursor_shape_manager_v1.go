@@ -0,0 +1,51 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type WpCursorShapeManagerV1 struct{}
+
+func (m *WpCursorShapeManagerV1) WpCursorShapeManagerV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpCursorShapeManagerV1],
+) bool {
+	return true
+}
+
+func (m *WpCursorShapeManagerV1) WpCursorShapeManagerV1_get_pointer(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpCursorShapeManagerV1],
+	cursorShapeDevice protocols.ObjectID[protocols.WpCursorShapeDeviceV1],
+	pointer protocols.ObjectID[protocols.WlPointer],
+) {
+	AddObject(s, cursorShapeDevice, MakeWpCursorShapeDeviceV1(&pointer))
+}
+
+func (m *WpCursorShapeManagerV1) WpCursorShapeManagerV1_get_tablet_tool_v2(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpCursorShapeManagerV1],
+	cursorShapeDevice protocols.ObjectID[protocols.WpCursorShapeDeviceV1],
+	_ protocols.ObjectID[protocols.ZwpTabletToolV2],
+) {
+	/**
+	 * We don't have tablets, so this
+	 * device is inert.
+	 */
+	AddObject(s, cursorShapeDevice, MakeWpCursorShapeDeviceV1(nil))
+}
+
+func (m *WpCursorShapeManagerV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeWpCursorShapeManagerV1() *protocols.WpCursorShapeManagerV1 {
+	return &protocols.WpCursorShapeManagerV1{
+		Delegate: &WpCursorShapeManagerV1{},
+	}
+}
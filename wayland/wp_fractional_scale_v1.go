@@ -0,0 +1,36 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type WpFractionalScaleV1 struct {
+	Surface protocols.ObjectID[protocols.WlSurface]
+}
+
+func (f *WpFractionalScaleV1) WpFractionalScaleV1_destroy(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpFractionalScaleV1],
+) bool {
+	if surface := GetWlSurfaceObject(s, f.Surface); surface != nil {
+		surface.HasFractionalScale = false
+	}
+	return true
+}
+
+func (f *WpFractionalScaleV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeWpFractionalScaleV1(surface protocols.ObjectID[protocols.WlSurface]) *protocols.WpFractionalScaleV1 {
+	return &protocols.WpFractionalScaleV1{
+		Delegate: &WpFractionalScaleV1{
+			Surface: surface,
+		},
+	}
+}
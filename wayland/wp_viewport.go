@@ -0,0 +1,110 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type WpViewport struct {
+	Surface protocols.ObjectID[protocols.WlSurface]
+}
+
+/**
+ * nil (and a no_surface error) once
+ * the wl_surface is gone.
+ */
+func (v *WpViewport) surface(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.WpViewport],
+) *WlSurface {
+	surface := GetWlSurfaceObject(s, v.Surface)
+	if surface == nil {
+		SendError(s, objectID, protocols.WpViewportError_enum_no_surface, "the wl_surface was destroyed")
+	}
+	return surface
+}
+
+func (v *WpViewport) WpViewport_destroy(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.WpViewport],
+) bool {
+	/**
+	 * From the docs:
+	 * The associated wl_surface's crop and scale state is
+	 * removed. The change is applied on the next
+	 * wl_surface.commit.
+	 */
+	if surface := GetWlSurfaceObject(s, v.Surface); surface != nil {
+		surface.ViewportObject = nil
+		*surface.PendingViewport() = SurfaceViewport{}
+	}
+	return true
+}
+
+func (v *WpViewport) WpViewport_set_source(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.WpViewport],
+	x protocols.Fixed,
+	y protocols.Fixed,
+	width protocols.Fixed,
+	height protocols.Fixed,
+) {
+	surface := v.surface(s, objectID)
+	if surface == nil {
+		return
+	}
+	if x == -1 && y == -1 && width == -1 && height == -1 {
+		surface.PendingViewport().Source = nil
+		return
+	}
+	if x < 0 || y < 0 || width <= 0 || height <= 0 {
+		SendError(s, objectID, protocols.WpViewportError_enum_bad_value, "invalid source rectangle")
+		return
+	}
+	surface.PendingViewport().Source = &ViewportSource{
+		X:      float64(x),
+		Y:      float64(y),
+		Width:  float64(width),
+		Height: float64(height),
+	}
+}
+
+func (v *WpViewport) WpViewport_set_destination(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.WpViewport],
+	width int32,
+	height int32,
+) {
+	surface := v.surface(s, objectID)
+	if surface == nil {
+		return
+	}
+	if width == -1 && height == -1 {
+		surface.PendingViewport().Destination = nil
+		return
+	}
+	if width <= 0 || height <= 0 {
+		SendError(s, objectID, protocols.WpViewportError_enum_bad_value, "invalid destination size")
+		return
+	}
+	surface.PendingViewport().Destination = &Size{
+		Width:  uint32(width),
+		Height: uint32(height),
+	}
+}
+
+func (v *WpViewport) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeWpViewport(surface protocols.ObjectID[protocols.WlSurface]) *protocols.WpViewport {
+	return &protocols.WpViewport{
+		Delegate: &WpViewport{
+			Surface: surface,
+		},
+	}
+}
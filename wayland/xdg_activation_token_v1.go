@@ -0,0 +1,67 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type XdgActivationTokenV1 struct {
+	AppID     string
+	Committed bool
+}
+
+func (t *XdgActivationTokenV1) XdgActivationTokenV1_set_serial(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationTokenV1],
+	_ uint32, // serial
+	_ protocols.ObjectID[protocols.WlSeat],
+) {
+}
+
+func (t *XdgActivationTokenV1) XdgActivationTokenV1_set_app_id(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationTokenV1],
+	appID string,
+) {
+	t.AppID = appID
+}
+
+func (t *XdgActivationTokenV1) XdgActivationTokenV1_set_surface(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationTokenV1],
+	_ protocols.ObjectID[protocols.WlSurface],
+) {
+}
+
+func (t *XdgActivationTokenV1) XdgActivationTokenV1_commit(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.XdgActivationTokenV1],
+) {
+	if t.Committed {
+		SendError(s, objectID, protocols.XdgActivationTokenV1Error_enum_already_used, "token has already been committed")
+		return
+	}
+	t.Committed = true
+	protocols.XdgActivationTokenV1_done(s, objectID, NewActivationToken(t.AppID))
+}
+
+func (t *XdgActivationTokenV1) XdgActivationTokenV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationTokenV1],
+) bool {
+	return true
+}
+
+func (t *XdgActivationTokenV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeXdgActivationTokenV1() *protocols.XdgActivationTokenV1 {
+	return &protocols.XdgActivationTokenV1{
+		Delegate: &XdgActivationTokenV1{},
+	}
+}
@@ -0,0 +1,152 @@
+package wayland
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+/**
+ * Tokens handed out by xdg_activation_token_v1.commit
+ * (or NewActivationToken for apps we launch), mapped
+ * to the app_id they were created for (if any).
+ *
+ * Shared by all clients, because the whole point is
+ * to pass a token from one client to another.
+ */
+var activationTokens = struct {
+	sync.Mutex
+	tokens map[string]activationToken
+}{
+	tokens: make(map[string]activationToken),
+}
+
+type activationToken struct {
+	AppID    string
+	IssuedAt time.Time
+}
+
+/**
+ * Tokens that haven't been used by then are
+ * forgotten, long enough for a launched
+ * app to start up.
+ */
+const activationTokenLifetime = 2 * time.Minute
+
+/**
+ * So a client can't grow the map forever by
+ * asking for tokens, the oldest go first.
+ */
+const maxActivationTokens = 256
+
+/**
+ * Make a new activation token, for example to pass
+ * in XDG_ACTIVATION_TOKEN to an app we launch.
+ */
+func NewActivationToken(appID string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	activationTokens.Lock()
+	defer activationTokens.Unlock()
+	pruneActivationTokens(time.Now())
+	activationTokens.tokens[token] = activationToken{AppID: appID, IssuedAt: time.Now()}
+	return token
+}
+
+/**
+ * Drop expired tokens, and the oldest until there
+ * is room for one more. Must hold activationTokens.
+ */
+func pruneActivationTokens(now time.Time) {
+	for token, issued := range activationTokens.tokens {
+		if now.Sub(issued.IssuedAt) >= activationTokenLifetime {
+			delete(activationTokens.tokens, token)
+		}
+	}
+	for len(activationTokens.tokens) >= maxActivationTokens {
+		oldest := ""
+		for token, issued := range activationTokens.tokens {
+			if oldest == "" || issued.IssuedAt.Before(activationTokens.tokens[oldest].IssuedAt) {
+				oldest = token
+			}
+		}
+		delete(activationTokens.tokens, oldest)
+	}
+}
+
+/**
+ * Returns true if the token was issued by us and
+ * hasn't expired, tokens can only be used once.
+ */
+func UseActivationToken(token string) bool {
+	activationTokens.Lock()
+	defer activationTokens.Unlock()
+	issued, ok := activationTokens.tokens[token]
+	delete(activationTokens.tokens, token)
+	return ok && time.Since(issued.IssuedAt) < activationTokenLifetime
+}
+
+type XdgActivationV1 struct{}
+
+func (a *XdgActivationV1) XdgActivationV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationV1],
+) bool {
+	return true
+}
+
+func (a *XdgActivationV1) XdgActivationV1_get_activation_token(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationV1],
+	id protocols.ObjectID[protocols.XdgActivationTokenV1],
+) {
+	AddObject(s, id, MakeXdgActivationTokenV1())
+}
+
+func (a *XdgActivationV1) XdgActivationV1_activate(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.XdgActivationV1],
+	token string,
+	surfaceID protocols.ObjectID[protocols.WlSurface],
+) {
+	/**
+	 * From the docs:
+	 * Compositors can ignore unknown activation tokens
+	 *
+	 * We don't ignore them, this is only used to get
+	 * the user's attention (it never steals focus), and
+	 * apps often get their tokens from somewhere else
+	 * (like the desktop that launched term.everything).
+	 */
+	UseActivationToken(token)
+
+	surface := GetWlSurfaceObject(s, surfaceID)
+	if surface == nil {
+		return
+	}
+	role, ok := surface.Role.(*SurfaceRoleXdgToplevel)
+	if !ok || role.Data == nil {
+		return
+	}
+	s.AddActivationRequest(*role.Data)
+}
+
+func (a *XdgActivationV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeXdgActivationV1() *protocols.XdgActivationV1 {
+	return &protocols.XdgActivationV1{
+		Delegate: &XdgActivationV1{},
+	}
+}
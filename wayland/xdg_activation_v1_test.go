@@ -0,0 +1,46 @@
+package wayland
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivationTokens(t *testing.T) {
+	token := NewActivationToken("app")
+	if !UseActivationToken(token) {
+		t.Fatal("a new token should be usable")
+	}
+	if UseActivationToken(token) {
+		t.Fatal("a token should only be usable once")
+	}
+	if UseActivationToken("not-a-token") {
+		t.Fatal("unknown tokens should not be usable")
+	}
+
+	expired := NewActivationToken("")
+	activationTokens.Lock()
+	activationTokens.tokens[expired] = activationToken{IssuedAt: time.Now().Add(-activationTokenLifetime)}
+	activationTokens.Unlock()
+	if UseActivationToken(expired) {
+		t.Fatal("expired tokens should not be usable")
+	}
+}
+
+func TestActivationTokensAreCapped(t *testing.T) {
+	first := NewActivationToken("")
+	activationTokens.Lock()
+	activationTokens.tokens[first] = activationToken{IssuedAt: time.Now().Add(-time.Second)}
+	activationTokens.Unlock()
+	for range maxActivationTokens * 2 {
+		NewActivationToken("")
+	}
+	activationTokens.Lock()
+	count := len(activationTokens.tokens)
+	activationTokens.Unlock()
+	if count > maxActivationTokens {
+		t.Fatalf("%d tokens kept, want at most %d", count, maxActivationTokens)
+	}
+	if UseActivationToken(first) {
+		t.Fatal("the oldest token should have been dropped")
+	}
+}
@@ -68,7 +68,8 @@ func (x *XdgPopup) XdgPopup_reposition(
 	 * @TODO figure out what
 	 * these values are
 	 */
-	protocols.XdgPopup_configure(s, object_id, 0, 0, int32(VirtualMonitorSize.Width), int32(VirtualMonitorSize.Height))
+	logicalSize := GetVirtualOutput(CurrentVirtualOutput()).LogicalSize()
+	protocols.XdgPopup_configure(s, object_id, 0, 0, int32(logicalSize.Width), int32(logicalSize.Height))
 
 	surface := GetSurfaceFromRole(s, object_id)
 	if surface == nil {
@@ -113,19 +113,29 @@ func (x *XdgSurface) XdgSurface_get_toplevel(
 	}
 
 	surfaceRole.Data = &id
-	AddObject(s, id, MakeXdgToplevel())
+	toplevel := MakeXdgToplevel(x.Version)
+	/**
+	 * New windows open on the output and
+	 * workspace shown in the terminal.
+	 */
+	output := GetVirtualOutput(CurrentVirtualOutput())
+	toplevel.Delegate.(*XdgToplevel).Output = output.Index
+	toplevel.Delegate.(*XdgToplevel).Workspace = CurrentWorkspace()
+	AddObject(s, id, toplevel)
 
 	RegisterRoleToSurface(s, id, *surface_id)
 	s.TopLevelSurfaces()[id] = true
 
+	logicalSize := output.LogicalSize()
 	protocols.XdgToplevel_configure(
 		s,
 		id,
-		int32(VirtualMonitorSize.Width),
-		int32(VirtualMonitorSize.Height),
-		ToBytes([]protocols.XdgToplevelState_enum{
+		int32(logicalSize.Width),
+		int32(logicalSize.Height),
+		ToplevelStatesToBytes([]protocols.XdgToplevelState_enum{
 			protocols.XdgToplevelState_enum_maximized,
 			protocols.XdgToplevelState_enum_fullscreen,
+			protocols.XdgToplevelState_enum_activated,
 		}),
 	)
 
@@ -134,20 +144,14 @@ func (x *XdgSurface) XdgSurface_get_toplevel(
 
 	// TODO should this be here
 
-	if output_binds := protocols.GetGlobalWlOutputBinds(s); output_binds != nil {
-		for output_id, _ := range output_binds {
-			protocols.WlSurface_enter(s, *surface_id, output_id)
-		}
-	}
+	SendSurfaceOutputEvent(s, *surface_id, output.Index, true)
 	serial := GlobalEnterSerial
 	GlobalEnterSerial += 1
 
-	if keyboard_binds := protocols.GetGlobalWlKeyboardBinds(s); keyboard_binds != nil {
-
-		for keyboard_id, _ := range keyboard_binds {
-			protocols.WlKeyboard_enter(s, keyboard_id, serial, *surface_id, []byte{})
-		}
-	}
+	/**
+	 * The keyboard is moved to the new window
+	 * by SetKeyboardFocus, once it is drawn.
+	 */
 
 	if pointer_binds := protocols.GetGlobalWlPointerBinds(s); pointer_binds != nil {
 		for pointer_id, version := range pointer_binds {
@@ -250,12 +254,13 @@ func (x *XdgSurface) XdgSurface_get_popup(
 
 	RegisterRoleToSurface(s, id, *surface_id)
 
+	logicalSize := GetVirtualOutput(CurrentVirtualOutput()).LogicalSize()
 	protocols.XdgPopup_configure(
 		s,
 		id,
 		0, 0,
-		int32(VirtualMonitorSize.Width),
-		int32(VirtualMonitorSize.Height),
+		int32(logicalSize.Width),
+		int32(logicalSize.Height),
 	)
 }
 
@@ -1,6 +1,8 @@
 package wayland
 
 import (
+	"encoding/binary"
+
 	"github.com/mmulet/term.everything/wayland/protocols"
 )
 
@@ -10,6 +12,11 @@ type PendingToplevelState struct {
 }
 
 type XdgToplevel struct {
+	/**
+	 * Of the xdg_wm_base it was made from
+	 */
+	Version uint32
+
 	Parent *protocols.ObjectID[protocols.XdgToplevel]
 
 	Title *string
@@ -17,10 +24,44 @@ type XdgToplevel struct {
 
 	Maximized  bool
 	Fullscreen bool
+	/**
+	 * Only the toplevel with the keyboard is activated,
+	 * and only while the terminal term.everything is
+	 * running in has focus (see SetKeyboardFocus).
+	 */
+	Activated bool
 
 	MinSize *Size
 	MaxSize *Size
 
+	/**
+	 * Set when the window gets less than the whole
+	 * monitor (ex: a tiling pane), nil for the
+	 * whole VirtualMonitorSize. In logical pixels.
+	 */
+	ConfiguredSize *Size
+
+	/**
+	 * Index into VirtualOutputs
+	 */
+	Output int
+
+	/**
+	 * 0 based, see WorkspaceCount
+	 */
+	Workspace int
+
+	/**
+	 * Not shown in the terminal (another workspace
+	 * or output), only sent to xdg_wm_base v6+.
+	 */
+	Suspended bool
+
+	/**
+	 * Higher is drawn on top, see RaiseToplevel
+	 */
+	Stacking uint64
+
 	PendingState *PendingToplevelState
 }
 
@@ -30,6 +71,7 @@ func (t *XdgToplevel) XdgToplevel_destroy(
 ) bool {
 	surface := GetSurfaceFromRole(s, objectID)
 
+	forgetKeyboardFocus(s, objectID)
 	UnregisterRoleToSurface(s, objectID)
 	s.TopLevelSurfaces()[objectID] = false
 	if surface != nil {
@@ -187,6 +229,65 @@ func (t *XdgToplevel) XdgToplevel_set_minimized(
 	// TODO: Implement minimize behavior if desired
 }
 
+/**
+ * Send a configure with the activated state
+ * added or removed, keeping maximized and fullscreen
+ * as they are. Must hold the client's lock, the
+ * configure is sent right away so it can't
+ * arrive after a newer one.
+ */
+func (t *XdgToplevel) SetActivated(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.XdgToplevel],
+	activated bool,
+) {
+	if t.Activated == activated {
+		return
+	}
+	t.Activated = activated
+	t.stateConfiguration(s, objectID, t.Maximized, t.Fullscreen)
+}
+
+/**
+ * Send a configure with the suspended state
+ * added or removed. Clients older than
+ * xdg_wm_base v6 don't know it, so they
+ * only stop getting frame callbacks.
+ * Must hold the client's lock, see SetActivated.
+ */
+func (t *XdgToplevel) SetSuspended(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.XdgToplevel],
+	suspended bool,
+) {
+	if t.Suspended == suspended {
+		return
+	}
+	t.Suspended = suspended
+	if t.Version < 6 {
+		return
+	}
+	t.stateConfiguration(s, objectID, t.Maximized, t.Fullscreen)
+}
+
+/**
+ * Ask the window to be size, nil for the whole
+ * monitor, keeping the states as they are.
+ * Must hold the client's lock, see SetActivated.
+ */
+func (t *XdgToplevel) SetConfiguredSize(
+	s protocols.ClientState,
+	objectID protocols.ObjectID[protocols.XdgToplevel],
+	size *Size,
+) {
+	if t.ConfiguredSize == size ||
+		(t.ConfiguredSize != nil && size != nil && *t.ConfiguredSize == *size) {
+		return
+	}
+	t.ConfiguredSize = size
+	t.stateConfiguration(s, objectID, t.Maximized, t.Fullscreen)
+}
+
 func (t *XdgToplevel) OnBind(
 	cs protocols.ClientState,
 	_ protocols.AnyObjectID,
@@ -230,21 +331,54 @@ func (t *XdgToplevel) stateConfiguration(
 	if fullscreen {
 		states = append(states, protocols.XdgToplevelState_enum_fullscreen)
 	}
+	if t.Activated {
+		states = append(states, protocols.XdgToplevelState_enum_activated)
+	}
+	if t.Suspended && t.Version >= 6 {
+		states = append(states, protocols.XdgToplevelState_enum_suspended)
+	}
+
+	size := GetVirtualOutput(t.Output).LogicalSize()
+	if t.ConfiguredSize != nil {
+		size = *t.ConfiguredSize
+	}
 
 	protocols.XdgToplevel_configure(
 		s,
 		objectID,
-		int32(VirtualMonitorSize.Width),
-		int32(VirtualMonitorSize.Height),
-		ToBytes(states),
+		int32(size.Width),
+		int32(size.Height),
+		ToplevelStatesToBytes(states),
 	)
 	xdg_surface_State.configure(s)
 
 	return true
 }
 
-func MakeXdgToplevel() *protocols.XdgToplevel {
+/**
+ * The states of xdg_toplevel.configure are
+ * an array of uint32, not bytes.
+ */
+func ToplevelStatesToBytes(states []protocols.XdgToplevelState_enum) []byte {
+	b := make([]byte, 0, len(states)*4)
+	for _, state := range states {
+		b = binary.LittleEndian.AppendUint32(b, uint32(state))
+	}
+	return b
+}
+
+func MakeXdgToplevel(version uint32) *protocols.XdgToplevel {
 	return &protocols.XdgToplevel{
-		Delegate: &XdgToplevel{},
+		Delegate: &XdgToplevel{
+			Version:  version,
+			Stacking: NextStacking(),
+			/**
+			 * Matches the first configure
+			 * sent in xdg_surface.get_toplevel
+			 */
+			Maximized:  true,
+			Fullscreen: true,
+			Activated:  true,
+		},
 	}
 }
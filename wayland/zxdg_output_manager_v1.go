@@ -0,0 +1,76 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type ZxdgOutputManagerV1 struct {
+	/**
+	 * Of this bind, xdg_outputs get the
+	 * same version as their manager.
+	 */
+	Version uint32
+}
+
+func (m *ZxdgOutputManagerV1) ZxdgOutputManagerV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.ZxdgOutputManagerV1],
+) bool {
+	return true
+}
+
+func (m *ZxdgOutputManagerV1) ZxdgOutputManagerV1_get_xdg_output(
+	s protocols.ClientState,
+	_ protocols.ObjectID[protocols.ZxdgOutputManagerV1],
+	id protocols.ObjectID[protocols.ZxdgOutputV1],
+	outputID protocols.ObjectID[protocols.WlOutput],
+) {
+	AddObject(s, id, MakeZxdgOutputV1())
+
+	bound := GetWlOutputObject(s, outputID)
+	if bound == nil || bound.Output == nil {
+		return
+	}
+	output := bound.Output
+
+	protocols.ZxdgOutputV1_logical_position(s, id, output.X, 0)
+	logicalSize := output.LogicalSize()
+	protocols.ZxdgOutputV1_logical_size(s, id, int32(logicalSize.Width), int32(logicalSize.Height))
+	protocols.ZxdgOutputV1_name(s, m.Version, id, output.Name())
+	protocols.ZxdgOutputV1_description(s, m.Version, id, output.Description())
+	/**
+	 * From version 3 on, wl_output.done
+	 * replaces xdg_output.done
+	 */
+	if m.Version >= 3 {
+		protocols.WlOutput_done(s, bound.Version, outputID)
+	} else {
+		protocols.ZxdgOutputV1_done(s, id)
+	}
+}
+
+func (m *ZxdgOutputManagerV1) OnBind(
+	s protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	newId_any protocols.AnyObjectID,
+	version uint32,
+) {
+	/**
+	 * Like wl_output, every bind gets its own
+	 * object to remember its version.
+	 */
+	AddObject(s, protocols.ObjectID[protocols.ZxdgOutputManagerV1](newId_any), &protocols.ZxdgOutputManagerV1{
+		Delegate: &ZxdgOutputManagerV1{
+			Version: version,
+		},
+	})
+}
+
+func MakeZxdgOutputManagerV1() *protocols.ZxdgOutputManagerV1 {
+	return &protocols.ZxdgOutputManagerV1{
+		Delegate: &ZxdgOutputManagerV1{
+			Version: 1,
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package wayland
+
+import (
+	"github.com/mmulet/term.everything/wayland/protocols"
+)
+
+type ZxdgOutputV1 struct{}
+
+func (o *ZxdgOutputV1) ZxdgOutputV1_destroy(
+	_ protocols.ClientState,
+	_ protocols.ObjectID[protocols.ZxdgOutputV1],
+) bool {
+	return true
+}
+
+func (o *ZxdgOutputV1) OnBind(
+	_ protocols.ClientState,
+	_ protocols.AnyObjectID,
+	_ string,
+	_ protocols.AnyObjectID,
+	_ uint32,
+) {
+}
+
+func MakeZxdgOutputV1() *protocols.ZxdgOutputV1 {
+	return &protocols.ZxdgOutputV1{
+		Delegate: &ZxdgOutputV1{},
+	}
+}